@@ -0,0 +1,117 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package findings
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/cmd/flag"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/pubsub"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/sync"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/webhook"
+)
+
+// newSyncClient creates a sync client using the values of the command-line
+// flags, and adds the optional sinks for finding transitions.
+// Use defer Client.Close() to clean up.
+func newSyncClient(ctx context.Context, log logr.Logger, googleServiceAccount string) (*sync.Client, error) {
+	client, err := sync.NewClient(ctx, log, kubeconfig.Value(), dryRun.Value(), source.Value(), clusterName.Value(), googleServiceAccount)
+	if err != nil {
+		return nil, err
+	}
+	if stateStore.Value() != "" {
+		if err := client.SetStore(stateStore.Value(), stateResyncInterval.Value()); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	if err := client.SetSecurityMarkLabels(securityMarkLabels.Value()); err != nil {
+		client.Close()
+		return nil, err
+	}
+	client.SetClusterLocation(clusterProject.Value(), clusterLocation.Value())
+	if err := client.SetLinkTemplates(linkTemplate.Value()); err != nil {
+		client.Close()
+		return nil, err
+	}
+	if err := client.SetFindingIDStrategy(findingIDStrategy.Value()); err != nil {
+		client.Close()
+		return nil, err
+	}
+	if err := client.SetFindingGranularity(findingGranularity.Value()); err != nil {
+		client.Close()
+		return nil, err
+	}
+	if err := client.SetAuditStaleness(auditStaleness.Value(), markStaleAudits.Value()); err != nil {
+		client.Close()
+		return nil, err
+	}
+	if err := client.SetAdmissionEvents(admissionEvents.Value(), admissionFindingTTL.Value()); err != nil {
+		client.Close()
+		return nil, err
+	}
+	if err := client.SetDeactivationLimit(maxDeactivations.Value(), maxDeactivationPct.Value(), deactivationConfirms.Value()); err != nil {
+		client.Close()
+		return nil, err
+	}
+	client.SetAnnotateConstraints(annotateConstraints.Value())
+	client.SetAggregateOwners(aggregateOwners.Value())
+	client.SetPolicyReports(policyReports.Value())
+	client.SetPolicyHealth(policyHealth.Value())
+	if configResource.Value() != "" {
+		client.SetConfigResource(configResource.Value())
+	}
+	if webhookURL.Value() != "" {
+		secret, err := readSecretFile(webhookSecretFile.Value())
+		if err != nil {
+			client.Close()
+			return nil, err
+		}
+		client.AddSink(webhook.NewClient(log.WithName("webhook"), webhookURL.Value(), secret))
+	}
+	if events.Value() != flag.EventsNone {
+		if err := client.EnableEvents(events.Value() == flag.EventsAll); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	if pubsubTopic.Value() != "" {
+		pubsubClient, err := pubsub.NewClient(ctx, log.WithName("pubsub"), pubsubTopic.Value(), pubsubOrdering.Value(), googleServiceAccount)
+		if err != nil {
+			client.Close()
+			return nil, err
+		}
+		client.AddSink(pubsubClient)
+	}
+	return client, nil
+}
+
+// readSecretFile returns the contents of the file, without leading and
+// trailing whitespace. Returns nil if the path is empty.
+func readSecretFile(path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+	secret, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read secret file %s: %w", path, err)
+	}
+	return []byte(strings.TrimSpace(string(secret))), nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package findings
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/spf13/pflag"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/config"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/sync"
+)
+
+// configWatchPeriod is the time between checks for changes to the config file
+const configWatchPeriod = 10 * time.Second
+
+var (
+	// commandLineFlags are the names of flags set on the command line, these
+	// override values from the config file
+	commandLineFlags = map[string]bool{}
+
+	// loadedConfig is the config that is in effect: the config file at
+	// startup, with the settings from later changes that were applied
+	// without a restart. nil if there is no config file.
+	loadedConfig *config.Config
+)
+
+// loadConfigFile sets the values from the config file for flags that weren't
+// set on the command line. Call this before validating the flags.
+func loadConfigFile(flags *pflag.FlagSet) error {
+	if err := configFile.Validate(); err != nil || configFile.Value() == "" {
+		return err
+	}
+	flags.Visit(func(f *pflag.Flag) {
+		commandLineFlags[f.Name] = true
+	})
+	cfg, err := config.Load(configFile.Value())
+	if err != nil {
+		return err
+	}
+	if err := applyConfig(flags, cfg, nil); err != nil {
+		return err
+	}
+	loadedConfig = cfg
+	return nil
+}
+
+// applyConfig sets the flag values from the config, except for flags that
+// were set on the command line. Flags that aren't in the config are reset to
+// their default values. If names is not nil, only the named flags are set.
+func applyConfig(flags *pflag.FlagSet, cfg *config.Config, names []string) error {
+	values := cfg.FlagValues()
+	if names == nil {
+		for name := range values {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		f := flags.Lookup(name)
+		if f == nil || commandLineFlags[name] {
+			continue // flag not used by this command, or overridden
+		}
+		value, exists := values[name]
+		if sliceValue, ok := f.Value.(pflag.SliceValue); ok {
+			var items []string
+			if exists && value != "" {
+				items = strings.Split(value, config.ListSeparator(name))
+			}
+			if err := sliceValue.Replace(items); err != nil {
+				return fmt.Errorf("invalid value in config file for %s: %w", name, err)
+			}
+			continue
+		}
+		if !exists {
+			value = f.DefValue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("invalid value in config file for %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// reloadConfig applies the settings from the changed config file that don't
+// require a restart, and logs the settings that do, until they are changed
+// back to their running values. The changed settings are validated, and the
+// previous values are kept if they are invalid.
+//
+// Returns the control loop interval in seconds.
+func reloadConfig(log logr.Logger, flags *pflag.FlagSet, client *sync.Client, cfg *config.Config) (int, error) {
+	reloadable, restartRequired := config.Diff(loadedConfig, cfg)
+	for _, name := range restartRequired {
+		if !commandLineFlags[name] {
+			log.Info("config file setting changed, restart to apply", "flag", name)
+		}
+	}
+	if err := applyConfig(flags, cfg, reloadable); err != nil {
+		return interval.Value(), err
+	}
+	err := interval.Validate()
+	if err == nil {
+		err = securityMarkLabels.Validate()
+	}
+	if err == nil {
+		err = client.SetSecurityMarkLabels(securityMarkLabels.Value())
+	}
+	if err != nil {
+		if revertErr := applyConfig(flags, loadedConfig, reloadable); revertErr != nil {
+			log.Error(revertErr, "could not revert config file settings")
+		}
+		return interval.Value(), err
+	}
+	loadedConfig = config.Reloaded(loadedConfig, cfg)
+	if len(reloadable) > 0 {
+		log.Info("applied config file settings", "flags", reloadable)
+	}
+	return interval.Value(), nil
+}
@@ -20,31 +20,47 @@ import (
 
 	"github.com/go-logr/logr"
 
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/config"
 	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/sync"
 )
 
 // Start the control loop
 // The control loop retrieves Gatekeeper audit constraint violations and
 // creates a finding in Security Command Center for each violation.
-func Start(ctx context.Context, log logr.Logger, kubeconfig string, dryRun bool, sourceName, clusterName string, intervalSeconds int) error {
+//
+// Changes received on configChanges are applied between iterations using
+// the reload function, which returns the new interval in seconds.
+// configChanges can be nil. If the spec of the config resource sets an
+// interval, it overrides intervalSeconds.
+func Start(ctx context.Context, log logr.Logger, client *sync.Client, intervalSeconds int, configChanges <-chan *config.Config, reload func(*config.Config) (int, error)) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	client, err := sync.NewClient(ctx, log, kubeconfig, dryRun, sourceName, clusterName, "")
-	if err != nil {
-		return err
-	}
-	defer client.Close()
 	log.Info("Starting control loop")
 	for {
 		if err := client.Sync(ctx); err != nil {
 			log.Error(err, "sync failed")
 		}
-		select {
-		case <-ctx.Done():
-			log.Info("Stopping control loop")
-			return nil
-		case <-time.After(time.Duration(intervalSeconds) * time.Second):
-			continue
+		waitSeconds := intervalSeconds
+		if resourceInterval := client.ConfigResourceInterval(); resourceInterval > 0 {
+			waitSeconds = resourceInterval
+		}
+		timer := time.NewTimer(time.Duration(waitSeconds) * time.Second)
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				log.Info("Stopping control loop")
+				return nil
+			case cfg := <-configChanges:
+				var err error
+				intervalSeconds, err = reload(cfg)
+				if err != nil {
+					log.Error(err, "could not apply config file changes")
+				}
+			case <-timer.C:
+				break wait
+			}
 		}
 	}
 }
@@ -0,0 +1,80 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package findings
+
+import (
+	"context"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/cmd/flag"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/logging"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/print"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/sync"
+)
+
+var (
+	explainFindingFlags = flag.New(finding, findingIDStrategy, findingGranularity, aggregateOwners, kubeconfig, googleServiceAccount)
+
+	explainFindingCmd = &cobra.Command{
+		Use:   "explain",
+		Short: "Explain a finding by looking up the constraint, constraint template, and resource that caused it",
+		Long: `Explain a finding by looking up the constraint, constraint template, and
+resource that caused it, in the cluster of the current kubeconfig context.
+
+The output shows if the objects still exist, if the violation is still listed
+in the constraint audit status, and if the finding is current, superseded by a
+finding with a different ID because of a spec change, or resolved. Use the
+same --finding-id-strategy, --finding-granularity, and --aggregate-owners as
+the controller.`,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			return explainFindingFlags.Validate()
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return explainFindingRun(cmd.Context())
+		},
+	}
+)
+
+func init() {
+	explainFindingFlags.AddToFlagSet(explainFindingCmd.Flags())
+}
+
+// explainFindingRun prints the explanation of the finding as JSON
+func explainFindingRun(ctx context.Context) error {
+	log := logging.CreateStdLog("explain")
+	findingName := finding.Value()
+	sourceName := findingName[:strings.Index(findingName, "/findings/")]
+	dryRun := true
+	clusterName := ""
+	syncClient, err := sync.NewClient(ctx, log, kubeconfig.Value(), dryRun, sourceName, clusterName, googleServiceAccount.Value())
+	if err != nil {
+		return err
+	}
+	defer syncClient.Close()
+	if err := syncClient.SetFindingIDStrategy(findingIDStrategy.Value()); err != nil {
+		return err
+	}
+	if err := syncClient.SetFindingGranularity(findingGranularity.Value()); err != nil {
+		return err
+	}
+	syncClient.SetAggregateOwners(aggregateOwners.Value())
+	explanation, err := syncClient.ExplainFinding(ctx, findingName)
+	if err != nil {
+		return err
+	}
+	return print.AsJSON(explanation)
+}
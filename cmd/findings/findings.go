@@ -24,21 +24,62 @@ var (
 	// Cmd is the findings sub-command
 	Cmd = &cobra.Command{
 		Use:   "findings",
-		Short: "Synchronize Gatekeeper audit violations to Security Command Center findings",
+		Short: "Synchronize Gatekeeper audit violations to Security Command Center findings, and query the findings",
 	}
 
 	// command-line flags for findings sub-commands
+	admissionEvents      = &flag.AdmissionEvents{}           // namespace of Gatekeeper admission events reported as findings
+	admissionFindingTTL  = &flag.AdmissionFindingTTL{}       // time after the last admission denial that a finding stays active
+	aggregateOwners      = &flag.AggregateOwners{}           // one finding per constraint and top-level controller
+	annotateConstraints  = &flag.AnnotateConstraints{}       // write finding references onto constraints
+	auditStaleness       = &flag.AuditStaleness{}            // max age of the last audit of a constraint
+	category             = &flag.Category{}                  // finding category (constraint kind) filter
+	clusterLocation      = &flag.ClusterLocation{}           // location of the GKE cluster, used in links
 	clusterName          = &flag.Cluster{}                   // cluster identifier, optional
+	clusterProject       = &flag.ClusterProject{}            // project ID of the GKE cluster, used in links
+	configFile           = &flag.ConfigFile{}                // path to YAML configuration file
+	configResource       = &flag.ConfigResource{}            // name of the GatekeeperSecurityCenterConfig resource
+	deactivationConfirms = &flag.DeactivationConfirmations{} // consecutive syncs before held deactivations are applied
 	dryRun               = &flag.DryRun{}                    // skip state-changing operations
+	events               = &flag.Events{}                    // objects that receive Kubernetes Events for finding transitions
+	finding              = &flag.Finding{}                   // Security Command Center finding name
+	findingGranularity   = &flag.FindingGranularity{}        // how many violations each finding reports
+	findingIDStrategy    = &flag.FindingIDStrategy{}         // how finding IDs are determined for violations
+	findingState         = &flag.FindingState{}              // finding state filter
 	googleServiceAccount = &flag.ImpersonateServiceAccount{} // Google service account to impersonate
 	interval             = &flag.Interval{}                  // time in seconds between interations of the control loop
 	kubeconfig           = &flag.Kubeconfig{}                // path to kubeconfig, or empty to use in-cluster config
+	linkTemplate         = &flag.LinkTemplate{}              // templates for links added to findings
+	markStaleAudits      = &flag.MarkStaleAudits{}           // security mark on findings of constraints with stale audits
+	maxDeactivationPct   = &flag.MaxDeactivationPercent{}    // percentage of active findings a sync can deactivate
+	maxDeactivations     = &flag.MaxDeactivations{}          // number of findings a sync can deactivate
+	metricsAddress       = &flag.MetricsAddress{}            // address of the Prometheus metrics endpoint
+	namespace            = &flag.Namespace{}                 // resource namespace filter
+	olderThan            = &flag.OlderThan{}                 // finding event time age filter
+	output               = &flag.Output{}                    // output format for lists
+	policyHealth         = &flag.PolicyHealth{}              // findings for broken constraint templates and constraints
+	policyReports        = &flag.PolicyReports{}             // findings for failed results in wgpolicyk8s.io policy reports
+	pubsubOrdering       = &flag.PubsubOrdering{}            // use finding name as Pub/Sub ordering key
+	pubsubTopic          = &flag.PubsubTopic{}               // Pub/Sub topic that receives finding transitions
+	purgeAction          = &flag.PurgeAction{}               // deactivate or mute purged findings
+	resourceKind         = &flag.ResourceKind{}              // resource kind filter
+	securityMarkLabels   = &flag.SecurityMarkLabels{}        // label keys copied to security marks
 	source               = &flag.Source{}                    // Security Command Center source name
+	stateResyncInterval  = &flag.StateResyncInterval{}       // max time between syncs that list all findings
+	stateStore           = &flag.StateStore{}                // location of the snapshot of findings from the last sync
+	webhookSecretFile    = &flag.WebhookSecretFile{}         // path to file with secret for signing webhook requests
+	webhookURL           = &flag.WebhookURL{}                // endpoint that receives finding transitions
+	yes                  = &flag.Yes{}                       // skip confirmation prompts
 )
 
 func init() {
 	Cmd.AddCommand(
+		explainFindingCmd,
+		getFindingCmd,
+		listFindingsCmd,
 		managerCmd,
+		migrateIDsCmd,
+		purgeFindingsCmd,
 		syncCmd,
 	)
 }
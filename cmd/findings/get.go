@@ -0,0 +1,61 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package findings
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/cmd/flag"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/logging"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/print"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+var (
+	getFindingFlags = flag.New(finding, googleServiceAccount)
+
+	getFindingCmd = &cobra.Command{
+		Use:   "get",
+		Short: "Print a Security Command Center finding, including all source properties",
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			return getFindingFlags.Validate()
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return getFindingRun(cmd.Context())
+		},
+	}
+)
+
+func init() {
+	getFindingFlags.AddToFlagSet(getFindingCmd.Flags())
+}
+
+// getFindingRun prints the finding as JSON
+func getFindingRun(ctx context.Context) error {
+	log := logging.CreateStdLog("get")
+	dryRun := false
+	securitycenterClient, err := securitycenter.NewClient(ctx, log, googleServiceAccount.Value(), dryRun)
+	if err != nil {
+		return err
+	}
+	defer securitycenterClient.Close()
+	f, err := securitycenterClient.GetFinding(ctx, finding.Value())
+	if err != nil {
+		return err
+	}
+	return print.AsJSON(f)
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package findings
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/spf13/cobra"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/cmd/flag"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/logging"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/print"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+var (
+	listFindingsFlags = flag.New(source, findingState, category, clusterName, namespace, resourceKind, output, googleServiceAccount)
+
+	listFindingsCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List findings in a Security Command Center source",
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			return listFindingsFlags.Validate()
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return listFindingsRun(cmd.Context())
+		},
+	}
+
+	// findingsTableHeaders are the column headers for table and CSV output
+	findingsTableHeaders = []string{"FINDING_ID", "STATE", "CATEGORY", "CLUSTER", "KIND", "NAMESPACE", "NAME", "EVENT_TIME"}
+)
+
+func init() {
+	listFindingsFlags.AddToFlagSet(listFindingsCmd.Flags())
+}
+
+// listFindingsRun prints the findings that match the filter flags
+func listFindingsRun(ctx context.Context) error {
+	log := logging.CreateStdLog("list")
+	filter := &securitycenter.FindingsFilter{
+		State:        findingState.Value(),
+		Category:     category.Value(),
+		Cluster:      clusterName.Value(),
+		Namespace:    namespace.Value(),
+		ResourceKind: resourceKind.Value(),
+	}
+	findings, err := listFindings(ctx, log, source.Value(), filter, googleServiceAccount.Value())
+	if err != nil {
+		return err
+	}
+	return printFindings(findings, output.Value())
+}
+
+func listFindings(ctx context.Context, log logr.Logger, sourceName string, filter *securitycenter.FindingsFilter, googleServiceAccount string) ([]*securitycenterpb.Finding, error) {
+	dryRun := false
+	securitycenterClient, err := securitycenter.NewClient(ctx, log, googleServiceAccount, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	defer securitycenterClient.Close()
+	return securitycenterClient.ListFindings(ctx, sourceName, filter.String())
+}
+
+// printFindings in the provided output format
+func printFindings(findings []*securitycenterpb.Finding, outputFormat string) error {
+	if outputFormat == flag.OutputJSON {
+		return print.AsJSON(findings)
+	}
+	var rows [][]string
+	for _, finding := range findings {
+		rows = append(rows, findingRow(finding))
+	}
+	if outputFormat == flag.OutputCSV {
+		return print.AsCSV(findingsTableHeaders, rows)
+	}
+	return print.AsTable(findingsTableHeaders, rows)
+}
+
+// findingRow returns the values for the findingsTableHeaders columns
+func findingRow(finding *securitycenterpb.Finding) []string {
+	props := finding.GetSourceProperties()
+	var eventTime string
+	if finding.EventTime != nil {
+		eventTime = finding.EventTime.AsTime().Format(time.RFC3339)
+	}
+	return []string{
+		findingID(finding.Name),
+		finding.State.String(),
+		finding.Category,
+		stringProperty(props, "Cluster"),
+		stringProperty(props, "ResourceKind"),
+		stringProperty(props, "ResourceNamespace"),
+		stringProperty(props, "ResourceName"),
+		eventTime,
+	}
+}
+
+// findingID returns the last segment of the full finding name
+func findingID(findingName string) string {
+	return findingName[strings.LastIndex(findingName, "/")+1:]
+}
+
+// stringProperty returns the value of a source property, or empty string if
+// it doesn't exist
+func stringProperty(props map[string]*structpb.Value, key string) string {
+	return props[key].GetStringValue()
+}
@@ -21,22 +21,28 @@ import (
 
 	"github.com/go-logr/zapr"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"go.uber.org/zap"
 
 	"github.com/googlecloudplatform/gatekeeper-securitycenter/cmd/flag"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/config"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/metrics"
 )
 
 var (
-	managerFlags = flag.New(kubeconfig, interval, dryRun, source, clusterName)
+	managerFlags = flag.New(configFile, kubeconfig, interval, webhookURL, webhookSecretFile, pubsubTopic, pubsubOrdering, stateStore, stateResyncInterval, securityMarkLabels, linkTemplate, clusterProject, clusterLocation, events, annotateConstraints, aggregateOwners, policyReports, policyHealth, auditStaleness, markStaleAudits, admissionEvents, admissionFindingTTL, metricsAddress, configResource, findingIDStrategy, findingGranularity, maxDeactivations, maxDeactivationPct, deactivationConfirms, dryRun, source, clusterName)
 
 	managerCmd = &cobra.Command{
 		Use:   "manager",
 		Short: "Start a Kubernetes controller manager",
-		PreRunE: func(_ *cobra.Command, _ []string) error {
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			if err := loadConfigFile(cmd.Flags()); err != nil {
+				return err
+			}
 			return managerFlags.Validate()
 		},
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			return managerRun(cmd.Context())
+			return managerRun(cmd.Context(), cmd.Flags())
 		},
 	}
 )
@@ -46,7 +52,7 @@ func init() {
 }
 
 // managerRun starts a controller manager
-func managerRun(ctx context.Context) error {
+func managerRun(ctx context.Context, flags *pflag.FlagSet) error {
 	zLog, err := createZapLogger()
 	if err != nil {
 		return err
@@ -56,7 +62,29 @@ func managerRun(ctx context.Context) error {
 	}()
 	log := zapr.NewLogger(zLog).WithName("controller")
 
-	return Start(ctx, log, kubeconfig.Value(), dryRun.Value(), source.Value(), clusterName.Value(), interval.Value())
+	client, err := newSyncClient(ctx, log, "")
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if metricsAddress.Value() != "" {
+		m := metrics.New()
+		client.SetMetrics(m)
+		go func() {
+			if err := m.ListenAndServe(ctx, log.WithName("metrics"), metricsAddress.Value()); err != nil {
+				log.Error(err, "could not serve metrics")
+			}
+		}()
+	}
+	var configChanges chan *config.Config
+	if configFile.Value() != "" {
+		configChanges = make(chan *config.Config)
+		go config.Watch(ctx, log.WithName("config"), configFile.Value(), configWatchPeriod, configChanges)
+	}
+	reload := func(cfg *config.Config) (int, error) {
+		return reloadConfig(log, flags, client, cfg)
+	}
+	return Start(ctx, log, client, interval.Value(), configChanges, reload)
 }
 
 func createZapLogger() (*zap.Logger, error) {
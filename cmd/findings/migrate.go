@@ -0,0 +1,191 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package findings
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/cmd/flag"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/logging"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/sync"
+)
+
+var (
+	migrateIDsFlags = flag.New(source, clusterName, yes, googleServiceAccount, dryRun)
+
+	migrateIDsCmd = &cobra.Command{
+		Use:   "migrate-ids",
+		Short: "Replace ACTIVE findings with findings that use the stable finding ID strategy",
+		Long: `Replace ACTIVE findings with findings that use the stable finding ID
+strategy, so the controller can run with --finding-id-strategy=stable without
+losing the triage history of existing findings.
+
+For each violation, the command creates a finding with the stable finding ID,
+copying the source properties, security marks, and mute state of the most
+recently created ACTIVE finding for the violation, and records the ID of that
+finding in the PreviousFindingId source property. It then sets the replaced
+findings to INACTIVE. If a finding with the stable ID already exists from an
+earlier, partial run, its source properties are updated instead.
+
+Stop the controller before running this command, and start it with
+--finding-id-strategy=stable afterwards. Otherwise, the controller sets the
+new findings to INACTIVE in its next sync.`,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			return migrateIDsFlags.Validate()
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return migrateIDsRun(cmd.Context(), cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+)
+
+func init() {
+	migrateIDsFlags.AddToFlagSet(migrateIDsCmd.Flags())
+}
+
+// idMigration is the set of ACTIVE findings for one violation that are
+// replaced by the finding with the stable ID
+type idMigration struct {
+	findingID string
+	// predecessor is the finding that is copied to the new finding, nil if
+	// the finding with the stable ID already exists
+	predecessor *securitycenterpb.Finding
+	// deactivate are the other findings for the violation
+	deactivate []*securitycenterpb.Finding
+}
+
+// migrateIDsRun selects ACTIVE findings, asks for confirmation, replaces the
+// findings with findings that use stable IDs, and prints a summary
+func migrateIDsRun(ctx context.Context, in io.Reader, out io.Writer) error {
+	log := logging.CreateStdLog("migrate-ids")
+	securitycenterClient, err := securitycenter.NewClient(ctx, log, googleServiceAccount.Value(), dryRun.Value())
+	if err != nil {
+		return err
+	}
+	defer securitycenterClient.Close()
+
+	filter := &securitycenter.FindingsFilter{
+		Cluster: clusterName.Value(),
+		State:   securitycenterpb.Finding_ACTIVE.String(),
+	}
+	findings, err := securitycenterClient.ListFindings(ctx, source.Value(), filter.String())
+	if err != nil {
+		return err
+	}
+	migrations, unchanged, skipped := planIDMigrations(findings)
+	fmt.Fprintf(out, "Selected %d findings in %s using filter: %s\n", len(findings), source.Value(), filter.String())
+	fmt.Fprintf(out, "Violations to migrate: %d, already using stable IDs: %d, findings missing source properties: %d\n", len(migrations), unchanged, len(skipped))
+	for _, name := range skipped {
+		fmt.Fprintf(out, "  skipped: %s\n", name)
+	}
+	if len(migrations) == 0 {
+		return nil
+	}
+	if !dryRun.Value() && !yes.Value() {
+		confirmed, err := confirm(in, out, fmt.Sprintf("migrate findings for %d violations?", len(migrations)))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Fprintln(out, "Aborted")
+			return nil
+		}
+	}
+
+	migrated, failed := 0, 0
+	for _, migration := range migrations {
+		if migration.predecessor != nil {
+			if _, err := securitycenterClient.ReplaceFinding(ctx, migration.predecessor, migration.findingID); err != nil {
+				fmt.Fprintf(out, "  failed: %s: %v\n", migration.predecessor.Name, err)
+				failed++
+				continue
+			}
+		}
+		result := securitycenterClient.SetFindingsState(ctx, migration.deactivate, securitycenterpb.Finding_INACTIVE)
+		for findingName, err := range result.Failed {
+			fmt.Fprintf(out, "  failed: %s: %v\n", findingName, err)
+		}
+		if len(result.Failed) > 0 {
+			failed++
+			continue
+		}
+		migrated++
+	}
+	prefix := ""
+	if dryRun.Value() {
+		prefix = "(dry-run) "
+	}
+	fmt.Fprintf(out, "%sSummary: migrated=%d failed=%d\n", prefix, migrated, failed)
+	if failed > 0 {
+		return fmt.Errorf("could not migrate findings for %d violations", failed)
+	}
+	return nil
+}
+
+// planIDMigrations groups the ACTIVE findings by stable finding ID. Returns
+// the migrations sorted by finding ID, the number of findings that already
+// use the stable ID and have no other ACTIVE findings for the violation, and
+// the names of findings without the source properties for a stable ID.
+func planIDMigrations(findings []*securitycenterpb.Finding) ([]*idMigration, int, []string) {
+	groups := map[string][]*securitycenterpb.Finding{}
+	var skipped []string
+	for _, finding := range findings {
+		findingID, err := sync.StableFindingID(finding)
+		if err != nil {
+			skipped = append(skipped, finding.Name)
+			continue
+		}
+		groups[findingID] = append(groups[findingID], finding)
+	}
+	var migrations []*idMigration
+	unchanged := 0
+	for findingID, group := range groups {
+		// most recently created finding first, the same order as the lineage
+		// of new findings
+		sort.SliceStable(group, func(i, j int) bool {
+			return securitycenter.CreatedAfter(group[i], group[j])
+		})
+		migration := &idMigration{findingID: findingID}
+		for _, finding := range group {
+			if strings.HasSuffix(finding.Name, "/findings/"+findingID) {
+				continue // already uses the stable ID
+			}
+			migration.deactivate = append(migration.deactivate, finding)
+		}
+		if len(migration.deactivate) == len(group) {
+			// no finding with the stable ID yet, the most recent finding is copied
+			migration.predecessor = migration.deactivate[0]
+			migration.deactivate = migration.deactivate[1:]
+		}
+		if migration.predecessor == nil && len(migration.deactivate) == 0 {
+			unchanged++
+			continue
+		}
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].findingID < migrations[j].findingID
+	})
+	sort.Strings(skipped)
+	return migrations, unchanged, skipped
+}
@@ -0,0 +1,149 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package findings
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/cmd/flag"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/logging"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+var (
+	purgeFindingsFlags = flag.New(source, clusterName, category, olderThan, purgeAction, yes, googleServiceAccount, dryRun)
+
+	purgeFindingsCmd = &cobra.Command{
+		Use:   "purge",
+		Short: "Deactivate or mute findings in bulk, e.g., findings from a decommissioned cluster",
+		Long: `Deactivate or mute findings in bulk, e.g., findings from a decommissioned
+cluster, where no controller is left to set the findings to INACTIVE.
+
+Select findings using at least one of --cluster, --category, or --older-than.
+The deactivate action selects ACTIVE findings and sets their state to INACTIVE.
+The mute action selects findings in any state and mutes them.`,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if err := purgeFindingsFlags.Validate(); err != nil {
+				return err
+			}
+			if clusterName.Value() == "" && category.Value() == "" && olderThan.Value() == 0 {
+				return errors.New("select findings using at least one of --cluster, --category, or --older-than")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return purgeFindingsRun(cmd.Context(), cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+)
+
+func init() {
+	purgeFindingsFlags.AddToFlagSet(purgeFindingsCmd.Flags())
+}
+
+// purgeFindingsRun selects findings using the filter flags, asks for
+// confirmation, applies the purge action, and prints a summary
+func purgeFindingsRun(ctx context.Context, in io.Reader, out io.Writer) error {
+	log := logging.CreateStdLog("purge")
+	securitycenterClient, err := securitycenter.NewClient(ctx, log, googleServiceAccount.Value(), dryRun.Value())
+	if err != nil {
+		return err
+	}
+	defer securitycenterClient.Close()
+
+	filter := &securitycenter.FindingsFilter{
+		Category: category.Value(),
+		Cluster:  clusterName.Value(),
+	}
+	if purgeAction.Value() == flag.PurgeActionDeactivate {
+		filter.State = securitycenterpb.Finding_ACTIVE.String()
+	}
+	if olderThan.Value() > 0 {
+		filter.EventTimeBefore = time.Now().Add(-olderThan.Value())
+	}
+	findings, err := securitycenterClient.ListFindings(ctx, source.Value(), filter.String())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Selected %d findings in %s using filter: %s\n", len(findings), source.Value(), filter.String())
+	if len(findings) == 0 {
+		return nil
+	}
+	if !dryRun.Value() && !yes.Value() {
+		confirmed, err := confirm(in, out, fmt.Sprintf("%s %d findings?", purgeAction.Value(), len(findings)))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Fprintln(out, "Aborted")
+			return nil
+		}
+	}
+
+	var result *securitycenter.BulkResult
+	switch purgeAction.Value() {
+	case flag.PurgeActionMute:
+		result = securitycenterClient.SetFindingsMute(ctx, findings, securitycenterpb.Finding_MUTED)
+	default:
+		result = securitycenterClient.SetFindingsState(ctx, findings, securitycenterpb.Finding_INACTIVE)
+	}
+	printPurgeSummary(out, result, purgeAction.Value(), dryRun.Value())
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("could not %s %d findings", purgeAction.Value(), len(result.Failed))
+	}
+	return nil
+}
+
+// confirm prints the question and returns true if the user answers yes
+func confirm(in io.Reader, out io.Writer, question string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N]: ", question)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// printPurgeSummary prints the number of findings per outcome, and the errors
+func printPurgeSummary(out io.Writer, result *securitycenter.BulkResult, action string, dryRun bool) {
+	prefix := ""
+	if dryRun {
+		prefix = "(dry-run) "
+	}
+	fmt.Fprintf(out, "%sSummary: action=%s updated=%d unchanged=%d failed=%d\n", prefix, action, len(result.Updated), len(result.Unchanged), len(result.Failed))
+	var failedFindingNames []string
+	for findingName := range result.Failed {
+		failedFindingNames = append(failedFindingNames, findingName)
+	}
+	sort.Strings(failedFindingNames)
+	for _, findingName := range failedFindingNames {
+		fmt.Fprintf(out, "  failed: %s: %v\n", findingName, result.Failed[findingName])
+	}
+}
@@ -21,16 +21,18 @@ import (
 
 	"github.com/googlecloudplatform/gatekeeper-securitycenter/cmd/flag"
 	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/logging"
-	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/sync"
 )
 
 var (
-	syncFlags = flag.New(googleServiceAccount, kubeconfig, dryRun, source, clusterName)
+	syncFlags = flag.New(configFile, googleServiceAccount, kubeconfig, webhookURL, webhookSecretFile, pubsubTopic, pubsubOrdering, stateStore, stateResyncInterval, securityMarkLabels, linkTemplate, clusterProject, clusterLocation, events, annotateConstraints, aggregateOwners, policyReports, policyHealth, auditStaleness, markStaleAudits, admissionEvents, admissionFindingTTL, configResource, findingIDStrategy, findingGranularity, maxDeactivations, maxDeactivationPct, deactivationConfirms, dryRun, source, clusterName)
 
 	syncCmd = &cobra.Command{
 		Use:   "sync",
 		Short: "Run a one-off sync",
-		PreRunE: func(_ *cobra.Command, _ []string) error {
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			if err := loadConfigFile(cmd.Flags()); err != nil {
+				return err
+			}
 			return syncFlags.Validate()
 		},
 		RunE: func(cmd *cobra.Command, _ []string) error {
@@ -46,9 +48,10 @@ func init() {
 // syncRun runs a one-off sync
 func syncRun(ctx context.Context) error {
 	log := logging.CreateStdLog("sync")
-	client, err := sync.NewClient(ctx, log, kubeconfig.Value(), dryRun.Value(), source.Value(), clusterName.Value(), googleServiceAccount.Value())
+	client, err := newSyncClient(ctx, log, googleServiceAccount.Value())
 	if err != nil {
 		return err
 	}
+	defer client.Close()
 	return client.Sync(ctx)
 }
@@ -0,0 +1,38 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// AdmissionEvents is the namespace of the Gatekeeper admission events to
+// report as findings
+type AdmissionEvents struct {
+	value string
+}
+
+func (a *AdmissionEvents) Add(flags *pflag.FlagSet) {
+	flags.StringVar(&a.value, "admission-events", "",
+		"(optional) namespace of the Gatekeeper admission events to report as findings, usually gatekeeper-system, or * for all namespaces, empty disables admission findings")
+}
+
+func (a *AdmissionEvents) Validate() error {
+	return nil
+}
+
+func (a *AdmissionEvents) Value() string {
+	return a.value
+}
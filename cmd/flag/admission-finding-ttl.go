@@ -0,0 +1,49 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	defaultAdmissionFindingTTL = 24 * time.Hour
+	minAdmissionFindingTTL     = time.Minute
+)
+
+// AdmissionFindingTTL is the time after the last admission denial that an
+// admission finding stays active
+type AdmissionFindingTTL struct {
+	value time.Duration
+}
+
+func (a *AdmissionFindingTTL) Add(flags *pflag.FlagSet) {
+	flags.DurationVar(&a.value, "admission-finding-ttl", defaultAdmissionFindingTTL,
+		"(optional) time after the last admission denial that an admission finding stays active")
+}
+
+func (a *AdmissionFindingTTL) Validate() error {
+	if a.value < minAdmissionFindingTTL {
+		return fmt.Errorf("invalid value for admission-finding-ttl=%v, must be at least %v", a.value, minAdmissionFindingTTL)
+	}
+	return nil
+}
+
+func (a *AdmissionFindingTTL) Value() time.Duration {
+	return a.value
+}
@@ -0,0 +1,36 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import "github.com/spf13/pflag"
+
+// AggregateOwners groups violations by the top-level controller of the
+// violating objects
+type AggregateOwners struct {
+	value bool
+}
+
+func (a *AggregateOwners) Add(flags *pflag.FlagSet) {
+	flags.BoolVar(&a.value, "aggregate-owners", false,
+		"(optional) if true, create one finding per constraint and top-level controller of the violating objects, e.g., one finding for a Deployment and its ReplicaSets and Pods (default false)")
+}
+
+func (a *AggregateOwners) Validate() error {
+	return nil
+}
+
+func (a *AggregateOwners) Value() bool {
+	return a.value
+}
@@ -0,0 +1,35 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import "github.com/spf13/pflag"
+
+// AnnotateConstraints writes finding references back onto constraints
+type AnnotateConstraints struct {
+	value bool
+}
+
+func (a *AnnotateConstraints) Add(flags *pflag.FlagSet) {
+	flags.BoolVar(&a.value, "annotate-constraints", false,
+		"(optional) if true, annotate each constraint with the count of ACTIVE findings, the source name, and a link to the findings (default false)")
+}
+
+func (a *AnnotateConstraints) Validate() error {
+	return nil
+}
+
+func (a *AnnotateConstraints) Value() bool {
+	return a.value
+}
@@ -0,0 +1,45 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const defaultAuditStaleness = time.Hour
+
+// AuditStaleness is the max age of the last Gatekeeper audit of a constraint
+type AuditStaleness struct {
+	value time.Duration
+}
+
+func (a *AuditStaleness) Add(flags *pflag.FlagSet) {
+	flags.DurationVar(&a.value, "audit-staleness", defaultAuditStaleness,
+		"(optional) max age of the last Gatekeeper audit of a constraint, older audits are reported as stale, 0 disables staleness detection")
+}
+
+func (a *AuditStaleness) Validate() error {
+	if a.value < 0 {
+		return fmt.Errorf("invalid value for audit-staleness=%v, must not be negative", a.value)
+	}
+	return nil
+}
+
+func (a *AuditStaleness) Value() time.Duration {
+	return a.value
+}
@@ -0,0 +1,36 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import "github.com/spf13/pflag"
+
+// Category is used to select findings by category. For Gatekeeper findings,
+// the category is the constraint kind, e.g., `K8sRequiredLabels`.
+type Category struct {
+	value string
+}
+
+func (c *Category) Add(flags *pflag.FlagSet) {
+	flags.StringVar(&c.value, "category", "",
+		"(optional) only include findings with this category (constraint kind), e.g., K8sRequiredLabels")
+}
+
+func (c *Category) Validate() error {
+	return nil
+}
+
+func (c *Category) Value() string {
+	return c.value
+}
@@ -0,0 +1,35 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import "github.com/spf13/pflag"
+
+// ClusterLocation is the location of the GKE cluster, used in links
+type ClusterLocation struct {
+	value string
+}
+
+func (c *ClusterLocation) Add(flags *pflag.FlagSet) {
+	flags.StringVar(&c.value, "cluster-location", "",
+		"(optional) location (region or zone) of the GKE cluster, used in links added to findings")
+}
+
+func (c *ClusterLocation) Validate() error {
+	return nil
+}
+
+func (c *ClusterLocation) Value() string {
+	return c.value
+}
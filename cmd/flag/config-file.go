@@ -0,0 +1,47 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+)
+
+// ConfigFile is the path to a configuration file. Flags set on the command
+// line override the values in the file.
+type ConfigFile struct {
+	value string
+}
+
+func (c *ConfigFile) Add(flags *pflag.FlagSet) {
+	flags.StringVar(&c.value, "config", "",
+		"(optional) path to a YAML configuration file, flags set on the command line override values in the file")
+}
+
+func (c *ConfigFile) Validate() error {
+	if c.value == "" {
+		return nil
+	}
+	if _, err := os.Stat(c.value); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
+
+func (c *ConfigFile) Value() string {
+	return c.value
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ConfigResource is the name of the cluster-scoped
+// GatekeeperSecurityCenterConfig resource
+type ConfigResource struct {
+	value string
+}
+
+func (c *ConfigResource) Add(flags *pflag.FlagSet) {
+	flags.StringVar(&c.value, "config-resource", "",
+		"(optional) name of a cluster-scoped GatekeeperSecurityCenterConfig resource that holds the sync configuration and receives the sync status")
+}
+
+func (c *ConfigResource) Validate() error {
+	if c.value == "" {
+		return nil
+	}
+	if errs := validation.IsDNS1123Subdomain(c.value); len(errs) > 0 {
+		return fmt.Errorf("invalid config-resource: [%v]: %s", c.value, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func (c *ConfigResource) Value() string {
+	return c.value
+}
@@ -0,0 +1,45 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+const defaultDeactivationConfirmations = 3
+
+// DeactivationConfirmations is the number of consecutive syncs that must
+// exceed the deactivation limit before the deactivations are applied
+type DeactivationConfirmations struct {
+	value int
+}
+
+func (d *DeactivationConfirmations) Add(flags *pflag.FlagSet) {
+	flags.IntVar(&d.value, "deactivation-confirmations", defaultDeactivationConfirmations,
+		"(optional) number of consecutive syncs that must exceed the deactivation limit before held deactivations are applied; 0 holds them until the limit is raised")
+}
+
+func (d *DeactivationConfirmations) Validate() error {
+	if d.value < 0 || d.value > 100 {
+		return fmt.Errorf("invalid value for deactivation-confirmations=%v, must be between 0 and 100", d.value)
+	}
+	return nil
+}
+
+func (d *DeactivationConfirmations) Value() int {
+	return d.value
+}
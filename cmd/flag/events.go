@@ -0,0 +1,52 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+// Kubernetes Events options
+const (
+	EventsNone      = "none"
+	EventsResources = "resources"
+	EventsAll       = "all"
+)
+
+// Events selects the objects that receive Kubernetes Events when findings
+// are created, reactivated, or resolved
+type Events struct {
+	value string
+}
+
+func (e *Events) Add(flags *pflag.FlagSet) {
+	flags.StringVar(&e.value, "events", EventsNone,
+		"(optional) record Kubernetes Events when findings are created, reactivated, or resolved: none, resources (on violating resources), or all (on violating resources and constraints)")
+}
+
+func (e *Events) Validate() error {
+	switch e.value {
+	case EventsNone, EventsResources, EventsAll:
+		return nil
+	default:
+		return fmt.Errorf("invalid events: [%v], must be one of %s, %s, or %s", e.value, EventsNone, EventsResources, EventsAll)
+	}
+}
+
+func (e *Events) Value() string {
+	return e.value
+}
@@ -0,0 +1,46 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+)
+
+// File is the path to an input file
+type File struct {
+	value string
+}
+
+func (f *File) Add(flags *pflag.FlagSet) {
+	flags.StringVarP(&f.value, "file", "f", "",
+		"path to the input file")
+}
+
+func (f *File) Validate() error {
+	if f.value == "" {
+		return fmt.Errorf("missing required flag: file")
+	}
+	if _, err := os.Stat(f.value); err != nil {
+		return fmt.Errorf("invalid file: %w", err)
+	}
+	return nil
+}
+
+func (f *File) Value() string {
+	return f.value
+}
@@ -0,0 +1,51 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+// Finding granularity options
+const (
+	FindingGranularityViolation  = "violation"
+	FindingGranularityConstraint = "constraint"
+	FindingGranularityNamespace  = "namespace"
+)
+
+// FindingGranularity selects how many violations each finding reports
+type FindingGranularity struct {
+	value string
+}
+
+func (f *FindingGranularity) Add(flags *pflag.FlagSet) {
+	flags.StringVar(&f.value, "finding-granularity", FindingGranularityViolation,
+		"(optional) how many violations each finding reports: violation creates one finding per violation; constraint creates one finding per constraint; namespace creates one finding per constraint and namespace")
+}
+
+func (f *FindingGranularity) Validate() error {
+	switch f.value {
+	case FindingGranularityViolation, FindingGranularityConstraint, FindingGranularityNamespace:
+		return nil
+	default:
+		return fmt.Errorf("invalid value for finding-granularity=%v, must be one of %s, %s, %s", f.value, FindingGranularityViolation, FindingGranularityConstraint, FindingGranularityNamespace)
+	}
+}
+
+func (f *FindingGranularity) Value() string {
+	return f.value
+}
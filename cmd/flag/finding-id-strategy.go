@@ -0,0 +1,50 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+// Finding ID strategy options
+const (
+	FindingIDStrategySpec   = "spec"
+	FindingIDStrategyStable = "stable"
+)
+
+// FindingIDStrategy selects how finding IDs are determined for violations
+type FindingIDStrategy struct {
+	value string
+}
+
+func (f *FindingIDStrategy) Add(flags *pflag.FlagSet) {
+	flags.StringVar(&f.value, "finding-id-strategy", FindingIDStrategySpec,
+		"(optional) how finding IDs are determined: spec creates a new finding when the constraint, constraint template, or resource spec changes; stable keeps the finding while the cluster, constraint, and resource name stay the same")
+}
+
+func (f *FindingIDStrategy) Validate() error {
+	switch f.value {
+	case FindingIDStrategySpec, FindingIDStrategyStable:
+		return nil
+	default:
+		return fmt.Errorf("invalid value for finding-id-strategy=%v, must be one of %s, %s", f.value, FindingIDStrategySpec, FindingIDStrategyStable)
+	}
+}
+
+func (f *FindingIDStrategy) Value() string {
+	return f.value
+}
@@ -0,0 +1,46 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// FindingState is used to select findings by state
+// Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings#State
+type FindingState struct {
+	value string
+}
+
+func (s *FindingState) Add(flags *pflag.FlagSet) {
+	flags.StringVar(&s.value, "state", "",
+		"(optional) only include findings with this `state`, ACTIVE or INACTIVE")
+}
+
+func (s *FindingState) Validate() error {
+	switch strings.ToUpper(s.value) {
+	case "", "ACTIVE", "INACTIVE":
+		return nil
+	default:
+		return fmt.Errorf("invalid state: [%v], must be ACTIVE or INACTIVE", s.value)
+	}
+}
+
+func (s *FindingState) Value() string {
+	return strings.ToUpper(s.value)
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/spf13/pflag"
+)
+
+// Finding represents a Security Command Center finding
+// Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings
+type Finding struct {
+	value string
+}
+
+func (f *Finding) Add(flags *pflag.FlagSet) {
+	flags.StringVar(&f.value, "finding", "",
+		"full name of the Security Command Center finding in the format `organizations/[organization_id]/sources/[source_id]/findings/[finding_id]`")
+}
+
+func (f *Finding) Validate() error {
+	findingNameRegexp, err := regexp.Compile("^organizations/[0-9]+/sources/[0-9]+/findings/[a-zA-Z0-9]{1,32}$")
+	if err != nil {
+		return err
+	}
+	if !findingNameRegexp.MatchString(f.value) {
+		return fmt.Errorf("invalid finding name: [%v]", f.value)
+	}
+	return nil
+}
+
+func (f *Finding) Value() string {
+	return f.value
+}
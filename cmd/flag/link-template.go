@@ -0,0 +1,58 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// LinkTemplate holds the templates for links added to findings, in the
+// format NAME=TEMPLATE
+type LinkTemplate struct {
+	value []string
+}
+
+func (l *LinkTemplate) Add(flags *pflag.FlagSet) {
+	flags.StringArrayVar(&l.value, "link-template", nil,
+		"(optional) link added to findings in the format `NAME=TEMPLATE`, where TEMPLATE is a Go template or a built-in template (gke, config-connector), and NAME=ExternalUri sets the finding external URI; can be repeated")
+}
+
+func (l *LinkTemplate) Validate() error {
+	seen := map[string]bool{}
+	for _, item := range l.value {
+		name, template, found := strings.Cut(item, "=")
+		if !found || name == "" || template == "" {
+			return fmt.Errorf("invalid link-template: [%v], must be in the format NAME=TEMPLATE", item)
+		}
+		if seen[name] {
+			return fmt.Errorf("duplicate link-template name: [%v]", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// Value returns the templates keyed by link name
+func (l *LinkTemplate) Value() map[string]string {
+	templates := map[string]string{}
+	for _, item := range l.value {
+		name, template, _ := strings.Cut(item, "=")
+		templates[name] = template
+	}
+	return templates
+}
@@ -0,0 +1,56 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import "testing"
+
+func TestLinkTemplate_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   []string
+		wantErr bool
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:  "built-in and custom templates",
+			value: []string{"ExternalUri=gke", "Portal=https://example.com/{{.Name}}?a=1,b=2"},
+		},
+		{
+			name:    "missing template",
+			value:   []string{"Portal="},
+			wantErr: true,
+		},
+		{
+			name:    "missing name",
+			value:   []string{"gke"},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate name",
+			value:   []string{"Portal=gke", "Portal=config-connector"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &LinkTemplate{value: tt.value}
+			if err := l.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
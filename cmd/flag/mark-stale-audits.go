@@ -0,0 +1,35 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import "github.com/spf13/pflag"
+
+// MarkStaleAudits adds a security mark to findings of constraints with stale audits
+type MarkStaleAudits struct {
+	value bool
+}
+
+func (m *MarkStaleAudits) Add(flags *pflag.FlagSet) {
+	flags.BoolVar(&m.value, "mark-stale-audits", false,
+		"(optional) if true, add the security mark gatekeeper_audit_stale=true to findings of constraints with stale audits, see --audit-staleness (default false)")
+}
+
+func (m *MarkStaleAudits) Validate() error {
+	return nil
+}
+
+func (m *MarkStaleAudits) Value() bool {
+	return m.value
+}
@@ -0,0 +1,45 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+const defaultMaxDeactivationPercent = 50
+
+// MaxDeactivationPercent is the percentage of active findings that a sync
+// can always set to INACTIVE, regardless of the max-deactivations limit
+type MaxDeactivationPercent struct {
+	value int
+}
+
+func (m *MaxDeactivationPercent) Add(flags *pflag.FlagSet) {
+	flags.IntVar(&m.value, "max-deactivation-percent", defaultMaxDeactivationPercent,
+		"(optional) deactivations of findings are held if a sync would set more than this percentage of the active findings, and more than max-deactivations findings, to INACTIVE; 100 disables the limit")
+}
+
+func (m *MaxDeactivationPercent) Validate() error {
+	if m.value < 0 || m.value > 100 {
+		return fmt.Errorf("invalid value for max-deactivation-percent=%v, must be between 0 and 100", m.value)
+	}
+	return nil
+}
+
+func (m *MaxDeactivationPercent) Value() int {
+	return m.value
+}
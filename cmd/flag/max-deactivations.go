@@ -0,0 +1,45 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+const defaultMaxDeactivations = 10
+
+// MaxDeactivations is the number of findings that a sync can always set to
+// INACTIVE, regardless of the max-deactivation-percent limit
+type MaxDeactivations struct {
+	value int
+}
+
+func (m *MaxDeactivations) Add(flags *pflag.FlagSet) {
+	flags.IntVar(&m.value, "max-deactivations", defaultMaxDeactivations,
+		"(optional) deactivations of findings are held if a sync would set more than this number of findings, and more than max-deactivation-percent of the active findings, to INACTIVE")
+}
+
+func (m *MaxDeactivations) Validate() error {
+	if m.value < 0 {
+		return fmt.Errorf("invalid value for max-deactivations=%v, must be at least 0", m.value)
+	}
+	return nil
+}
+
+func (m *MaxDeactivations) Value() int {
+	return m.value
+}
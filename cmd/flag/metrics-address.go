@@ -0,0 +1,46 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/spf13/pflag"
+)
+
+// MetricsAddress is the address of the Prometheus metrics endpoint
+type MetricsAddress struct {
+	value string
+}
+
+func (m *MetricsAddress) Add(flags *pflag.FlagSet) {
+	flags.StringVar(&m.value, "metrics-address", "",
+		"(optional) address to serve Prometheus metrics on at the /metrics path, e.g., :9090, disabled if empty")
+}
+
+func (m *MetricsAddress) Validate() error {
+	if m.value == "" {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(m.value); err != nil {
+		return fmt.Errorf("invalid value for metrics-address=%v: %w", m.value, err)
+	}
+	return nil
+}
+
+func (m *MetricsAddress) Value() string {
+	return m.value
+}
@@ -0,0 +1,42 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+// MuteRuleDescription of a Security Command Center mute config
+type MuteRuleDescription struct {
+	value string
+}
+
+func (m *MuteRuleDescription) Add(flags *pflag.FlagSet) {
+	flags.StringVar(&m.value, "description", "",
+		"(optional) description of the mute rule")
+}
+
+func (m *MuteRuleDescription) Validate() error {
+	if len(m.value) > 1024 {
+		return fmt.Errorf("invalid description: [%v], must be max 1024 characters", m.value)
+	}
+	return nil
+}
+
+func (m *MuteRuleDescription) Value() string {
+	return m.value
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/spf13/pflag"
+)
+
+// MuteRule is the ID of a Security Command Center mute config
+// Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.muteConfigs
+type MuteRule struct {
+	value string
+}
+
+func (m *MuteRule) Add(flags *pflag.FlagSet) {
+	flags.StringVar(&m.value, "mute-rule", "",
+		"ID of the mute rule, must start with a lowercase letter and contain only lowercase letters, numbers, and hyphens, max 63 characters")
+}
+
+func (m *MuteRule) Validate() error {
+	muteRuleRegexp, err := regexp.Compile("^[a-z][a-z0-9-]{0,62}$")
+	if err != nil {
+		return err
+	}
+	if !muteRuleRegexp.MatchString(m.value) {
+		return fmt.Errorf("invalid mute rule ID: [%v]", m.value)
+	}
+	return nil
+}
+
+func (m *MuteRule) Value() string {
+	return m.value
+}
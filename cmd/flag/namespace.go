@@ -0,0 +1,36 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import "github.com/spf13/pflag"
+
+// Namespace is used to select findings by the namespace of the resource that
+// violated the constraint
+type Namespace struct {
+	value string
+}
+
+func (n *Namespace) Add(flags *pflag.FlagSet) {
+	flags.StringVar(&n.value, "namespace", "",
+		"(optional) only include findings for resources in this Kubernetes namespace")
+}
+
+func (n *Namespace) Validate() error {
+	return nil
+}
+
+func (n *Namespace) Value() string {
+	return n.value
+}
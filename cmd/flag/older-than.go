@@ -0,0 +1,43 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// OlderThan selects findings with an event time older than this duration
+type OlderThan struct {
+	value time.Duration
+}
+
+func (o *OlderThan) Add(flags *pflag.FlagSet) {
+	flags.DurationVar(&o.value, "older-than", 0,
+		"(optional) only select findings with an event time older than this duration, e.g., 720h")
+}
+
+func (o *OlderThan) Validate() error {
+	if o.value < 0 {
+		return fmt.Errorf("invalid value for older-than=%v, must not be negative", o.value)
+	}
+	return nil
+}
+
+func (o *OlderThan) Value() time.Duration {
+	return o.value
+}
@@ -0,0 +1,51 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+// Output formats
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputCSV   = "csv"
+)
+
+// Output format for commands that print lists
+type Output struct {
+	value string
+}
+
+func (o *Output) Add(flags *pflag.FlagSet) {
+	flags.StringVarP(&o.value, "output", "o", OutputTable,
+		"(optional) output `format`, one of table, json, or csv")
+}
+
+func (o *Output) Validate() error {
+	switch o.value {
+	case OutputTable, OutputJSON, OutputCSV:
+		return nil
+	default:
+		return fmt.Errorf("invalid output: [%v], must be one of %s, %s, or %s", o.value, OutputTable, OutputJSON, OutputCSV)
+	}
+}
+
+func (o *Output) Value() string {
+	return o.value
+}
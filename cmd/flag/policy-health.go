@@ -0,0 +1,36 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import "github.com/spf13/pflag"
+
+// PolicyHealth reports constraint templates and constraints that don't work
+// as intended as findings
+type PolicyHealth struct {
+	value bool
+}
+
+func (p *PolicyHealth) Add(flags *pflag.FlagSet) {
+	flags.BoolVar(&p.value, "policy-health", false,
+		"(optional) if true, create POLICY_HEALTH findings for constraint templates and constraints with errors, and for constraints that have never been audited or have stale audits")
+}
+
+func (p *PolicyHealth) Validate() error {
+	return nil
+}
+
+func (p *PolicyHealth) Value() bool {
+	return p.value
+}
@@ -0,0 +1,36 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import "github.com/spf13/pflag"
+
+// PolicyReports reports the failed and warned results of wgpolicyk8s.io
+// policy reports as findings
+type PolicyReports struct {
+	value bool
+}
+
+func (p *PolicyReports) Add(flags *pflag.FlagSet) {
+	flags.BoolVar(&p.value, "policy-reports", false,
+		"(optional) if true, create findings for failed and warned results in wgpolicyk8s.io PolicyReports and ClusterPolicyReports, e.g., from Kyverno")
+}
+
+func (p *PolicyReports) Validate() error {
+	return nil
+}
+
+func (p *PolicyReports) Value() bool {
+	return p.value
+}
@@ -0,0 +1,35 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import "github.com/spf13/pflag"
+
+// Prune deletes existing objects that aren't in the input
+type Prune struct {
+	value bool
+}
+
+func (p *Prune) Add(flags *pflag.FlagSet) {
+	flags.BoolVar(&p.value, "prune", false,
+		"(optional) delete existing mute rules for the source that aren't in the input file (default false)")
+}
+
+func (p *Prune) Validate() error {
+	return nil
+}
+
+func (p *Prune) Value() bool {
+	return p.value
+}
@@ -0,0 +1,35 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import "github.com/spf13/pflag"
+
+// PubsubOrdering publishes messages for the same finding in order
+type PubsubOrdering struct {
+	value bool
+}
+
+func (p *PubsubOrdering) Add(flags *pflag.FlagSet) {
+	flags.BoolVar(&p.value, "pubsub-ordering", false,
+		"(optional) if true, use the finding name as the Pub/Sub ordering key (default false)")
+}
+
+func (p *PubsubOrdering) Validate() error {
+	return nil
+}
+
+func (p *PubsubOrdering) Value() bool {
+	return p.value
+}
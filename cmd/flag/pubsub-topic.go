@@ -0,0 +1,49 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/spf13/pflag"
+)
+
+var pubsubTopicRegexp = regexp.MustCompile(`^projects/[^/]+/topics/[^/]+$`)
+
+// PubsubTopic is the Pub/Sub topic that receives finding transitions
+// Ref: https://cloud.google.com/pubsub/docs/reference/rest/v1/projects.topics
+type PubsubTopic struct {
+	value string
+}
+
+func (p *PubsubTopic) Add(flags *pflag.FlagSet) {
+	flags.StringVar(&p.value, "pubsub-topic", "",
+		"(optional) full name of a Pub/Sub topic that receives a message when findings are created or change state, in the format `projects/[project_id]/topics/[topic_id]`")
+}
+
+func (p *PubsubTopic) Validate() error {
+	if p.value == "" {
+		return nil
+	}
+	if !pubsubTopicRegexp.MatchString(p.value) {
+		return fmt.Errorf("invalid pubsub-topic: [%v]", p.value)
+	}
+	return nil
+}
+
+func (p *PubsubTopic) Value() string {
+	return p.value
+}
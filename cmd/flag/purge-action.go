@@ -0,0 +1,50 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+// Purge actions
+const (
+	PurgeActionDeactivate = "deactivate"
+	PurgeActionMute       = "mute"
+)
+
+// PurgeAction is the operation applied to findings selected for purging
+type PurgeAction struct {
+	value string
+}
+
+func (p *PurgeAction) Add(flags *pflag.FlagSet) {
+	flags.StringVar(&p.value, "action", PurgeActionDeactivate,
+		"(optional) action to apply to the selected findings, either deactivate (set state to INACTIVE) or mute")
+}
+
+func (p *PurgeAction) Validate() error {
+	switch p.value {
+	case PurgeActionDeactivate, PurgeActionMute:
+		return nil
+	default:
+		return fmt.Errorf("invalid action: [%v], must be one of %s or %s", p.value, PurgeActionDeactivate, PurgeActionMute)
+	}
+}
+
+func (p *PurgeAction) Value() string {
+	return p.value
+}
@@ -0,0 +1,47 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// SecurityMarkLabels is the allow-list of namespace and resource label keys
+// that are copied to the security marks of findings
+type SecurityMarkLabels struct {
+	value []string
+}
+
+func (s *SecurityMarkLabels) Add(flags *pflag.FlagSet) {
+	flags.StringSliceVar(&s.value, "security-mark-labels", nil,
+		"(optional) comma-separated list of namespace and resource label keys to copy to security marks on findings, e.g., team,env,cost-center")
+}
+
+func (s *SecurityMarkLabels) Validate() error {
+	for _, labelKey := range s.value {
+		if errs := validation.IsQualifiedName(labelKey); len(errs) > 0 {
+			return fmt.Errorf("invalid label key in security-mark-labels: [%v]: %s", labelKey, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+func (s *SecurityMarkLabels) Value() []string {
+	return s.value
+}
@@ -0,0 +1,46 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import "testing"
+
+func TestSecurityMarkLabels_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   []string
+		wantErr bool
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:  "valid label keys",
+			value: []string{"team", "env", "app.kubernetes.io/name"},
+		},
+		{
+			name:    "wildcard",
+			value:   []string{"*"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SecurityMarkLabels{value: tt.value}
+			if err := s.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const defaultStateResyncInterval = time.Hour
+
+// StateResyncInterval is the max time between syncs that list all findings,
+// when using a state store
+type StateResyncInterval struct {
+	value time.Duration
+}
+
+func (s *StateResyncInterval) Add(flags *pflag.FlagSet) {
+	flags.DurationVar(&s.value, "state-resync-interval", defaultStateResyncInterval,
+		"(optional) when using a state store, the max time between syncs that list all findings in Security Command Center")
+}
+
+func (s *StateResyncInterval) Validate() error {
+	if s.value < time.Minute {
+		return fmt.Errorf("invalid value for state-resync-interval=%v, must be at least 1m", s.value)
+	}
+	return nil
+}
+
+func (s *StateResyncInterval) Value() time.Duration {
+	return s.value
+}
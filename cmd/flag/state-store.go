@@ -0,0 +1,48 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/spf13/pflag"
+)
+
+var stateStoreRegexp = regexp.MustCompile(`^(file:///.+|configmap://[^/]+/[^/]+)$`)
+
+// StateStore is the location of the snapshot of findings used to detect changes between syncs
+type StateStore struct {
+	value string
+}
+
+func (s *StateStore) Add(flags *pflag.FlagSet) {
+	flags.StringVar(&s.value, "state-store", "",
+		"(optional) where to store the findings from the last sync to detect changes, in the format `file:///[path]` or `configmap://[namespace]/[name]`")
+}
+
+func (s *StateStore) Validate() error {
+	if s.value == "" {
+		return nil
+	}
+	if !stateStoreRegexp.MatchString(s.value) {
+		return fmt.Errorf("invalid state-store: [%v]", s.value)
+	}
+	return nil
+}
+
+func (s *StateStore) Value() string {
+	return s.value
+}
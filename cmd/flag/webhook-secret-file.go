@@ -0,0 +1,47 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+)
+
+// WebhookSecretFile is the path to a file containing the secret used to sign
+// webhook request bodies using HMAC-SHA256
+type WebhookSecretFile struct {
+	value string
+}
+
+func (w *WebhookSecretFile) Add(flags *pflag.FlagSet) {
+	flags.StringVar(&w.value, "webhook-secret-file", "",
+		"(optional) path to a file containing the secret used to sign webhook requests, leave blank to send unsigned requests")
+}
+
+func (w *WebhookSecretFile) Validate() error {
+	if w.value == "" {
+		return nil
+	}
+	if _, err := os.Stat(w.value); err != nil {
+		return fmt.Errorf("invalid webhook-secret-file: %w", err)
+	}
+	return nil
+}
+
+func (w *WebhookSecretFile) Value() string {
+	return w.value
+}
@@ -0,0 +1,47 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flag
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/spf13/pflag"
+)
+
+// WebhookURL is the endpoint that receives finding transitions as CloudEvents
+type WebhookURL struct {
+	value string
+}
+
+func (w *WebhookURL) Add(flags *pflag.FlagSet) {
+	flags.StringVar(&w.value, "webhook-url", "",
+		"(optional) URL of an HTTP endpoint that receives CloudEvents when findings are created, reactivated, or resolved")
+}
+
+func (w *WebhookURL) Validate() error {
+	if w.value == "" {
+		return nil
+	}
+	u, err := url.Parse(w.value)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("invalid webhook-url: [%v]", w.value)
+	}
+	return nil
+}
+
+func (w *WebhookURL) Value() string {
+	return w.value
+}
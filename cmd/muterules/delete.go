@@ -0,0 +1,64 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package muterules
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/spf13/cobra"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/cmd/flag"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/logging"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+var (
+	deleteMuteRuleFlags = flag.New(source, muteRule, googleServiceAccount, dryRun)
+
+	deleteMuteRuleCmd = &cobra.Command{
+		Use:   "delete",
+		Short: "Delete a mute rule",
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			return deleteMuteRuleFlags.Validate()
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return deleteMuteRuleRun(cmd.Context())
+		},
+	}
+)
+
+func init() {
+	deleteMuteRuleFlags.AddToFlagSet(deleteMuteRuleCmd.Flags())
+}
+
+// deleteMuteRuleRun deletes the mute config
+func deleteMuteRuleRun(ctx context.Context) error {
+	log := logging.CreateStdLog("delete")
+	return deleteMuteRule(ctx, log, source.Value(), muteRule.Value(), googleServiceAccount.Value(), dryRun.Value())
+}
+
+func deleteMuteRule(ctx context.Context, log logr.Logger, sourceName, muteRuleID, googleServiceAccount string, dryRun bool) error {
+	name, err := securitycenter.MuteConfigName(sourceName, muteRuleID)
+	if err != nil {
+		return err
+	}
+	securitycenterClient, err := securitycenter.NewClient(ctx, log, googleServiceAccount, dryRun)
+	if err != nil {
+		return err
+	}
+	defer securitycenterClient.Close()
+	return securitycenterClient.DeleteMuteConfig(ctx, name)
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package muterules
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/spf13/cobra"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/cmd/flag"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/logging"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/print"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+var (
+	getMuteRuleFlags = flag.New(source, muteRule, googleServiceAccount)
+
+	getMuteRuleCmd = &cobra.Command{
+		Use:   "get",
+		Short: "Get a mute rule",
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			return getMuteRuleFlags.Validate()
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return getMuteRuleRun(cmd.Context())
+		},
+	}
+)
+
+func init() {
+	getMuteRuleFlags.AddToFlagSet(getMuteRuleCmd.Flags())
+}
+
+// getMuteRuleRun prints the mute config
+func getMuteRuleRun(ctx context.Context) error {
+	log := logging.CreateStdLog("get")
+	muteConfig, err := getMuteRule(ctx, log, source.Value(), muteRule.Value(), googleServiceAccount.Value())
+	if err != nil {
+		return err
+	}
+	return print.AsJSON(muteConfig)
+}
+
+func getMuteRule(ctx context.Context, log logr.Logger, sourceName, muteRuleID, googleServiceAccount string) (*securitycenterpb.MuteConfig, error) {
+	name, err := securitycenter.MuteConfigName(sourceName, muteRuleID)
+	if err != nil {
+		return nil, err
+	}
+	dryRun := false
+	securitycenterClient, err := securitycenter.NewClient(ctx, log, googleServiceAccount, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	defer securitycenterClient.Close()
+	return securitycenterClient.GetMuteConfig(ctx, name)
+}
@@ -0,0 +1,66 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package muterules
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/spf13/cobra"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/cmd/flag"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/logging"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/print"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+var (
+	listMuteRulesFlags = flag.New(source, googleServiceAccount)
+
+	listMuteRulesCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List mute rules for Gatekeeper findings in a Security Command Center source",
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			return listMuteRulesFlags.Validate()
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return listMuteRulesRun(cmd.Context())
+		},
+	}
+)
+
+func init() {
+	listMuteRulesFlags.AddToFlagSet(listMuteRulesCmd.Flags())
+}
+
+// listMuteRulesRun lists the mute configs with a filter on the source
+func listMuteRulesRun(ctx context.Context) error {
+	log := logging.CreateStdLog("list")
+	return listMuteRules(ctx, log, source.Value(), googleServiceAccount.Value())
+}
+
+func listMuteRules(ctx context.Context, log logr.Logger, sourceName, googleServiceAccount string) error {
+	dryRun := false
+	securitycenterClient, err := securitycenter.NewClient(ctx, log, googleServiceAccount, dryRun)
+	if err != nil {
+		return err
+	}
+	defer securitycenterClient.Close()
+	muteConfigs, err := securitycenterClient.ListMuteConfigs(ctx, sourceName)
+	if err != nil {
+		return err
+	}
+	return print.AsJSON(muteConfigs)
+}
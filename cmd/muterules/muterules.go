@@ -0,0 +1,67 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package muterules
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/cmd/flag"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+var (
+	// Cmd is the mute-rules sub-command
+	Cmd = &cobra.Command{
+		Use:   "mute-rules",
+		Short: "Manage Security Command Center mute rules for Gatekeeper findings",
+		Long: `Manage Security Command Center mute rules (mute configs) for Gatekeeper
+findings. The filter of each mute rule selects findings in the source, using
+the optional category, cluster, and namespace flags.`,
+	}
+
+	// command-line flags for mute-rules sub-commands
+	category             = &flag.Category{}                  // finding category (constraint kind)
+	clusterName          = &flag.Cluster{}                   // Cluster source property
+	description          = &flag.MuteRuleDescription{}       // mute rule description
+	dryRun               = &flag.DryRun{}                    // skip state-changing operations
+	file                 = &flag.File{}                      // path to YAML file with mute rules
+	googleServiceAccount = &flag.ImpersonateServiceAccount{} // Google service account to impersonate
+	muteRule             = &flag.MuteRule{}                  // mute rule ID
+	namespace            = &flag.Namespace{}                 // resource namespace
+	prune                = &flag.Prune{}                     // delete mute rules not in the file
+	source               = &flag.Source{}                    // Security Command Center source name
+)
+
+func init() {
+	Cmd.AddCommand(
+		createMuteRuleCmd,
+		deleteMuteRuleCmd,
+		getMuteRuleCmd,
+		listMuteRulesCmd,
+		syncMuteRulesCmd,
+		updateMuteRuleCmd,
+	)
+}
+
+// muteRuleFromFlags creates a mute rule using the values of the command-line flags
+func muteRuleFromFlags() *securitycenter.MuteRule {
+	return &securitycenter.MuteRule{
+		ID:          muteRule.Value(),
+		Description: description.Value(),
+		Category:    category.Value(),
+		Cluster:     clusterName.Value(),
+		Namespace:   namespace.Value(),
+	}
+}
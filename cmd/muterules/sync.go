@@ -0,0 +1,104 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package muterules
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/go-logr/logr"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/cmd/flag"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/logging"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/print"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+var (
+	syncMuteRulesFlags = flag.New(source, file, prune, googleServiceAccount, dryRun)
+
+	syncMuteRulesCmd = &cobra.Command{
+		Use:   "sync",
+		Short: "Create and update mute rules to match the mute rules in a YAML file",
+		Long: `Create and update mute rules to match the mute rules in a YAML file.
+
+Example file:
+
+  muteRules:
+  - id: sandbox-required-labels
+    description: Labels aren't required in the sandbox cluster
+    category: K8sRequiredLabels
+    cluster: sandbox
+  - id: dev-allowed-repos
+    category: K8sAllowedRepos
+    namespace: dev
+
+Use --prune to delete existing mute rules for the source that aren't in the file.`,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			return syncMuteRulesFlags.Validate()
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return syncMuteRulesRun(cmd.Context())
+		},
+	}
+)
+
+// muteRulesFile is the format of the input file for the sync command
+type muteRulesFile struct {
+	MuteRules []*securitycenter.MuteRule `json:"muteRules"`
+}
+
+func init() {
+	syncMuteRulesFlags.AddToFlagSet(syncMuteRulesCmd.Flags())
+}
+
+// syncMuteRulesRun syncs the mute rules from the file and prints the result
+func syncMuteRulesRun(ctx context.Context) error {
+	log := logging.CreateStdLog("sync")
+	rules, err := readMuteRules(file.Value())
+	if err != nil {
+		return err
+	}
+	result, err := syncMuteRules(ctx, log, source.Value(), rules, prune.Value(), googleServiceAccount.Value(), dryRun.Value())
+	if printErr := print.AsJSON(result); printErr != nil {
+		return printErr
+	}
+	return err
+}
+
+// readMuteRules reads mute rules from a YAML file, rejecting unknown fields
+func readMuteRules(filename string) ([]*securitycenter.MuteRule, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var rulesFile muteRulesFile
+	if err := yaml.UnmarshalStrict(data, &rulesFile); err != nil {
+		return nil, fmt.Errorf("could not parse mute rules file %s: %w", filename, err)
+	}
+	return rulesFile.MuteRules, nil
+}
+
+func syncMuteRules(ctx context.Context, log logr.Logger, sourceName string, rules []*securitycenter.MuteRule, prune bool, googleServiceAccount string, dryRun bool) (*securitycenter.MuteConfigSyncResult, error) {
+	securitycenterClient, err := securitycenter.NewClient(ctx, log, googleServiceAccount, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	defer securitycenterClient.Close()
+	return securitycenterClient.SyncMuteConfigs(ctx, sourceName, rules, prune)
+}
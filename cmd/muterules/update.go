@@ -0,0 +1,69 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package muterules
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/spf13/cobra"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/cmd/flag"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/logging"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/print"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+var (
+	updateMuteRuleFlags = flag.New(source, muteRule, category, clusterName, namespace, description, googleServiceAccount, dryRun)
+
+	updateMuteRuleCmd = &cobra.Command{
+		Use:   "update",
+		Short: "Replace the filter and description of a mute rule",
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if err := updateMuteRuleFlags.Validate(); err != nil {
+				return err
+			}
+			return muteRuleFromFlags().Validate()
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return updateMuteRuleRun(cmd.Context())
+		},
+	}
+)
+
+func init() {
+	updateMuteRuleFlags.AddToFlagSet(updateMuteRuleCmd.Flags())
+}
+
+// updateMuteRuleRun updates a mute rule and prints the mute config
+func updateMuteRuleRun(ctx context.Context) error {
+	log := logging.CreateStdLog("update")
+	muteConfig, err := updateMuteRule(ctx, log, source.Value(), muteRuleFromFlags(), googleServiceAccount.Value(), dryRun.Value())
+	if err != nil {
+		return err
+	}
+	return print.AsJSON(muteConfig)
+}
+
+func updateMuteRule(ctx context.Context, log logr.Logger, sourceName string, rule *securitycenter.MuteRule, googleServiceAccount string, dryRun bool) (*securitycenterpb.MuteConfig, error) {
+	securitycenterClient, err := securitycenter.NewClient(ctx, log, googleServiceAccount, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	defer securitycenterClient.Close()
+	return securitycenterClient.UpdateMuteConfig(ctx, sourceName, rule)
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/googlecloudplatform/gatekeeper-securitycenter/cmd/findings"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/cmd/muterules"
 	"github.com/googlecloudplatform/gatekeeper-securitycenter/cmd/sources"
 	"github.com/googlecloudplatform/gatekeeper-securitycenter/cmd/version"
 	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/signals"
@@ -39,12 +40,13 @@ var rootCmd = &cobra.Command{
   audit controller
 
 - a command-line tool that creates and manages the IAM policies of
-  Security Command Center sources`,
+  Security Command Center sources, and mute rules for findings`,
 }
 
 func init() {
 	rootCmd.AddCommand(
 		findings.Cmd,
+		muterules.Cmd,
 		sources.Cmd,
 		version.Cmd,
 	)
@@ -0,0 +1,296 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config reads the configuration file for the findings commands.
+//
+// The file values are defaults for command-line flags. Flags set on the
+// command line override the file values.
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Supported apiVersion and kind of the configuration file
+const (
+	APIVersion = "gatekeeper-securitycenter.cloud.google.com/v1alpha1"
+	Kind       = "Config"
+)
+
+// hotReloadable are the flags that `findings manager` applies without a
+// restart when the configuration file changes
+var hotReloadable = map[string]bool{
+	"interval":             true,
+	"security-mark-labels": true,
+}
+
+// Config is the configuration file format
+type Config struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// Source is the full name of the Security Command Center source
+	Source string `json:"source,omitempty"`
+	// Cluster name or other identifier, added to findings
+	Cluster string `json:"cluster,omitempty"`
+	// Interval is the control loop interval in seconds
+	Interval *int `json:"interval,omitempty"`
+	// DryRun skips all write operations
+	DryRun *bool `json:"dryRun,omitempty"`
+	// StateStore enables change detection between syncs
+	StateStore *StateStore `json:"stateStore,omitempty"`
+	// Webhook receives finding transitions
+	Webhook *Webhook `json:"webhook,omitempty"`
+	// Pubsub receives finding transitions
+	Pubsub *Pubsub `json:"pubsub,omitempty"`
+	// SecurityMarkLabels are the label keys copied to security marks
+	SecurityMarkLabels []string `json:"securityMarkLabels,omitempty"`
+	// Events selects the objects that receive Kubernetes Events: none, resources, or all
+	Events string `json:"events,omitempty"`
+	// AnnotateConstraints writes finding references onto constraints
+	AnnotateConstraints *bool `json:"annotateConstraints,omitempty"`
+	// AggregateOwners creates one finding per constraint and top-level controller
+	AggregateOwners *bool `json:"aggregateOwners,omitempty"`
+	// PolicyReports creates findings for failed and warned results in
+	// wgpolicyk8s.io policy reports
+	PolicyReports *bool `json:"policyReports,omitempty"`
+	// PolicyHealth creates findings for constraint templates and constraints
+	// that don't work as intended
+	PolicyHealth *bool `json:"policyHealth,omitempty"`
+	// AuditStaleness is the max age of the last Gatekeeper audit of a
+	// constraint, e.g., `1h`, or `0s` to disable staleness detection
+	AuditStaleness string `json:"auditStaleness,omitempty"`
+	// MarkStaleAudits adds a security mark to findings of constraints with stale audits
+	MarkStaleAudits *bool `json:"markStaleAudits,omitempty"`
+	// AdmissionEvents creates findings for Gatekeeper admission denials and warnings
+	AdmissionEvents *AdmissionEvents `json:"admissionEvents,omitempty"`
+	// MetricsAddress is the address of the Prometheus metrics endpoint, e.g., `:9090`
+	MetricsAddress string `json:"metricsAddress,omitempty"`
+	// LinkTemplates are the templates for links added to findings, keyed by link name
+	LinkTemplates map[string]string `json:"linkTemplates,omitempty"`
+	// ClusterProject is the Google Cloud project ID of the GKE cluster, used in links
+	ClusterProject string `json:"clusterProject,omitempty"`
+	// ClusterLocation is the location of the GKE cluster, used in links
+	ClusterLocation string `json:"clusterLocation,omitempty"`
+	// ConfigResource is the name of the GatekeeperSecurityCenterConfig resource
+	ConfigResource string `json:"configResource,omitempty"`
+	// FindingIDStrategy is how finding IDs are determined: spec or stable
+	FindingIDStrategy string `json:"findingIdStrategy,omitempty"`
+	// FindingGranularity is how many violations each finding reports:
+	// violation, constraint, or namespace
+	FindingGranularity string `json:"findingGranularity,omitempty"`
+	// DeactivationLimit holds deactivations of findings when a sync would deactivate too many
+	DeactivationLimit *DeactivationLimit `json:"deactivationLimit,omitempty"`
+}
+
+// DeactivationLimit configuration
+type DeactivationLimit struct {
+	// MaxDeactivations is the number of findings that a sync can always deactivate
+	MaxDeactivations *int `json:"maxDeactivations,omitempty"`
+	// MaxPercent is the percentage of active findings that a sync can always deactivate
+	MaxPercent *int `json:"maxPercent,omitempty"`
+	// Confirmations is the number of consecutive syncs that must exceed the limit before
+	// held deactivations are applied
+	Confirmations *int `json:"confirmations,omitempty"`
+}
+
+// AdmissionEvents configuration
+type AdmissionEvents struct {
+	// Namespace of the Gatekeeper admission events, or `*` for all namespaces
+	Namespace string `json:"namespace,omitempty"`
+	// FindingTTL is the time after the last denial that a finding stays active, e.g., `24h`
+	FindingTTL string `json:"findingTtl,omitempty"`
+}
+
+// StateStore configuration
+type StateStore struct {
+	// URI of the store, `file:///[path]` or `configmap://[namespace]/[name]`
+	URI string `json:"uri,omitempty"`
+	// ResyncInterval is the max time between syncs that list all findings, e.g., `1h`
+	ResyncInterval string `json:"resyncInterval,omitempty"`
+}
+
+// Webhook configuration
+type Webhook struct {
+	URL        string `json:"url,omitempty"`
+	SecretFile string `json:"secretFile,omitempty"`
+}
+
+// Pubsub configuration
+type Pubsub struct {
+	Topic    string `json:"topic,omitempty"`
+	Ordering *bool  `json:"ordering,omitempty"`
+}
+
+// Load reads and parses the configuration file. Unknown fields are errors.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse the configuration file contents. Unknown fields are errors.
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("could not parse config file: %w", err)
+	}
+	if cfg.APIVersion != APIVersion || cfg.Kind != Kind {
+		return nil, fmt.Errorf("unsupported config file apiVersion=[%v] kind=[%v], must be apiVersion=%s kind=%s", cfg.APIVersion, cfg.Kind, APIVersion, Kind)
+	}
+	return &cfg, nil
+}
+
+// FlagValues returns the values in the configuration file, keyed by flag
+// name. List values are comma-separated.
+func (c *Config) FlagValues() map[string]string {
+	values := map[string]string{}
+	if c == nil {
+		return values
+	}
+	setString := func(name, value string) {
+		if value != "" {
+			values[name] = value
+		}
+	}
+	setString("source", c.Source)
+	setString("cluster", c.Cluster)
+	if c.Interval != nil {
+		values["interval"] = strconv.Itoa(*c.Interval)
+	}
+	if c.DryRun != nil {
+		values["dry-run"] = strconv.FormatBool(*c.DryRun)
+	}
+	if c.StateStore != nil {
+		setString("state-store", c.StateStore.URI)
+		setString("state-resync-interval", c.StateStore.ResyncInterval)
+	}
+	if c.Webhook != nil {
+		setString("webhook-url", c.Webhook.URL)
+		setString("webhook-secret-file", c.Webhook.SecretFile)
+	}
+	if c.Pubsub != nil {
+		setString("pubsub-topic", c.Pubsub.Topic)
+		if c.Pubsub.Ordering != nil {
+			values["pubsub-ordering"] = strconv.FormatBool(*c.Pubsub.Ordering)
+		}
+	}
+	setString("cluster-project", c.ClusterProject)
+	setString("cluster-location", c.ClusterLocation)
+	if c.LinkTemplates != nil {
+		var items []string
+		for name, template := range c.LinkTemplates {
+			items = append(items, name+"="+template)
+		}
+		sort.Strings(items)
+		values["link-template"] = strings.Join(items, ListSeparator("link-template"))
+	}
+	setString("events", c.Events)
+	if c.AnnotateConstraints != nil {
+		values["annotate-constraints"] = strconv.FormatBool(*c.AnnotateConstraints)
+	}
+	if c.AggregateOwners != nil {
+		values["aggregate-owners"] = strconv.FormatBool(*c.AggregateOwners)
+	}
+	if c.PolicyReports != nil {
+		values["policy-reports"] = strconv.FormatBool(*c.PolicyReports)
+	}
+	if c.PolicyHealth != nil {
+		values["policy-health"] = strconv.FormatBool(*c.PolicyHealth)
+	}
+	setString("audit-staleness", c.AuditStaleness)
+	if c.MarkStaleAudits != nil {
+		values["mark-stale-audits"] = strconv.FormatBool(*c.MarkStaleAudits)
+	}
+	if c.AdmissionEvents != nil {
+		setString("admission-events", c.AdmissionEvents.Namespace)
+		setString("admission-finding-ttl", c.AdmissionEvents.FindingTTL)
+	}
+	setString("metrics-address", c.MetricsAddress)
+	setString("config-resource", c.ConfigResource)
+	setString("finding-id-strategy", c.FindingIDStrategy)
+	setString("finding-granularity", c.FindingGranularity)
+	if c.DeactivationLimit != nil {
+		setInt := func(name string, value *int) {
+			if value != nil {
+				values[name] = strconv.Itoa(*value)
+			}
+		}
+		setInt("max-deactivations", c.DeactivationLimit.MaxDeactivations)
+		setInt("max-deactivation-percent", c.DeactivationLimit.MaxPercent)
+		setInt("deactivation-confirmations", c.DeactivationLimit.Confirmations)
+	}
+	if c.SecurityMarkLabels != nil {
+		values["security-mark-labels"] = strings.Join(c.SecurityMarkLabels, ",")
+	}
+	return values
+}
+
+// ListSeparator returns the separator of list items in the FlagValues for
+// the named flag. Link templates can contain commas, so they are separated
+// by newlines.
+func ListSeparator(name string) string {
+	if name == "link-template" {
+		return "\n"
+	}
+	return ","
+}
+
+// Diff returns the names of flags with different values in the two
+// configurations, split by whether they can be applied without a restart.
+func Diff(oldCfg, newCfg *Config) (reloadable []string, restartRequired []string) {
+	oldValues := oldCfg.FlagValues()
+	newValues := newCfg.FlagValues()
+	names := map[string]bool{}
+	for name := range oldValues {
+		names[name] = true
+	}
+	for name := range newValues {
+		names[name] = true
+	}
+	for name := range names {
+		if oldValues[name] == newValues[name] {
+			continue
+		}
+		if hotReloadable[name] {
+			reloadable = append(reloadable, name)
+		} else {
+			restartRequired = append(restartRequired, name)
+		}
+	}
+	sort.Strings(reloadable)
+	sort.Strings(restartRequired)
+	return reloadable, restartRequired
+}
+
+// Reloaded returns a copy of the running configuration with the settings
+// from the changed configuration that are applied without a restart. Use it
+// to track the settings that are in effect, so settings that require a
+// restart are compared with their running values.
+func Reloaded(running, changed *Config) *Config {
+	reloaded := &Config{}
+	if running != nil {
+		*reloaded = *running
+	}
+	reloaded.Interval = changed.Interval
+	reloaded.SecurityMarkLabels = changed.SecurityMarkLabels
+	return reloaded
+}
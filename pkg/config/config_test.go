@@ -0,0 +1,221 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+)
+
+const validConfig = `
+apiVersion: gatekeeper-securitycenter.cloud.google.com/v1alpha1
+kind: Config
+source: organizations/123/sources/456
+cluster: my-cluster
+interval: 60
+dryRun: false
+stateStore:
+  uri: configmap://gatekeeper-securitycenter/gatekeeper-securitycenter-state
+  resyncInterval: 30m
+webhook:
+  url: https://example.com/hook
+pubsub:
+  topic: projects/my-project/topics/findings
+  ordering: true
+securityMarkLabels:
+- team
+- env
+clusterProject: my-project
+clusterLocation: us-central1
+linkTemplates:
+  ExternalUri: gke
+  Portal: https://portal.example.com/{{.ConstraintKind}}?a=1,b=2
+findingIdStrategy: stable
+findingGranularity: namespace
+aggregateOwners: true
+policyReports: true
+policyHealth: false
+auditStaleness: 30m
+markStaleAudits: true
+admissionEvents:
+  namespace: gatekeeper-system
+  findingTtl: 12h
+metricsAddress: :9090
+deactivationLimit:
+  maxPercent: 25
+  confirmations: 0
+`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "all fields",
+			data: validConfig,
+			want: map[string]string{
+				"source":                     "organizations/123/sources/456",
+				"cluster":                    "my-cluster",
+				"interval":                   "60",
+				"dry-run":                    "false",
+				"state-store":                "configmap://gatekeeper-securitycenter/gatekeeper-securitycenter-state",
+				"state-resync-interval":      "30m",
+				"webhook-url":                "https://example.com/hook",
+				"pubsub-topic":               "projects/my-project/topics/findings",
+				"pubsub-ordering":            "true",
+				"security-mark-labels":       "team,env",
+				"cluster-project":            "my-project",
+				"cluster-location":           "us-central1",
+				"link-template":              "ExternalUri=gke\nPortal=https://portal.example.com/{{.ConstraintKind}}?a=1,b=2",
+				"finding-id-strategy":        "stable",
+				"finding-granularity":        "namespace",
+				"aggregate-owners":           "true",
+				"policy-reports":             "true",
+				"policy-health":              "false",
+				"audit-staleness":            "30m",
+				"mark-stale-audits":          "true",
+				"admission-events":           "gatekeeper-system",
+				"admission-finding-ttl":      "12h",
+				"metrics-address":            ":9090",
+				"max-deactivation-percent":   "25",
+				"deactivation-confirmations": "0",
+			},
+		},
+		{
+			name: "only apiVersion and kind",
+			data: "apiVersion: " + APIVersion + "\nkind: " + Kind + "\n",
+			want: map[string]string{},
+		},
+		{
+			name:    "unknown field",
+			data:    "apiVersion: " + APIVersion + "\nkind: " + Kind + "\nsourceName: organizations/123/sources/456\n",
+			wantErr: true,
+		},
+		{
+			name:    "unsupported apiVersion",
+			data:    "apiVersion: v1\nkind: " + Kind + "\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing kind",
+			data:    "apiVersion: " + APIVersion + "\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Parse([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tt.want, cfg.FlagValues()); diff != "" {
+				t.Errorf("FlagValues() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	interval := 60
+	newInterval := 30
+	oldCfg := &Config{Source: "organizations/123/sources/456", Interval: &interval}
+	newCfg := &Config{Source: "organizations/123/sources/789", Interval: &newInterval, SecurityMarkLabels: []string{"team"}}
+	reloadable, restartRequired := Diff(oldCfg, newCfg)
+	if diff := cmp.Diff([]string{"interval", "security-mark-labels"}, reloadable); diff != "" {
+		t.Errorf("Diff() reloadable mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"source"}, restartRequired); diff != "" {
+		t.Errorf("Diff() restartRequired mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReloaded(t *testing.T) {
+	interval := 60
+	newInterval := 30
+	running := &Config{Source: "organizations/123/sources/456", Interval: &interval}
+	changed := &Config{Source: "organizations/123/sources/789", Interval: &newInterval, SecurityMarkLabels: []string{"team"}}
+	reloaded := Reloaded(running, changed)
+	if reloaded.Source != running.Source {
+		t.Errorf("Reloaded() source = %v, want running value %v", reloaded.Source, running.Source)
+	}
+	// only the settings that require a restart still differ
+	reloadable, restartRequired := Diff(reloaded, changed)
+	if len(reloadable) != 0 {
+		t.Errorf("Diff() after Reloaded() reloadable = %v, want none", reloadable)
+	}
+	if diff := cmp.Diff([]string{"source"}, restartRequired); diff != "" {
+		t.Errorf("Diff() after Reloaded() restartRequired mismatch (-want +got):\n%s", diff)
+	}
+	// reverting the file to the running values is a change of the reloaded settings only
+	reloadable, restartRequired = Diff(reloaded, running)
+	if diff := cmp.Diff([]string{"interval", "security-mark-labels"}, reloadable); diff != "" {
+		t.Errorf("Diff() after revert reloadable mismatch (-want +got):\n%s", diff)
+	}
+	if len(restartRequired) != 0 {
+		t.Errorf("Diff() after revert restartRequired = %v, want none", restartRequired)
+	}
+	// every hot-reloadable flag is copied
+	for name := range hotReloadable {
+		if reloaded.FlagValues()[name] != changed.FlagValues()[name] {
+			t.Errorf("Reloaded() didn't copy hot-reloadable flag %s", name)
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(validConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	changes := make(chan *Config)
+	go Watch(ctx, testr.New(t), path, 10*time.Millisecond, changes)
+
+	time.Sleep(50 * time.Millisecond)
+	if err := os.WriteFile(path, []byte("invalid: config\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if err := os.WriteFile(path, []byte(validConfig+"cluster2: x\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	updated := "apiVersion: " + APIVersion + "\nkind: " + Kind + "\ninterval: 30\n"
+	if err := os.WriteFile(path, []byte(updated), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case cfg := <-changes:
+		if cfg.Interval == nil || *cfg.Interval != 30 {
+			t.Errorf("expected interval 30, got %+v", cfg.Interval)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for config change")
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Watch polls the configuration file and sends the new configuration on the
+// changes channel when the file contents change. Invalid files are logged
+// and ignored. Polling works for ConfigMap volumes, where the kubelet
+// replaces the file using a symlink. Watch returns when the context is done.
+func Watch(ctx context.Context, log logr.Logger, path string, period time.Duration, changes chan<- *Config) {
+	last, err := os.ReadFile(path)
+	if err != nil {
+		log.Error(err, "could not read config file", "path", path)
+	}
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Error(err, "could not read config file", "path", path)
+			continue
+		}
+		if bytes.Equal(data, last) {
+			continue
+		}
+		last = data
+		cfg, err := Parse(data)
+		if err != nil {
+			log.Error(err, "ignoring invalid config file", "path", path)
+			continue
+		}
+		log.Info("config file changed", "path", path)
+		select {
+		case <-ctx.Done():
+			return
+		case changes <- cfg:
+		}
+	}
+}
@@ -21,10 +21,12 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 )
@@ -34,14 +36,28 @@ import (
 const (
 	defaultTimeout                  = 60 * time.Second
 	gatekeeperConstraintsAPIVersion = "v1beta1"
+	gatekeeperConstraintsGroup      = "constraints.gatekeeper.sh"
+	policyReportGroup               = "wgpolicyk8s.io"
 )
 
+// policyReportKinds are the kinds of the Policy Report API of the Kubernetes
+// Policy Working Group, see https://github.com/kubernetes-sigs/wg-policy-prototypes
+var policyReportKinds = []string{"PolicyReport", "ClusterPolicyReport"}
+
 var (
 	gatekeeperConstraintTemplateGVR = schema.GroupVersionResource{
 		Group:    "templates.gatekeeper.sh",
 		Version:  "v1beta1",
 		Resource: "constrainttemplates",
 	}
+	namespaceGVR = schema.GroupVersionResource{
+		Version:  "v1",
+		Resource: "namespaces",
+	}
+	eventGVR = schema.GroupVersionResource{
+		Version:  "v1",
+		Resource: "events",
+	}
 )
 
 // Client is a dynamic.Interface wrapper
@@ -85,22 +101,55 @@ func (c *Client) GetViolatedConstraints(ctx context.Context, groupResources []sc
 	return violatedConstraints, nil
 }
 
+// GetConstraints returns all constraints, including constraints without
+// violations
+func (c *Client) GetConstraints(ctx context.Context, groupResources []schema.GroupResource) ([]unstructured.Unstructured, error) {
+	var constraints []unstructured.Unstructured
+	for _, groupResource := range groupResources {
+		groupVersionResource := groupResource.WithVersion(gatekeeperConstraintsAPIVersion)
+		list, err := c.listResources(ctx, groupVersionResource)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, list.Items...)
+	}
+	return constraints, nil
+}
+
+// ConstraintGVR returns the GroupVersionResource for constraints of the
+// provided kind. Gatekeeper uses the lowercase kind as the resource name.
+func ConstraintGVR(constraintKind string) schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    gatekeeperConstraintsGroup,
+		Version:  gatekeeperConstraintsAPIVersion,
+		Resource: strings.ToLower(constraintKind),
+	}
+}
+
 // GetResourceByKind returns a resource by trying all the kind-to-GVR mappings
 // See explanation in kind.go
+//
+// The returned error wraps a NotFound API error if the resource wasn't found
+// using any of the mappings, or if there are no mappings for the kind, e.g.,
+// because the CRD was removed. Other errors, such as timeouts and missing
+// permissions, take precedence, so callers can tell a deleted resource from
+// a failed lookup.
 func (c *Client) GetResourceByKind(ctx context.Context, kind, name, namespace string, kindToGVR map[string][]schema.GroupVersionResource) (*unstructured.Unstructured, error) {
 	possibleGVRs := kindToGVR[kind]
-	var r *unstructured.Unstructured
-	var err error
+	if len(possibleGVRs) == 0 {
+		return nil, fmt.Errorf("no GroupVersionResource mappings for kind=[%v]: %w", kind, apierrors.NewNotFound(schema.GroupResource{Resource: kind}, name))
+	}
+	var lookupErr error
 	for _, gvr := range possibleGVRs {
-		r, err = c.getResource(ctx, gvr, name, namespace)
-		if err != nil {
-			break // found a match
+		r, err := c.getResource(ctx, gvr, name, namespace)
+		if err == nil {
+			return r, nil // found a match
+		}
+		if lookupErr == nil || apierrors.IsNotFound(lookupErr) {
+			lookupErr = err
 		}
 	}
-	if r == nil {
-		return nil, fmt.Errorf("could not find resource with kind=[%v] name=[%v] in namespace=[%v] using any of these GroupVersionResource mappings=%+v", kind, name, namespace, possibleGVRs)
-	}
-	return r, nil
+	return nil, fmt.Errorf("could not find resource with kind=[%v] name=[%v] in namespace=[%v] using any of these GroupVersionResource mappings=%+v: %w", kind, name, namespace, possibleGVRs, lookupErr)
 }
 
 // GetConstraintTemplate returns the constraint template for the provided constraint Kind
@@ -110,6 +159,83 @@ func (c *Client) GetConstraintTemplate(ctx context.Context, constraintKind strin
 	return c.dynamic.Resource(gatekeeperConstraintTemplateGVR).Get(ctx, constraintTemplateName, metav1.GetOptions{})
 }
 
+// GetConstraintTemplates returns all constraint templates
+func (c *Client) GetConstraintTemplates(ctx context.Context) ([]unstructured.Unstructured, error) {
+	list, err := c.listResources(ctx, gatekeeperConstraintTemplateGVR)
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// ListWarningEvents returns the Kubernetes Events of type Warning in the
+// namespace. Use an empty namespace to list events in all namespaces.
+func (c *Client) ListWarningEvents(ctx context.Context, namespace string) ([]unstructured.Unstructured, error) {
+	c.log.V(2).Info("listing warning events", "namespace", namespace)
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	list, err := c.dynamic.Resource(eventGVR).Namespace(namespace).List(ctx, metav1.ListOptions{FieldSelector: "type=Warning"})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// GetPolicyReports returns all PolicyReports and ClusterPolicyReports in the
+// wgpolicyk8s.io API group, using the most recent version served by the API
+// server. Returns no reports if the API server doesn't serve the API group.
+func (c *Client) GetPolicyReports(ctx context.Context, kindToGVR map[string][]schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+	var reports []unstructured.Unstructured
+	for _, kind := range policyReportKinds {
+		var gvr *schema.GroupVersionResource
+		for i, candidate := range kindToGVR[kind] {
+			if candidate.Group == policyReportGroup && (gvr == nil || version.CompareKubeAwareVersionStrings(candidate.Version, gvr.Version) > 0) {
+				gvr = &kindToGVR[kind][i]
+			}
+		}
+		if gvr == nil {
+			c.log.V(1).Info("policy report resource type not found", "kind", kind, "apiGroup", policyReportGroup)
+			continue
+		}
+		list, err := c.listResources(ctx, *gvr)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, list.Items...)
+	}
+	c.log.V(1).Info("Policy reports", "count", len(reports))
+	return reports, nil
+}
+
+// GetNamespace returns the namespace with the provided name
+func (c *Client) GetNamespace(ctx context.Context, name string) (*unstructured.Unstructured, error) {
+	return c.getResource(ctx, namespaceGVR, name, "")
+}
+
+// GetResource returns the resource with the provided GVR, name, and
+// namespace. Use an empty namespace for cluster-scoped resources.
+func (c *Client) GetResource(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+	return c.getResource(ctx, gvr, name, namespace)
+}
+
+// UpdateResourceStatus updates the status subresource of the provided object
+func (c *Client) UpdateResourceStatus(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	c.log.V(2).Info("updating resource status", "name", obj.GetName(), "namespace", obj.GetNamespace(), "apiGroup", gvr.Group, "apiVersion", gvr.Version, "resourceType", gvr.Resource)
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.dynamic.Resource(gvr).Namespace(obj.GetNamespace()).UpdateStatus(ctx, obj, metav1.UpdateOptions{})
+}
+
+// ApplyResource applies the provided object using server-side apply with
+// the provided field manager. The object should contain only the fields
+// owned by the field manager.
+func (c *Client) ApplyResource(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, fieldManager string) (*unstructured.Unstructured, error) {
+	c.log.V(2).Info("applying resource", "name", obj.GetName(), "namespace", obj.GetNamespace(), "apiGroup", gvr.Group, "apiVersion", gvr.Version, "resourceType", gvr.Resource, "fieldManager", fieldManager)
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.dynamic.Resource(gvr).Namespace(obj.GetNamespace()).Apply(ctx, obj.GetName(), obj, metav1.ApplyOptions{FieldManager: fieldManager, Force: true})
+}
+
 // getResource for the provided GVR
 func (c *Client) getResource(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
 	c.log.V(2).Info("getting resource", "name", name, "namespace", namespace, "apiGroup", gvr.Group, "apiVersion", gvr.Version, "resourceType", gvr.Resource)
@@ -0,0 +1,258 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package events records Kubernetes Events on violating resources, and
+// optionally on constraints, when findings are created, reactivated, or
+// resolved.
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/time/rate"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+const (
+	component = "gatekeeper-securitycenter"
+
+	// Event reasons
+	ReasonFindingCreated     = "FindingCreated"
+	ReasonFindingReactivated = "FindingReactivated"
+	ReasonFindingResolved    = "FindingResolved"
+
+	// Event annotations
+	AnnotationFindingName = "gatekeeper-securitycenter/finding-name"
+	AnnotationConsoleURL  = "gatekeeper-securitycenter/console-url"
+
+	// defaultConstraintBurst is the number of transitions per constraint
+	// that are recorded before rate limiting starts
+	defaultConstraintBurst = 25
+	// defaultConstraintInterval is the time to earn one more event per
+	// constraint after the burst is used up
+	defaultConstraintInterval = 10 * time.Second
+
+	constraintAPIVersion = "constraints.gatekeeper.sh/v1beta1"
+)
+
+// Recorder records a Kubernetes Event for each finding transition.
+// Implements the sync.Sink interface.
+//
+// Events for each constraint are rate limited, so a constraint with many
+// violations can't flood the API server. The broadcaster also applies the
+// client-go spam filter to events for each object.
+type Recorder struct {
+	log                logr.Logger
+	recorder           record.EventRecorder
+	broadcaster        record.EventBroadcaster
+	includeConstraints bool
+	burst              int
+	interval           time.Duration
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter // key from limiterKey()
+}
+
+// New creates a Recorder that sends events to the API server using the
+// provided config. If includeConstraints is true, the Recorder also records
+// events on the constraints. Use defer Recorder.Close() to clean up.
+func New(log logr.Logger, config *rest.Config, includeConstraints bool) (*Recorder, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	recorder := NewWithRecorder(log, broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: component}), includeConstraints)
+	recorder.broadcaster = broadcaster
+	return recorder, nil
+}
+
+// NewWithRecorder creates a Recorder that uses the provided EventRecorder,
+// e.g., record.FakeRecorder for tests.
+func NewWithRecorder(log logr.Logger, recorder record.EventRecorder, includeConstraints bool) *Recorder {
+	return &Recorder{
+		log:                log,
+		recorder:           recorder,
+		includeConstraints: includeConstraints,
+		burst:              defaultConstraintBurst,
+		interval:           defaultConstraintInterval,
+		limiters:           map[string]*rate.Limiter{},
+	}
+}
+
+// SetRateLimit sets the number of transitions per constraint that are
+// recorded before rate limiting starts, and the time to earn one more event
+// after that.
+func (r *Recorder) SetRateLimit(burst int, interval time.Duration) error {
+	if burst < 1 {
+		return fmt.Errorf("invalid burst: %v", burst)
+	}
+	if interval <= 0 {
+		return fmt.Errorf("invalid interval: %v", interval)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.burst = burst
+	r.interval = interval
+	r.limiters = map[string]*rate.Limiter{}
+	return nil
+}
+
+// Close stops the event broadcaster, implements io.Closer
+func (r *Recorder) Close() error {
+	if r.broadcaster != nil {
+		r.broadcaster.Shutdown()
+	}
+	return nil
+}
+
+// Send records events for findings that were created, reactivated, or
+// resolved. Events are best effort, so Send doesn't return errors.
+func (r *Recorder) Send(_ context.Context, transitions []*securitycenter.Transition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	dropped := map[string]int{}
+	for _, transition := range transitions {
+		eventType, reason, verb := eventFor(transition)
+		if reason == "" {
+			continue
+		}
+		finding := transition.Finding
+		resource, ok := resourceReference(finding)
+		if !ok {
+			r.log.V(1).Info("skipping event for finding without resource reference", "findingName", transition.FindingName)
+			continue
+		}
+		constraint := constraintReference(finding)
+		if !r.allow(limiterKey(constraint)) {
+			dropped[constraint.Name]++
+			continue
+		}
+		consoleURL := securitycenter.FindingConsoleURL(transition.FindingName)
+		annotations := map[string]string{
+			AnnotationFindingName: transition.FindingName,
+			AnnotationConsoleURL:  consoleURL,
+		}
+		fromPolicyReport := securitycenter.FromPolicyReport(finding)
+		violated := fmt.Sprintf("%s %s", constraint.Kind, constraint.Name)
+		if fromPolicyReport {
+			violated = fmt.Sprintf("policy %s", constraint.Name)
+		}
+		message := fmt.Sprintf("Security Command Center finding %s for violation of %s: %s. Finding: %s Console: %s",
+			verb, violated, property(finding, "Explanation"), transition.FindingName, consoleURL)
+		r.recorder.AnnotatedEventf(resource, annotations, eventType, reason, "%s", message)
+		// findings from policy reports don't have a constraint object
+		if r.includeConstraints && constraint.Name != "" && !fromPolicyReport {
+			message := fmt.Sprintf("Security Command Center finding %s for %s %s in namespace [%s]. Finding: %s Console: %s",
+				verb, resource.Kind, resource.Name, resource.Namespace, transition.FindingName, consoleURL)
+			r.recorder.AnnotatedEventf(constraint, annotations, eventType, reason, "%s", message)
+		}
+	}
+	for constraintName, count := range dropped {
+		r.log.Info("rate limited events for constraint", "constraint", constraintName, "dropped", count)
+	}
+	return nil
+}
+
+// allow returns true if an event for the constraint with the provided
+// limiter key can be recorded now
+func (r *Recorder) allow(key string) bool {
+	limiter, exists := r.limiters[key]
+	if !exists {
+		limiter = rate.NewLimiter(rate.Every(r.interval), r.burst)
+		r.limiters[key] = limiter
+	}
+	return limiter.Allow()
+}
+
+// eventFor returns the event type, reason, and the verb used in the message
+// for the transition. The reason is empty for transitions that don't need
+// an event.
+func eventFor(transition *securitycenter.Transition) (string, string, string) {
+	switch {
+	case transition.OldState == securitycenterpb.Finding_STATE_UNSPECIFIED:
+		return corev1.EventTypeWarning, ReasonFindingCreated, "created"
+	case transition.NewState == securitycenterpb.Finding_ACTIVE:
+		return corev1.EventTypeWarning, ReasonFindingReactivated, "reactivated"
+	case transition.NewState == securitycenterpb.Finding_INACTIVE:
+		return corev1.EventTypeNormal, ReasonFindingResolved, "resolved"
+	default:
+		return "", "", ""
+	}
+}
+
+// resourceReference returns a reference to the violating resource, using
+// the source properties of the finding
+func resourceReference(finding *securitycenterpb.Finding) (*corev1.ObjectReference, bool) {
+	kind := property(finding, "ResourceKind")
+	name := property(finding, "ResourceName")
+	version := property(finding, "ResourceAPIVersion")
+	if kind == "" || name == "" || version == "" {
+		return nil, false
+	}
+	return &corev1.ObjectReference{
+		APIVersion: schema.GroupVersion{Group: property(finding, "ResourceAPIGroup"), Version: version}.String(),
+		Kind:       kind,
+		Name:       name,
+		Namespace:  property(finding, "ResourceNamespace"),
+		UID:        types.UID(property(finding, "ResourceUID")),
+	}, true
+}
+
+// constraintReference returns a reference to the constraint, using the
+// category and source properties of the finding. Findings that aren't
+// created by the audit, e.g., admission denials, have a ConstraintKind
+// property, because their category isn't the constraint kind.
+func constraintReference(finding *securitycenterpb.Finding) *corev1.ObjectReference {
+	kind := property(finding, "ConstraintKind")
+	if kind == "" {
+		kind = finding.GetCategory()
+	}
+	return &corev1.ObjectReference{
+		APIVersion: constraintAPIVersion,
+		Kind:       kind,
+		Name:       property(finding, "ConstraintName"),
+		UID:        types.UID(property(finding, "ConstraintUID")),
+	}
+}
+
+// limiterKey returns the key of the rate limiter for the constraint. Not
+// all findings have a constraint UID, e.g., admission denials, so those use
+// the constraint kind and name instead.
+func limiterKey(constraint *corev1.ObjectReference) string {
+	if constraint.UID != "" {
+		return string(constraint.UID)
+	}
+	return constraint.Kind + "/" + constraint.Name
+}
+
+// property returns a string source property of the finding
+func property(finding *securitycenterpb.Finding, key string) string {
+	return finding.GetSourceProperties()[key].GetStringValue()
+}
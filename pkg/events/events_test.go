@@ -0,0 +1,241 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+const source = "organizations/123/sources/456"
+
+func transition(id, constraintUID string, oldState, newState securitycenterpb.Finding_State) *securitycenter.Transition {
+	name := source + "/findings/" + id
+	return &securitycenter.Transition{
+		FindingName: name,
+		OldState:    oldState,
+		NewState:    newState,
+		Finding: &securitycenterpb.Finding{
+			Name:     name,
+			Parent:   source,
+			State:    newState,
+			Category: "K8sRequiredLabels",
+			SourceProperties: map[string]*structpb.Value{
+				"Explanation":        structpb.NewStringValue("you must provide labels"),
+				"ConstraintName":     structpb.NewStringValue("ns-must-have-owner"),
+				"ConstraintUID":      structpb.NewStringValue(constraintUID),
+				"ResourceName":       structpb.NewStringValue("frontend"),
+				"ResourceNamespace":  structpb.NewStringValue("default"),
+				"ResourceUID":        structpb.NewStringValue("resource-uid-" + id),
+				"ResourceAPIGroup":   structpb.NewStringValue("apps"),
+				"ResourceAPIVersion": structpb.NewStringValue("v1"),
+				"ResourceKind":       structpb.NewStringValue("Deployment"),
+			},
+		},
+	}
+}
+
+// drain returns the events recorded by the fake recorder
+func drain(fake *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case event := <-fake.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestRecorder_Send(t *testing.T) {
+	tests := []struct {
+		name               string
+		transitions        []*securitycenter.Transition
+		includeConstraints bool
+		wantPrefixes       []string
+	}{
+		{
+			name: "created",
+			transitions: []*securitycenter.Transition{
+				transition("a", "c1", securitycenterpb.Finding_STATE_UNSPECIFIED, securitycenterpb.Finding_ACTIVE),
+			},
+			wantPrefixes: []string{"Warning FindingCreated"},
+		},
+		{
+			name: "reactivated and resolved",
+			transitions: []*securitycenter.Transition{
+				transition("a", "c1", securitycenterpb.Finding_INACTIVE, securitycenterpb.Finding_ACTIVE),
+				transition("b", "c1", securitycenterpb.Finding_ACTIVE, securitycenterpb.Finding_INACTIVE),
+			},
+			wantPrefixes: []string{"Warning FindingReactivated", "Normal FindingResolved"},
+		},
+		{
+			name: "include constraints",
+			transitions: []*securitycenter.Transition{
+				transition("a", "c1", securitycenterpb.Finding_STATE_UNSPECIFIED, securitycenterpb.Finding_ACTIVE),
+			},
+			includeConstraints: true,
+			wantPrefixes:       []string{"Warning FindingCreated", "Warning FindingCreated"},
+		},
+		{
+			name: "rate limited per constraint",
+			transitions: []*securitycenter.Transition{
+				transition("a", "c1", securitycenterpb.Finding_STATE_UNSPECIFIED, securitycenterpb.Finding_ACTIVE),
+				transition("b", "c1", securitycenterpb.Finding_STATE_UNSPECIFIED, securitycenterpb.Finding_ACTIVE),
+				transition("c", "c1", securitycenterpb.Finding_STATE_UNSPECIFIED, securitycenterpb.Finding_ACTIVE),
+				transition("d", "c2", securitycenterpb.Finding_STATE_UNSPECIFIED, securitycenterpb.Finding_ACTIVE),
+			},
+			wantPrefixes: []string{"Warning FindingCreated", "Warning FindingCreated", "Warning FindingCreated"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := record.NewFakeRecorder(10)
+			recorder := NewWithRecorder(testr.New(t), fake, tt.includeConstraints)
+			if err := recorder.SetRateLimit(2, time.Hour); err != nil {
+				t.Fatal(err)
+			}
+			if err := recorder.Send(context.Background(), tt.transitions); err != nil {
+				t.Fatal(err)
+			}
+			got := drain(fake)
+			if len(got) != len(tt.wantPrefixes) {
+				t.Fatalf("Send() recorded %d events, want %d: %v", len(got), len(tt.wantPrefixes), got)
+			}
+			for i, event := range got {
+				if !strings.HasPrefix(event, tt.wantPrefixes[i]) {
+					t.Errorf("Send() event %d = %q, want prefix %q", i, event, tt.wantPrefixes[i])
+				}
+				if !strings.Contains(event, "organizationId=123") {
+					t.Errorf("Send() event %d = %q, want console URL", i, event)
+				}
+			}
+		})
+	}
+}
+
+func TestRecorder_Send_skipsFindingsWithoutResource(t *testing.T) {
+	fake := record.NewFakeRecorder(10)
+	recorder := NewWithRecorder(testr.New(t), fake, false)
+	tr := transition("a", "c1", securitycenterpb.Finding_STATE_UNSPECIFIED, securitycenterpb.Finding_ACTIVE)
+	delete(tr.Finding.SourceProperties, "ResourceKind")
+	if err := recorder.Send(context.Background(), []*securitycenter.Transition{tr}); err != nil {
+		t.Fatal(err)
+	}
+	if got := drain(fake); len(got) != 0 {
+		t.Errorf("Send() recorded events for finding without resource reference: %v", got)
+	}
+}
+
+func admissionTransition(id, constraintName string) *securitycenter.Transition {
+	tr := transition(id, "", securitycenterpb.Finding_STATE_UNSPECIFIED, securitycenterpb.Finding_ACTIVE)
+	tr.Finding.Category = "ADMISSION_VIOLATION"
+	delete(tr.Finding.SourceProperties, "ConstraintUID")
+	tr.Finding.SourceProperties["ConstraintKind"] = structpb.NewStringValue("K8sRequiredLabels")
+	tr.Finding.SourceProperties["ConstraintName"] = structpb.NewStringValue(constraintName)
+	return tr
+}
+
+func TestRecorder_Send_admissionFindings(t *testing.T) {
+	fake := record.NewFakeRecorder(10)
+	recorder := NewWithRecorder(testr.New(t), fake, true)
+	if err := recorder.SetRateLimit(1, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	transitions := []*securitycenter.Transition{
+		admissionTransition("a", "ns-must-have-owner"),
+		admissionTransition("b", "ns-must-have-owner"),
+		admissionTransition("c", "ns-must-have-team"),
+	}
+	if err := recorder.Send(context.Background(), transitions); err != nil {
+		t.Fatal(err)
+	}
+	got := drain(fake)
+	// one resource event and one constraint event for each constraint,
+	// the second denial for the first constraint is rate limited
+	if len(got) != 4 {
+		t.Fatalf("Send() recorded %d events, want 4: %v", len(got), got)
+	}
+	for i, event := range got {
+		if strings.Contains(event, "ADMISSION_VIOLATION") {
+			t.Errorf("Send() event %d = %q, want constraint kind instead of category", i, event)
+		}
+	}
+	if !strings.Contains(got[0], "violation of K8sRequiredLabels ns-must-have-owner") {
+		t.Errorf("Send() event 0 = %q, want constraint kind and name", got[0])
+	}
+}
+
+func Test_constraintReference(t *testing.T) {
+	tests := []struct {
+		name       string
+		transition *securitycenter.Transition
+		wantKind   string
+		wantKey    string
+	}{
+		{
+			name:       "audit",
+			transition: transition("a", "c1", securitycenterpb.Finding_STATE_UNSPECIFIED, securitycenterpb.Finding_ACTIVE),
+			wantKind:   "K8sRequiredLabels",
+			wantKey:    "c1",
+		},
+		{
+			name:       "admission",
+			transition: admissionTransition("a", "ns-must-have-owner"),
+			wantKind:   "K8sRequiredLabels",
+			wantKey:    "K8sRequiredLabels/ns-must-have-owner",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := constraintReference(tt.transition.Finding)
+			if got.Kind != tt.wantKind {
+				t.Errorf("constraintReference() kind = %q, want %q", got.Kind, tt.wantKind)
+			}
+			if key := limiterKey(got); key != tt.wantKey {
+				t.Errorf("limiterKey() = %q, want %q", key, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestRecorder_Send_policyReportFindings(t *testing.T) {
+	fake := record.NewFakeRecorder(10)
+	recorder := NewWithRecorder(testr.New(t), fake, true)
+	tr := transition("a", "POLICY_REPORT/kyverno/require-labels/check-team", securitycenterpb.Finding_STATE_UNSPECIFIED, securitycenterpb.Finding_ACTIVE)
+	tr.Finding.Category = "require-labels"
+	tr.Finding.SourceProperties["ScannerName"] = structpb.NewStringValue("POLICY_REPORT")
+	tr.Finding.SourceProperties["ConstraintName"] = structpb.NewStringValue("require-labels")
+	if err := recorder.Send(context.Background(), []*securitycenter.Transition{tr}); err != nil {
+		t.Fatal(err)
+	}
+	got := drain(fake)
+	if len(got) != 1 {
+		t.Fatalf("Send() recorded %d events, want only the resource event: %v", len(got), got)
+	}
+	if !strings.Contains(got[0], "violation of policy require-labels:") {
+		t.Errorf("Send() event = %q, want policy name", got[0])
+	}
+}
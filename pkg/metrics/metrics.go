@@ -0,0 +1,118 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics exposes controller metrics in the Prometheus format
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "gatekeeper_securitycenter"
+
+	// Path of the metrics endpoint
+	Path = "/metrics"
+
+	shutdownTimeout = 5 * time.Second
+)
+
+// AuditAge is the time since the last Gatekeeper audit of a constraint
+type AuditAge struct {
+	ConstraintKind string
+	ConstraintName string
+	Age            time.Duration
+	// Stale is true if the age exceeds the staleness threshold, or if the
+	// audit timestamp is missing or can't be parsed
+	Stale bool
+}
+
+// Metrics records controller metrics
+type Metrics struct {
+	registry    *prometheus.Registry
+	auditAge    *prometheus.GaugeVec
+	staleAudits prometheus.Gauge
+}
+
+// New creates Metrics with its own registry
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		auditAge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "constraint_audit_age_seconds",
+			Help:      "Time since the last Gatekeeper audit of the constraint, as of the last sync.",
+		}, []string{"constraint_kind", "constraint_name"}),
+		staleAudits: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "stale_audit_constraints",
+			Help:      "Number of constraints whose last Gatekeeper audit is older than the staleness threshold, or unknown, as of the last sync.",
+		}),
+	}
+	m.registry.MustRegister(m.auditAge, m.staleAudits)
+	return m
+}
+
+// SetAuditAges replaces the audit ages of all constraints. Constraints with
+// an unknown audit time are counted as stale, but don't have an age.
+func (m *Metrics) SetAuditAges(ages []AuditAge) {
+	m.auditAge.Reset()
+	stale := 0
+	for _, age := range ages {
+		if age.Stale {
+			stale++
+		}
+		if age.Age > 0 {
+			m.auditAge.WithLabelValues(age.ConstraintKind, age.ConstraintName).Set(age.Age.Seconds())
+		}
+	}
+	m.staleAudits.Set(float64(stale))
+}
+
+// Handler returns the HTTP handler for the metrics endpoint
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ListenAndServe serves the metrics endpoint on the address until the
+// context is done
+func (m *Metrics) ListenAndServe(ctx context.Context, log logr.Logger, address string) error {
+	mux := http.NewServeMux()
+	mux.Handle(Path, m.Handler())
+	server := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Error(err, "could not shut down metrics server")
+		}
+	}()
+	log.Info("serving metrics", "address", address, "path", Path)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics_SetAuditAges(t *testing.T) {
+	m := New()
+	m.SetAuditAges([]AuditAge{
+		{ConstraintKind: "K8sRequiredLabels", ConstraintName: "ns-must-have-geo", Age: 30 * time.Second},
+		{ConstraintKind: "K8sRequiredLabels", ConstraintName: "stale", Age: 2 * time.Hour, Stale: true},
+		{ConstraintKind: "K8sAllowedRepos", ConstraintName: "unparseable", Stale: true},
+	})
+	if got := testutil.ToFloat64(m.staleAudits); got != 2 {
+		t.Errorf("stale_audit_constraints = %v, want 2", got)
+	}
+	if got := testutil.CollectAndCount(m.auditAge); got != 2 {
+		t.Errorf("constraint_audit_age_seconds series = %d, want 2", got)
+	}
+	if got := testutil.ToFloat64(m.auditAge.WithLabelValues("K8sRequiredLabels", "stale")); got != 7200 {
+		t.Errorf("constraint_audit_age_seconds = %v, want 7200", got)
+	}
+
+	// deleted constraints are removed
+	m.SetAuditAges([]AuditAge{
+		{ConstraintKind: "K8sRequiredLabels", ConstraintName: "ns-must-have-geo", Age: 10 * time.Second},
+	})
+	if got := testutil.ToFloat64(m.staleAudits); got != 0 {
+		t.Errorf("stale_audit_constraints = %v, want 0", got)
+	}
+	if got := testutil.CollectAndCount(m.auditAge); got != 1 {
+		t.Errorf("constraint_audit_age_seconds series = %d, want 1", got)
+	}
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	m.SetAuditAges([]AuditAge{{ConstraintKind: "K8sRequiredLabels", ConstraintName: "stale", Age: time.Hour, Stale: true}})
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", Path, nil))
+	body := recorder.Body.String()
+	for _, want := range []string{
+		`gatekeeper_securitycenter_constraint_audit_age_seconds{constraint_kind="K8sRequiredLabels",constraint_name="stale"} 3600`,
+		`gatekeeper_securitycenter_stale_audit_constraints 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output doesn't contain %q:\n%s", want, body)
+		}
+	}
+}
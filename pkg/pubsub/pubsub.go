@@ -0,0 +1,200 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/go-logr/logr"
+	"google.golang.org/api/impersonate"
+	"google.golang.org/api/option"
+	"google.golang.org/protobuf/encoding/protojson"
+	errorutils "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/version"
+)
+
+// Ref: https://pkg.go.dev/cloud.google.com/go/pubsub
+//
+// The client connects to the Pub/Sub emulator if the PUBSUB_EMULATOR_HOST
+// environment variable is set.
+// Ref: https://cloud.google.com/pubsub/docs/emulator
+
+// defaultMaxPending is the max number of transitions that failed to publish
+// and are kept for the next call to Send
+const defaultMaxPending = 1000
+
+var topicNameRegexp = regexp.MustCompile(`^projects/([^/]+)/topics/([^/]+)$`)
+
+// Message is the JSON payload published for each finding transition
+type Message struct {
+	FindingName string          `json:"findingName"`
+	OldState    string          `json:"oldState"`
+	NewState    string          `json:"newState"`
+	Finding     json.RawMessage `json:"finding"`
+}
+
+// Client publishes finding transitions to a Pub/Sub topic.
+// Implements the sync.Sink interface.
+type Client struct {
+	client     *pubsub.Client
+	topic      *pubsub.Topic
+	log        logr.Logger
+	maxPending int
+
+	mu sync.Mutex
+	// pending holds transitions that could not be published, oldest first.
+	// They are published again on the next call to Send. At most maxPending
+	// transitions are kept.
+	pending []*securitycenter.Transition
+}
+
+// NewClient creates a Client that publishes to the provided topic, in the
+// format `projects/[project_id]/topics/[topic_id]`.
+// Remember to `defer Close()` to clean up.
+//
+// If ordering is true, messages for the same finding are published in
+// order, using the finding name as the ordering key. The subscription must
+// have message ordering enabled to receive the messages in order.
+//
+// Optional arguments:
+// - a Google Service Account to impersonate. Defaults to no impersonation for empty string.
+// - ClientOptions from the google.golang.org/api/option package
+func NewClient(ctx context.Context, log logr.Logger, topicName string, ordering bool, googleServiceAccount string, opts ...option.ClientOption) (*Client, error) {
+	match := topicNameRegexp.FindStringSubmatch(topicName)
+	if match == nil {
+		return nil, fmt.Errorf("invalid topic name: [%v]", topicName)
+	}
+	projectID, topicID := match[1], match[2]
+	if googleServiceAccount != "" {
+		log.V(1).Info("impersonating Google service account", "serviceAccount", googleServiceAccount)
+		tokenSource, err := impersonate.CredentialsTokenSource(ctx, impersonate.CredentialsConfig{
+			TargetPrincipal: googleServiceAccount,
+			Scopes:          []string{pubsub.ScopePubSub},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not create token source for Google service account %s: %w", googleServiceAccount, err)
+		}
+		opts = append(opts, option.WithTokenSource(tokenSource))
+	}
+	opts = append(opts, option.WithUserAgent("cloud-solutions/gatekeeper-securitycenter-"+version.Version))
+	pubsubClient, err := pubsub.NewClient(ctx, projectID, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not create pubsub client: %w", err)
+	}
+	topic := pubsubClient.Topic(topicID)
+	topic.EnableMessageOrdering = ordering
+	return &Client{
+		client:     pubsubClient,
+		topic:      topic,
+		log:        log,
+		maxPending: defaultMaxPending,
+	}, nil
+}
+
+// SetMaxPending sets the max number of transitions that failed to publish
+// and are kept for the next call to Send
+func (c *Client) SetMaxPending(maxPending int) error {
+	if maxPending < 1 {
+		return fmt.Errorf("invalid maxPending: %v", maxPending)
+	}
+	c.maxPending = maxPending
+	return nil
+}
+
+// Close flushes outstanding messages and cleans up
+func (c *Client) Close() error {
+	c.topic.Stop()
+	return c.client.Close()
+}
+
+// Send publishes a message for each transition, and waits for the results.
+// Transitions that failed to publish on earlier calls are published first.
+// Transitions that fail to publish are kept and published again on the next
+// call. If more than maxPending transitions fail, the oldest are dropped.
+func (c *Client) Send(ctx context.Context, transitions []*securitycenter.Transition) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	transitions = append(c.pending, transitions...)
+	c.pending = nil
+	var results []*pubsub.PublishResult
+	var published []*securitycenter.Transition // transition for each result
+	var errs []error
+	for _, transition := range transitions {
+		msg, err := newMessage(transition)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not create message for finding %s: %w", transition.FindingName, err))
+			continue
+		}
+		if c.topic.EnableMessageOrdering {
+			msg.OrderingKey = transition.FindingName
+		}
+		c.log.V(2).Info("publishing message", "topic", c.topic.String(), "findingName", transition.FindingName, "newState", transition.NewState.String())
+		results = append(results, c.topic.Publish(ctx, msg))
+		published = append(published, transition)
+	}
+	for i, result := range results {
+		if _, err := result.Get(ctx); err != nil {
+			findingName := published[i].FindingName
+			errs = append(errs, fmt.Errorf("could not publish message for finding %s: %w", findingName, err))
+			c.pending = append(c.pending, published[i])
+			if c.topic.EnableMessageOrdering {
+				// publishing for an ordering key is paused after an error
+				c.topic.ResumePublish(findingName)
+			}
+		}
+	}
+	if dropped := len(c.pending) - c.maxPending; dropped > 0 {
+		c.log.Info("dropping oldest transitions that could not be published", "dropped", dropped)
+		c.pending = c.pending[dropped:]
+	}
+	if len(c.pending) > 0 {
+		c.log.Info("keeping transitions that could not be published for the next sync", "pending", len(c.pending))
+	}
+	return errorutils.NewAggregate(errs)
+}
+
+// newMessage creates a Pub/Sub message for the transition. The message
+// attributes allow subscriptions to filter on the finding states and category.
+func newMessage(transition *securitycenter.Transition) (*pubsub.Message, error) {
+	findingJSON, err := protojson.Marshal(transition.Finding)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(&Message{
+		FindingName: transition.FindingName,
+		OldState:    transition.OldState.String(),
+		NewState:    transition.NewState.String(),
+		Finding:     findingJSON,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pubsub.Message{
+		Data: data,
+		Attributes: map[string]string{
+			"findingName": transition.FindingName,
+			"oldState":    transition.OldState.String(),
+			"newState":    transition.NewState.String(),
+			"category":    transition.Finding.GetCategory(),
+		},
+	}, nil
+}
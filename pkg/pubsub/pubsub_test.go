@@ -0,0 +1,181 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"cloud.google.com/go/pubsub/apiv1/pubsubpb"
+	"cloud.google.com/go/pubsub/pstest"
+	"github.com/go-logr/logr/testr"
+	"google.golang.org/api/option"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+const (
+	topicName = "projects/my-project/topics/findings"
+	source    = "organizations/123/sources/456"
+)
+
+func newTestClient(t *testing.T, ctx context.Context, ordering bool) (*Client, *pstest.Server) {
+	server := pstest.NewServer()
+	t.Cleanup(func() { _ = server.Close() })
+	if _, err := server.GServer.CreateTopic(ctx, &pubsubpb.Topic{Name: topicName}); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := grpc.NewClient(server.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient(ctx, testr.New(t), topicName, ordering, "", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client, server
+}
+
+func TestClient_Send(t *testing.T) {
+	ctx := context.Background()
+	client, server := newTestClient(t, ctx, true)
+
+	findingName := source + "/findings/1"
+	transitions := []*securitycenter.Transition{
+		{
+			FindingName: findingName,
+			OldState:    securitycenterpb.Finding_STATE_UNSPECIFIED,
+			NewState:    securitycenterpb.Finding_ACTIVE,
+			Finding:     &securitycenterpb.Finding{Name: findingName, Category: "K8sRequiredLabels", State: securitycenterpb.Finding_ACTIVE},
+		},
+		{
+			FindingName: findingName,
+			OldState:    securitycenterpb.Finding_ACTIVE,
+			NewState:    securitycenterpb.Finding_INACTIVE,
+			Finding:     &securitycenterpb.Finding{Name: findingName, Category: "K8sRequiredLabels", State: securitycenterpb.Finding_INACTIVE},
+		},
+	}
+	if err := client.Send(ctx, transitions); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != len(transitions) {
+		t.Fatalf("expected %d messages, got %d", len(transitions), len(messages))
+	}
+	for i, msg := range messages {
+		if msg.OrderingKey != findingName {
+			t.Errorf("message %d: expected ordering key %s, got %s", i, findingName, msg.OrderingKey)
+		}
+		if msg.Attributes["newState"] != transitions[i].NewState.String() {
+			t.Errorf("message %d: expected newState attribute %s, got %s", i, transitions[i].NewState, msg.Attributes["newState"])
+		}
+		if msg.Attributes["category"] != "K8sRequiredLabels" {
+			t.Errorf("message %d: expected category attribute K8sRequiredLabels, got %s", i, msg.Attributes["category"])
+		}
+		got := &Message{}
+		if err := json.Unmarshal(msg.Data, got); err != nil {
+			t.Fatal(err)
+		}
+		if got.FindingName != findingName || got.OldState != transitions[i].OldState.String() || got.NewState != transitions[i].NewState.String() {
+			t.Errorf("message %d: unexpected data %+v", i, got)
+		}
+		finding := map[string]interface{}{}
+		if err := json.Unmarshal(got.Finding, &finding); err != nil {
+			t.Fatal(err)
+		}
+		if finding["name"] != findingName {
+			t.Errorf("message %d: expected finding name %s, got %v", i, findingName, finding["name"])
+		}
+	}
+}
+
+func TestClient_SendWithoutOrdering(t *testing.T) {
+	ctx := context.Background()
+	client, server := newTestClient(t, ctx, false)
+	findingName := source + "/findings/1"
+	if err := client.Send(ctx, []*securitycenter.Transition{{
+		FindingName: findingName,
+		NewState:    securitycenterpb.Finding_ACTIVE,
+		Finding:     &securitycenterpb.Finding{Name: findingName},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	messages := server.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	if messages[0].OrderingKey != "" {
+		t.Errorf("expected no ordering key, got %s", messages[0].OrderingKey)
+	}
+}
+
+func TestNewClient_invalidTopicName(t *testing.T) {
+	if _, err := NewClient(context.Background(), testr.New(t), "findings", false, ""); err == nil {
+		t.Error("expected error for invalid topic name")
+	}
+}
+
+func TestClient_SendRetriesFailedMessages(t *testing.T) {
+	ctx := context.Background()
+	client, server := newTestClient(t, ctx, true)
+	if err := client.SetMaxPending(2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.GServer.DeleteTopic(ctx, &pubsubpb.DeleteTopicRequest{Topic: topicName}); err != nil {
+		t.Fatal(err)
+	}
+	var transitions []*securitycenter.Transition
+	for _, id := range []string{"1", "2", "3"} {
+		findingName := source + "/findings/" + id
+		transitions = append(transitions, &securitycenter.Transition{
+			FindingName: findingName,
+			NewState:    securitycenterpb.Finding_ACTIVE,
+			Finding:     &securitycenterpb.Finding{Name: findingName},
+		})
+	}
+	if err := client.Send(ctx, transitions); err == nil {
+		t.Fatal("expected error when the topic doesn't exist")
+	}
+	if len(client.pending) != 2 {
+		t.Fatalf("expected 2 pending transitions, got %d", len(client.pending))
+	}
+
+	if _, err := server.GServer.CreateTopic(ctx, &pubsubpb.Topic{Name: topicName}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Send(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	messages := server.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+	got := map[string]bool{}
+	for _, msg := range messages {
+		got[msg.Attributes["findingName"]] = true
+	}
+	if got[source+"/findings/1"] || !got[source+"/findings/2"] || !got[source+"/findings/3"] {
+		t.Errorf("expected messages for the 2 newest findings, got %v", got)
+	}
+	if len(client.pending) != 0 {
+		t.Errorf("expected no pending transitions, got %d", len(client.pending))
+	}
+}
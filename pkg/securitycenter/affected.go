@@ -0,0 +1,83 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"context"
+	"sort"
+
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Source properties that list the Kubernetes objects of a finding that
+// aggregates multiple violations. SyncFindings keeps these source
+// properties, and the Kubernetes field, of existing findings in sync with
+// the finding requests, because the affected objects can change without a
+// change to the finding ID.
+const (
+	AffectedObjectsProperty     = "AffectedObjects"
+	AffectedObjectCountProperty = "AffectedObjectCount"
+	AffectedNamespacesProperty  = "AffectedNamespaces"
+)
+
+// affectedProperties are the source properties reconciled by ensureAffectedObjects
+var affectedProperties = []string{AffectedNamespacesProperty, AffectedObjectCountProperty, AffectedObjectsProperty}
+
+// ensureAffectedObjects ensures the affected objects source properties and
+// the Kubernetes field of the finding match the desired finding from the
+// finding request.
+func (c *Client) ensureAffectedObjects(ctx context.Context, finding *securitycenterpb.Finding, desired *securitycenterpb.Finding) (*securitycenterpb.Finding, error) {
+	var paths []string
+	if !proto.Equal(finding.GetKubernetes(), desired.GetKubernetes()) {
+		paths = append(paths, "kubernetes")
+	}
+	for _, key := range affectedProperties {
+		desiredValue, desiredExists := desired.GetSourceProperties()[key]
+		currentValue, currentExists := finding.GetSourceProperties()[key]
+		if desiredExists != currentExists || !proto.Equal(desiredValue, currentValue) {
+			paths = append(paths, "source_properties."+key)
+		}
+	}
+	if len(paths) == 0 {
+		return finding, nil
+	}
+	sort.Strings(paths)
+	if c.dryRun {
+		c.log.Info("(dry-run) skip update affected objects", "findingIDToName", finding.Name, "paths", paths)
+		return finding, nil
+	}
+	c.log.Info("update affected objects", "findingIDToName", finding.Name, "paths", paths)
+	updatedFinding := proto.Clone(finding).(*securitycenterpb.Finding)
+	updatedFinding.Kubernetes = desired.GetKubernetes()
+	if updatedFinding.SourceProperties == nil {
+		updatedFinding.SourceProperties = map[string]*structpb.Value{}
+	}
+	for _, key := range affectedProperties {
+		delete(updatedFinding.SourceProperties, key)
+		if value, exists := desired.GetSourceProperties()[key]; exists {
+			updatedFinding.SourceProperties[key] = value
+		}
+	}
+	req := &securitycenterpb.UpdateFindingRequest{
+		Finding:    updatedFinding,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: paths},
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.client.UpdateFinding(ctx, req, retryOption)
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"context"
+	"testing"
+
+	sccpb "cloud.google.com/go/securitycenter/apiv1/securitycenterpb"
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func Test_ensureAffectedObjects(t *testing.T) {
+	objects := func(names ...string) *sccpb.Kubernetes {
+		kubernetes := &sccpb.Kubernetes{}
+		for _, name := range names {
+			kubernetes.Objects = append(kubernetes.Objects, &sccpb.Kubernetes_Object{Kind: "Pod", Ns: "default", Name: name})
+		}
+		return kubernetes
+	}
+	properties := func(count int, affected string) map[string]*structpb.Value {
+		values := map[string]*structpb.Value{
+			"ScannerName": structpb.NewStringValue("GATEKEEPER"),
+		}
+		if count > 0 {
+			values[AffectedObjectCountProperty] = structpb.NewNumberValue(float64(count))
+			values[AffectedObjectsProperty] = structpb.NewStringValue(affected)
+		}
+		return values
+	}
+	tests := []struct {
+		name        string
+		current     *securitycenterpb.Finding
+		desired     *securitycenterpb.Finding
+		wantPaths   []string
+		wantCount   float64
+		wantObjects int
+	}{
+		{
+			name:    "unchanged",
+			current: &securitycenterpb.Finding{Kubernetes: objects("a", "b"), SourceProperties: properties(2, "Pod/default/a,Pod/default/b")},
+			desired: &securitycenterpb.Finding{Kubernetes: objects("a", "b"), SourceProperties: properties(2, "Pod/default/a,Pod/default/b")},
+		},
+		{
+			name:        "affected object added",
+			current:     &securitycenterpb.Finding{Kubernetes: objects("a"), SourceProperties: properties(1, "Pod/default/a")},
+			desired:     &securitycenterpb.Finding{Kubernetes: objects("a", "b"), SourceProperties: properties(2, "Pod/default/a,Pod/default/b")},
+			wantPaths:   []string{"kubernetes", "source_properties.AffectedObjectCount", "source_properties.AffectedObjects"},
+			wantCount:   2,
+			wantObjects: 2,
+		},
+		{
+			name:      "aggregation disabled",
+			current:   &securitycenterpb.Finding{Kubernetes: objects("a", "b"), SourceProperties: properties(2, "Pod/default/a,Pod/default/b")},
+			desired:   &securitycenterpb.Finding{SourceProperties: properties(0, "")},
+			wantPaths: []string{"kubernetes", "source_properties.AffectedObjectCount", "source_properties.AffectedObjects"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, err := NewClient(ctx, testr.New(t), "", false, clientOptionsForMockServer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resetMockSecurityCenter()
+			defer resetMockSecurityCenter()
+			if tt.wantPaths != nil {
+				mockSecurityCenter.resps = []proto.Message{&securitycenterpb.Finding{}}
+			}
+			tt.current.Name = findingIDToName("1")
+
+			if _, err := client.ensureAffectedObjects(ctx, tt.current, tt.desired); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.wantPaths == nil {
+				if len(mockSecurityCenter.reqs) > 0 {
+					t.Errorf("expected no requests, got %+v", mockSecurityCenter.reqs)
+				}
+				return
+			}
+			if len(mockSecurityCenter.reqs) != 1 {
+				t.Fatalf("expected 1 request, got %d", len(mockSecurityCenter.reqs))
+			}
+			req := mockSecurityCenter.reqs[0].(*securitycenterpb.UpdateFindingRequest)
+			if diff := cmp.Diff(tt.wantPaths, req.UpdateMask.Paths); diff != "" {
+				t.Errorf("update mask mismatch (-want +got):\n%s", diff)
+			}
+			if got := req.Finding.SourceProperties[AffectedObjectCountProperty].GetNumberValue(); got != tt.wantCount {
+				t.Errorf("expected affected object count %v, got %v", tt.wantCount, got)
+			}
+			if got := len(req.Finding.GetKubernetes().GetObjects()); got != tt.wantObjects {
+				t.Errorf("expected %d Kubernetes objects, got %d", tt.wantObjects, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"context"
+
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ResourceAssetNameProperty is the source property that holds the Cloud Asset
+// Inventory name of the resource of a finding. The ResourceName of a finding
+// is immutable after creation, so SyncFindings keeps this source property of
+// existing findings in sync with the finding requests instead, e.g., when the
+// asset name can only be resolved after the finding was created.
+const ResourceAssetNameProperty = "ResourceAssetName"
+
+// ensureResourceAssetName ensures the ResourceAssetName source property of
+// the finding matches the desired finding from the finding request. Findings
+// are unchanged if the request doesn't have an asset name.
+//
+// Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings/patch
+func (c *Client) ensureResourceAssetName(ctx context.Context, finding *securitycenterpb.Finding, desired *securitycenterpb.Finding) (*securitycenterpb.Finding, error) {
+	assetName := stringProperty(desired, ResourceAssetNameProperty)
+	if assetName == "" || stringProperty(finding, ResourceAssetNameProperty) == assetName {
+		return finding, nil
+	}
+	if c.dryRun {
+		c.log.Info("(dry-run) skip update resource asset name", "findingIDToName", finding.Name, "assetName", assetName)
+		return finding, nil
+	}
+	c.log.Info("update resource asset name", "findingIDToName", finding.Name, "assetName", assetName)
+	updatedFinding := proto.Clone(finding).(*securitycenterpb.Finding)
+	if updatedFinding.SourceProperties == nil {
+		updatedFinding.SourceProperties = map[string]*structpb.Value{}
+	}
+	updatedFinding.SourceProperties[ResourceAssetNameProperty] = structpb.NewStringValue(assetName)
+	req := &securitycenterpb.UpdateFindingRequest{
+		Finding:    updatedFinding,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"source_properties." + ResourceAssetNameProperty}},
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.client.UpdateFinding(ctx, req, retryOption)
+}
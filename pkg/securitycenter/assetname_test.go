@@ -0,0 +1,113 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func Test_ensureResourceAssetName(t *testing.T) {
+	bucket := "//storage.googleapis.com/my-bucket"
+	tests := []struct {
+		name       string
+		current    string
+		desired    string
+		wantUpdate bool
+	}{
+		{
+			name:    "unchanged",
+			current: bucket,
+			desired: bucket,
+		},
+		{
+			name:       "resolved after the finding was created",
+			desired:    bucket,
+			wantUpdate: true,
+		},
+		{
+			name:       "changed",
+			current:    "//storage.googleapis.com/old-bucket",
+			desired:    bucket,
+			wantUpdate: true,
+		},
+		{
+			name:    "not resolved",
+			current: bucket,
+		},
+	}
+	properties := func(assetName string) map[string]*structpb.Value {
+		values := map[string]*structpb.Value{
+			"ScannerName": structpb.NewStringValue("GATEKEEPER"),
+		}
+		if assetName != "" {
+			values[ResourceAssetNameProperty] = structpb.NewStringValue(assetName)
+		}
+		return values
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, err := NewClient(ctx, testr.New(t), "", false, clientOptionsForMockServer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resetMockSecurityCenter()
+			defer resetMockSecurityCenter()
+			if tt.wantUpdate {
+				mockSecurityCenter.resps = []proto.Message{&securitycenterpb.Finding{}}
+			}
+			finding := &securitycenterpb.Finding{
+				Name:             findingIDToName("1"),
+				ResourceName:     "https://10.0.0.1/apis/storage.cnrm.cloud.google.com/v1beta1/namespaces/config-connector/storagebuckets/my-bucket",
+				SourceProperties: properties(tt.current),
+			}
+			desired := &securitycenterpb.Finding{
+				ResourceName:     tt.desired,
+				SourceProperties: properties(tt.desired),
+			}
+
+			if _, err := client.ensureResourceAssetName(ctx, finding, desired); err != nil {
+				t.Fatal(err)
+			}
+
+			if !tt.wantUpdate {
+				if len(mockSecurityCenter.reqs) > 0 {
+					t.Errorf("expected no requests, got %+v", mockSecurityCenter.reqs)
+				}
+				return
+			}
+			if len(mockSecurityCenter.reqs) != 1 {
+				t.Fatalf("expected 1 request, got %d", len(mockSecurityCenter.reqs))
+			}
+			req := mockSecurityCenter.reqs[0].(*securitycenterpb.UpdateFindingRequest)
+			if diff := cmp.Diff([]string{"source_properties." + ResourceAssetNameProperty}, req.UpdateMask.Paths); diff != "" {
+				t.Errorf("update mask mismatch (-want +got):\n%s", diff)
+			}
+			if got := stringProperty(req.Finding, ResourceAssetNameProperty); got != tt.desired {
+				t.Errorf("expected asset name %s, got %s", tt.desired, got)
+			}
+			if req.Finding.ResourceName != finding.ResourceName {
+				t.Errorf("expected unchanged resource name %s, got %s", finding.ResourceName, req.Finding.ResourceName)
+			}
+		})
+	}
+}
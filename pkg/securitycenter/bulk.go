@@ -0,0 +1,131 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/googleapis/gax-go/v2"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	defaultConcurrency = 10
+)
+
+// retryOption retries API calls that fail with transient errors, using
+// exponential backoff. The call timeout set on the context still applies.
+var retryOption = gax.WithRetry(func() gax.Retryer {
+	return gax.OnCodes([]codes.Code{
+		codes.DeadlineExceeded,
+		codes.ResourceExhausted,
+		codes.Unavailable,
+	}, gax.Backoff{
+		Initial:    100 * time.Millisecond,
+		Max:        10 * time.Second,
+		Multiplier: 2,
+	})
+})
+
+// BulkResult summarizes an operation applied to a set of findings
+type BulkResult struct {
+	// Updated contains the names of findings that were changed
+	Updated []string
+	// Unchanged contains the names of findings already in the desired state
+	Unchanged []string
+	// Failed maps finding names to the error from the operation
+	Failed map[string]error
+}
+
+// SetFindingsState sets the state of the provided findings, using concurrent
+// API calls. Findings already in the desired state are skipped.
+func (c *Client) SetFindingsState(ctx context.Context, findings []*securitycenterpb.Finding, newState securitycenterpb.Finding_State) *BulkResult {
+	return c.forEachFinding(ctx, findings, func(ctx context.Context, finding *securitycenterpb.Finding) (bool, error) {
+		if finding.State == newState {
+			return false, nil
+		}
+		_, err := c.setFindingState(ctx, finding, newState)
+		return err == nil, err
+	})
+}
+
+// SetFindingsMute sets the mute state of the provided findings, using
+// concurrent API calls. Findings already in the desired mute state are skipped.
+func (c *Client) SetFindingsMute(ctx context.Context, findings []*securitycenterpb.Finding, mute securitycenterpb.Finding_Mute) *BulkResult {
+	return c.forEachFinding(ctx, findings, func(ctx context.Context, finding *securitycenterpb.Finding) (bool, error) {
+		if finding.Mute == mute {
+			return false, nil
+		}
+		_, err := c.setFindingMute(ctx, finding, mute)
+		return err == nil, err
+	})
+}
+
+// forEachFinding applies fn to each finding, running up to c.concurrency
+// calls at a time. fn returns true if it changed the finding.
+func (c *Client) forEachFinding(ctx context.Context, findings []*securitycenterpb.Finding, fn func(ctx context.Context, finding *securitycenterpb.Finding) (bool, error)) *BulkResult {
+	result := &BulkResult{Failed: map[string]error{}}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, c.concurrency)
+	for _, finding := range findings {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(finding *securitycenterpb.Finding) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			changed, err := fn(ctx, finding)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				c.log.Error(err, "could not update finding", "findingName", finding.Name)
+				result.Failed[finding.Name] = err
+			case changed:
+				result.Updated = append(result.Updated, finding.Name)
+			default:
+				result.Unchanged = append(result.Unchanged, finding.Name)
+			}
+		}(finding)
+	}
+	wg.Wait()
+	sort.Strings(result.Updated)
+	sort.Strings(result.Unchanged)
+	return result
+}
+
+// setFindingMute sets the mute state of the finding.
+//
+// Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings/setMute
+func (c *Client) setFindingMute(ctx context.Context, finding *securitycenterpb.Finding, mute securitycenterpb.Finding_Mute) (*securitycenterpb.Finding, error) {
+	if c.dryRun {
+		c.log.Info("(dry-run) skip set finding mute", "findingIDToName", finding.Name, "mute", mute.String())
+		return finding, nil
+	}
+	c.log.Info("set finding mute", "findingIDToName", finding.Name, "mute", mute.String())
+	req := &securitycenterpb.SetMuteRequest{
+		Name: finding.Name,
+		Mute: mute,
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.client.SetMute(ctx, req, retryOption)
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// resetMockSecurityCenter clears requests, responses, and errors, for tests
+// that expect an unused mock server
+func resetMockSecurityCenter() {
+	mockSecurityCenter.reqs = nil
+	mockSecurityCenter.resps = nil
+	mockSecurityCenter.err = nil
+}
+
+func Test_SetFindingsState(t *testing.T) {
+	ctx := context.Background()
+	log := testr.New(t)
+	client, err := NewClient(ctx, log, "", false, clientOptionsForMockServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SetConcurrency(1); err != nil { // mock server responses are ordered
+		t.Fatal(err)
+	}
+	resetMockSecurityCenter()
+	defer resetMockSecurityCenter()
+	mockSecurityCenter.resps = []proto.Message{
+		&securitycenterpb.Finding{Name: findingIDToName("1"), State: securitycenterpb.Finding_INACTIVE},
+	}
+	findings := []*securitycenterpb.Finding{
+		{Name: findingIDToName("1"), State: securitycenterpb.Finding_ACTIVE},
+		{Name: findingIDToName("2"), State: securitycenterpb.Finding_INACTIVE},
+	}
+
+	result := client.SetFindingsState(ctx, findings, securitycenterpb.Finding_INACTIVE)
+
+	if len(result.Updated) != 1 || result.Updated[0] != findingIDToName("1") {
+		t.Errorf("expected updated finding 1, got %+v", result.Updated)
+	}
+	if len(result.Unchanged) != 1 || result.Unchanged[0] != findingIDToName("2") {
+		t.Errorf("expected unchanged finding 2, got %+v", result.Unchanged)
+	}
+	if len(result.Failed) != 0 {
+		t.Errorf("expected no failures, got %+v", result.Failed)
+	}
+	if len(mockSecurityCenter.reqs) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(mockSecurityCenter.reqs))
+	}
+	req, ok := mockSecurityCenter.reqs[0].(*securitycenterpb.SetFindingStateRequest)
+	if !ok {
+		t.Fatalf("expected type securitycenterpb.SetFindingStateRequest, got %T", mockSecurityCenter.reqs[0])
+	}
+	if req.Name != findingIDToName("1") || req.State != securitycenterpb.Finding_INACTIVE {
+		t.Errorf("expected %s INACTIVE, got %s %s", findingIDToName("1"), req.Name, req.State)
+	}
+}
+
+func Test_SetFindingsMute(t *testing.T) {
+	ctx := context.Background()
+	log := testr.New(t)
+	client, err := NewClient(ctx, log, "", false, clientOptionsForMockServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resetMockSecurityCenter()
+	defer resetMockSecurityCenter()
+	mockSecurityCenter.err = status.Error(codes.PermissionDenied, "denied")
+	findings := []*securitycenterpb.Finding{
+		{Name: findingIDToName("1"), Mute: securitycenterpb.Finding_UNMUTED},
+	}
+
+	result := client.SetFindingsMute(ctx, findings, securitycenterpb.Finding_MUTED)
+
+	if len(result.Updated) != 0 {
+		t.Errorf("expected no updated findings, got %+v", result.Updated)
+	}
+	if status.Code(result.Failed[findingIDToName("1")]) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied for finding 1, got %+v", result.Failed)
+	}
+	if len(mockSecurityCenter.reqs) != 1 {
+		t.Fatalf("expected 1 request (no retry for PermissionDenied), got %d", len(mockSecurityCenter.reqs))
+	}
+	if _, ok := mockSecurityCenter.reqs[0].(*securitycenterpb.SetMuteRequest); !ok {
+		t.Errorf("expected type securitycenterpb.SetMuteRequest, got %T", mockSecurityCenter.reqs[0])
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FindingsFilter holds criteria for selecting Gatekeeper findings. Empty
+// fields match all findings.
+//
+// Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings/list#query-parameters
+type FindingsFilter struct {
+	// Source is the full name of the source (parent) of the findings
+	Source string
+	// State of the finding, ACTIVE or INACTIVE
+	State string
+	// Category of the finding, this is the constraint kind
+	Category string
+	// Cluster source property
+	Cluster string
+	// Namespace of the resource that violated the constraint
+	Namespace string
+	// ResourceKind of the resource that violated the constraint
+	ResourceKind string
+	// ConstraintName of the violated constraint
+	ConstraintName string
+	// EventTimeBefore matches findings with an event time before this time
+	EventTimeBefore time.Time
+}
+
+// String returns the filter expression for the ListFindings API method
+func (f *FindingsFilter) String() string {
+	if f == nil {
+		return ""
+	}
+	var terms []string
+	if f.Source != "" {
+		terms = append(terms, fmt.Sprintf("parent=%s", quote(f.Source)))
+	}
+	if f.State != "" {
+		terms = append(terms, fmt.Sprintf("state=%s", quote(strings.ToUpper(f.State))))
+	}
+	if f.Category != "" {
+		terms = append(terms, fmt.Sprintf("category=%s", quote(f.Category)))
+	}
+	if f.Cluster != "" {
+		terms = append(terms, fmt.Sprintf("source_properties.Cluster=%s", quote(f.Cluster)))
+	}
+	if f.Namespace != "" {
+		terms = append(terms, fmt.Sprintf("source_properties.ResourceNamespace=%s", quote(f.Namespace)))
+	}
+	if f.ResourceKind != "" {
+		terms = append(terms, fmt.Sprintf("source_properties.ResourceKind=%s", quote(f.ResourceKind)))
+	}
+	if f.ConstraintName != "" {
+		terms = append(terms, fmt.Sprintf("source_properties.ConstraintName=%s", quote(f.ConstraintName)))
+	}
+	if !f.EventTimeBefore.IsZero() {
+		terms = append(terms, fmt.Sprintf("event_time<%d", f.EventTimeBefore.UnixNano()/int64(time.Millisecond)))
+	}
+	return strings.Join(terms, " AND ")
+}
+
+// quote returns a double-quoted string literal for use in filter expressions
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,77 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindingsFilter_String(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter *FindingsFilter
+		want   string
+	}{
+		{
+			name:   "nil filter",
+			filter: nil,
+			want:   "",
+		},
+		{
+			name:   "empty filter",
+			filter: &FindingsFilter{},
+			want:   "",
+		},
+		{
+			name:   "state is upper case",
+			filter: &FindingsFilter{State: "active"},
+			want:   `state="ACTIVE"`,
+		},
+		{
+			name: "all fields",
+			filter: &FindingsFilter{
+				Source:         "organizations/123/sources/456",
+				State:          "INACTIVE",
+				Category:       "K8sRequiredLabels",
+				Cluster:        "my-cluster",
+				Namespace:      "default",
+				ResourceKind:   "Deployment",
+				ConstraintName: "ns-must-have-owner",
+			},
+			want: `parent="organizations/123/sources/456" AND state="INACTIVE" AND category="K8sRequiredLabels" AND source_properties.Cluster="my-cluster" AND source_properties.ResourceNamespace="default" AND source_properties.ResourceKind="Deployment" AND source_properties.ConstraintName="ns-must-have-owner"`,
+		},
+		{
+			name: "event time in milliseconds",
+			filter: &FindingsFilter{
+				Cluster:         "my-cluster",
+				EventTimeBefore: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+			},
+			want: `source_properties.Cluster="my-cluster" AND event_time<1614556800000`,
+		},
+		{
+			name:   "quotes are escaped",
+			filter: &FindingsFilter{Cluster: `my "cluster" \ `},
+			want:   `source_properties.Cluster="my \"cluster\" \\ "`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.String(); got != tt.want {
+				t.Errorf("String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
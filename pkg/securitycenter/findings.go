@@ -17,45 +17,118 @@ package securitycenter
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strings"
 
 	"github.com/pkg/errors"
 	"google.golang.org/api/iterator"
 	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	errorutils "k8s.io/apimachinery/pkg/util/errors"
 )
 
+// consoleFindingsURL is the Security Command Center findings page in the
+// Google Cloud console
+const consoleFindingsURL = "https://console.cloud.google.com/security/command-center/findings"
+
 var (
 	errIterator = errors.New("iterator error")
 )
 
+// Transition records a change made to a finding by SyncFindings.
+//
+// OldState is STATE_UNSPECIFIED for findings that were created by the sync.
+type Transition struct {
+	FindingName string
+	OldState    securitycenterpb.Finding_State
+	NewState    securitycenterpb.Finding_State
+	Finding     *securitycenterpb.Finding
+}
+
+// DeactivationCheckFunc is called by SyncFindings after listing the existing findings, and before
+// changing any of them, with the number of ACTIVE findings in the source, and the number of those
+// findings that the sync would set to INACTIVE. If it returns an error, the sync doesn't set any
+// findings to INACTIVE and returns the error, but it still creates and updates other findings.
+type DeactivationCheckFunc func(active, deactivations int) error
+
+// PreserveFunc returns true if an existing finding that isn't in the finding requests should
+// keep its current state, e.g., because the violation couldn't be resolved.
+type PreserveFunc func(finding *securitycenterpb.Finding) bool
+
 // SyncFindings synchronizes the findings already in Security Command Center (SCC) with the
-// provided finding requests
-func (c *Client) SyncFindings(ctx context.Context, source string, findingRequests map[string]*securitycenterpb.CreateFindingRequest) error {
+// provided finding requests.
+//
+// Returns the transitions of findings that were created, or that had their state changed,
+// even if there were errors syncing other findings. The returned error includes the error
+// of the deactivation check, see SetDeactivationCheck.
+func (c *Client) SyncFindings(ctx context.Context, source string, findingRequests map[string]*securitycenterpb.CreateFindingRequest) ([]*Transition, error) {
+	return c.SyncFindingsPreserving(ctx, source, findingRequests, nil)
+}
+
+// SyncFindingsPreserving is like SyncFindings, but existing findings that aren't in the finding
+// requests keep their current state if preserve returns true. preserve can be nil.
+func (c *Client) SyncFindingsPreserving(ctx context.Context, source string, findingRequests map[string]*securitycenterpb.CreateFindingRequest, preserve PreserveFunc) ([]*Transition, error) {
 	c.log.Info("syncing findings", "source", source, "numActiveFindings", len(findingRequests))
-	newFindingRequests, err := c.syncFindingsState(ctx, source, findingRequests)
+	newFindingRequests, transitions, err := c.syncFindingsState(ctx, source, findingRequests, preserve)
 	if err != nil && newFindingRequests == nil {
-		return err
+		return transitions, err
 	}
 	if err != nil {
 		c.log.Error(err, "findings state sync errors")
 	}
-	var createFindingErrs []error
+	// the state errors include held deactivations, so they're returned even
+	// if the new findings are created
+	createFindingErrs := []error{err}
 	for _, req := range newFindingRequests {
-		if err := c.CreateFinding(ctx, req); err != nil {
+		// security marks are output only when creating findings, see ensureSecurityMarks
+		createReq := proto.Clone(req).(*securitycenterpb.CreateFindingRequest)
+		createReq.Finding.SecurityMarks = nil
+		if err := c.CreateFinding(ctx, createReq); err != nil {
+			createFindingErrs = append(createFindingErrs, err)
+			continue
+		}
+		finding := proto.Clone(createReq.Finding).(*securitycenterpb.Finding)
+		finding.Name = fmt.Sprintf("%s/findings/%s", req.Parent, req.FindingId)
+		finding.Parent = req.Parent
+		if markedFinding, err := c.ensureSecurityMarks(ctx, finding, req.Finding); err != nil {
 			createFindingErrs = append(createFindingErrs, err)
+		} else {
+			finding = markedFinding
 		}
+		if stringProperty(finding, MuteReasonProperty) != "" {
+			if _, err := c.setFindingMute(ctx, finding, securitycenterpb.Finding_MUTED); err != nil {
+				createFindingErrs = append(createFindingErrs, err)
+			} else {
+				finding.Mute = securitycenterpb.Finding_MUTED
+			}
+		}
+		transitions = append(transitions, &Transition{
+			FindingName: finding.Name,
+			OldState:    securitycenterpb.Finding_STATE_UNSPECIFIED,
+			NewState:    finding.State,
+			Finding:     finding,
+		})
 	}
-	return errorutils.NewAggregate(createFindingErrs) // returns nil if errs is empty
+	return transitions, errorutils.NewAggregate(createFindingErrs) // returns nil if all errs are nil
 }
 
 // syncFindingsState updates the state of existing findings for the provided source in Security
 // Command Center based on their presence in the findingRequests input parameter.
 //
 // Existing findings that are present in the findingRequests input have their state set to ACTIVE.
-// Existing findings that are _not_ present in the findingRequests input have their state set to INACTIVE.
+// Existing findings that are _not_ present in the findingRequests input have their state set to INACTIVE,
+// unless preserve is not nil and returns true for the finding, or the deactivation check returns an
+// error, see SetDeactivationCheck.
+// Existing findings that are present in the findingRequests input have their mute state reconciled
+// with the MuteReason source property of the request, see ensureFindingMute, their security
+// marks reconciled with the security marks of the request, see ensureSecurityMarks, their
+// ExternalUri and link source properties reconciled with the request, see ensureFindingLinks,
+// their ResourceAssetName source property reconciled with the request, see
+// ensureResourceAssetName, their affected objects reconciled with the request, see
+// ensureAffectedObjects, and their occurrences advanced to the request, see ensureOccurrences.
 //
 // The `source` input parameter should be of the format `organizations/[organization_id]/sources/[source_id]`
 // To sync across all sources provide a "-" as the source_id.
@@ -64,50 +137,95 @@ func (c *Client) SyncFindings(ctx context.Context, source string, findingRequest
 // `organizations/[organization_id]/sources/[source_id]/findings/[finding_id]`
 //
 // Returns the subset of findingRequests from the input that were _not_ already present in SCC.
-// These request objects can then be used to create new findings.
+// These request objects can then be used to create new findings. If a lineage key is set, the
+// returned requests are copies with the lineage source properties, see withLineage.
+// Also returns the transitions of existing findings that had their state changed.
 //
 // Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings/setState
-func (c *Client) syncFindingsState(ctx context.Context, source string, findingRequests map[string]*securitycenterpb.CreateFindingRequest) ([]*securitycenterpb.CreateFindingRequest, error) {
+func (c *Client) syncFindingsState(ctx context.Context, source string, findingRequests map[string]*securitycenterpb.CreateFindingRequest, preserve PreserveFunc) ([]*securitycenterpb.CreateFindingRequest, []*Transition, error) {
 	if c.log.V(2).Enabled() {
 		for findingName := range findingRequests {
 			c.log.V(2).Info("findingRequest", "findingIDToName", findingName)
 		}
 	}
-	syncedFindingNames := map[string]bool{}
-	var pageToken string
-	ensureStateFn := func(ctx context.Context, finding *securitycenterpb.Finding) (*securitycenterpb.Finding, error) {
-		c.log.V(2).Info("ensure state", "finding", finding.Name)
+	// List all findings before changing any of them, so the deactivation check sees the full sync
+	findings, err := c.ListFindings(ctx, source, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	deactivates := func(finding *securitycenterpb.Finding) bool {
 		_, exists := findingRequests[finding.Name]
-		return c.ensureFindingState(ctx, finding, exists)
+		return !exists && (preserve == nil || !preserve(finding))
 	}
-	var ensureStateFnErrors []error
-	for {
-		syncedFindingsFromPage, pageToken, err := c.mapFindingsPage(ctx, source, pageToken, ensureStateFn)
-		if err != nil && errors.Is(err, errIterator) {
-			return nil, err // iterator error, stop
-		}
-		switch mapErr := err.(type) {
-		case errorutils.Aggregate:
-			// err is collection of errors from applying ensureStateFn. Collect them.
-			ensureStateFnErrors = append(ensureStateFnErrors, mapErr.Errors()...)
-		default:
-			if err != nil {
-				return nil, err // some other error, stop
+	var ensureStateErrors []error
+	holdDeactivations := false
+	if c.deactivationCheck != nil {
+		active, deactivations := 0, 0
+		for _, finding := range findings {
+			if finding.State != securitycenterpb.Finding_ACTIVE {
+				continue
+			}
+			active++
+			if deactivates(finding) {
+				deactivations++
 			}
 		}
-		for _, syncedFinding := range syncedFindingsFromPage {
-			c.log.V(2).Info("synced finding state", "name", syncedFinding.Name)
-			syncedFindingNames[syncedFinding.Name] = true // add findings even if there was an error applying the ensureStateFn
+		if err := c.deactivationCheck(active, deactivations); err != nil {
+			c.log.Error(err, "holding deactivation of findings", "activeFindings", active, "deactivations", deactivations)
+			holdDeactivations = true
+			ensureStateErrors = append(ensureStateErrors, err)
 		}
-		if pageToken == "" {
-			break
+	}
+	syncedFindingNames := map[string]bool{}
+	var transitions []*Transition
+	for _, finding := range findings {
+		c.log.V(2).Info("ensure state", "finding", finding.Name)
+		syncedFindingNames[finding.Name] = true // add findings even if there was an error ensuring the state
+		req, exists := findingRequests[finding.Name]
+		if !exists && !deactivates(finding) {
+			c.log.V(1).Info("preserving state of finding for unresolved violation", "finding", finding.Name, "state", finding.State.String())
+			continue
+		}
+		if !exists && holdDeactivations {
+			continue
+		}
+		oldState := finding.State
+		syncedFinding, err := c.ensureFindingState(ctx, finding, exists)
+		if err == nil && syncedFinding != nil && syncedFinding.State != oldState {
+			transitions = append(transitions, &Transition{
+				FindingName: finding.Name,
+				OldState:    oldState,
+				NewState:    syncedFinding.State,
+				Finding:     syncedFinding,
+			})
+		}
+		if err == nil && exists {
+			syncedFinding, err = c.ensureFindingMute(ctx, syncedFinding, req.Finding)
+		}
+		if err == nil && exists {
+			syncedFinding, err = c.ensureSecurityMarks(ctx, syncedFinding, req.Finding)
+		}
+		if err == nil && exists {
+			syncedFinding, err = c.ensureFindingLinks(ctx, syncedFinding, req.Finding)
+		}
+		if err == nil && exists {
+			syncedFinding, err = c.ensureResourceAssetName(ctx, syncedFinding, req.Finding)
+		}
+		if err == nil && exists {
+			syncedFinding, err = c.ensureAffectedObjects(ctx, syncedFinding, req.Finding)
+		}
+		if err == nil && exists {
+			_, err = c.ensureOccurrences(ctx, syncedFinding, req.Finding)
+		}
+		if err != nil {
+			ensureStateErrors = append(ensureStateErrors, err)
 		}
 	}
-	unsyncedFindingRequests := c.filterUnsyncedFindingRequests(findingRequests, syncedFindingNames)
-	if len(ensureStateFnErrors) > 0 {
-		return unsyncedFindingRequests, errors.Wrap(errorutils.NewAggregate(ensureStateFnErrors), "findings state sync errors")
+	unsyncedFindingRequests := c.withLineage(findings, c.filterUnsyncedFindingRequests(findingRequests, syncedFindingNames))
+	if len(ensureStateErrors) > 0 {
+		return unsyncedFindingRequests, transitions, errors.Wrap(errorutils.NewAggregate(ensureStateErrors), "findings state sync errors")
 	}
-	return unsyncedFindingRequests, nil
+	return unsyncedFindingRequests, transitions, nil
 }
 
 // filterUnsyncedFindingRequests returns the subset of findingRequests that do not have corresponding
@@ -124,7 +242,56 @@ func (c *Client) filterUnsyncedFindingRequests(findingRequests map[string]*secur
 	return unsyncedFindingRequests
 }
 
-// mapFindingsPage applies the mapFn to each finding returned by the call to the ListFindings API method.
+// ListFindings returns the findings for the provided source that match the filter.
+// Use an empty filter to return all findings.
+//
+// The `source` input parameter should be of the format `organizations/[organization_id]/sources/[source_id]`
+// To list findings across all sources provide a "-" as the source_id.
+//
+// Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings/list
+func (c *Client) ListFindings(ctx context.Context, source string, filter string) ([]*securitycenterpb.Finding, error) {
+	identityFn := func(_ context.Context, finding *securitycenterpb.Finding) (*securitycenterpb.Finding, error) {
+		return finding, nil
+	}
+	var findings []*securitycenterpb.Finding
+	var pageToken string
+	for {
+		findingsFromPage, pageToken, err := c.mapFindingsPage(ctx, source, filter, pageToken, identityFn)
+		if err != nil {
+			return nil, fmt.Errorf("could not list findings: %w", err)
+		}
+		findings = append(findings, findingsFromPage...)
+		if pageToken == "" {
+			break
+		}
+	}
+	return findings, nil
+}
+
+// GetFinding returns the finding with the provided full name, in the format
+// `organizations/[organization_id]/sources/[source_id]/findings/[finding_id]`.
+// The v1 API doesn't have a get method for findings, so this method lists
+// findings in the source using a filter on the finding name.
+func (c *Client) GetFinding(ctx context.Context, findingName string) (*securitycenterpb.Finding, error) {
+	i := strings.LastIndex(findingName, "/findings/")
+	if i < 0 {
+		return nil, fmt.Errorf("invalid finding name: [%v]", findingName)
+	}
+	source := findingName[:i]
+	findings, err := c.ListFindings(ctx, source, fmt.Sprintf("name=%s", quote(findingName)))
+	if err != nil {
+		return nil, err
+	}
+	for _, finding := range findings {
+		if finding.Name == findingName {
+			return finding, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "could not find finding %s", findingName)
+}
+
+// mapFindingsPage applies the mapFn to each finding returned by the call to the ListFindings API method,
+// using the optional filter expression.
 // Returns all findings from where the mapFn returned non-nil, and the pageToken to allow the
 // caller to repeat this for the next page.
 //
@@ -138,15 +305,16 @@ func (c *Client) filterUnsyncedFindingRequests(findingRequests map[string]*secur
 //     the end.
 //
 // Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings/list
-func (c *Client) mapFindingsPage(ctx context.Context, source string, pageToken string, mapFn func(ctx context.Context, finding *securitycenterpb.Finding) (*securitycenterpb.Finding, error)) ([]*securitycenterpb.Finding, string, error) {
+func (c *Client) mapFindingsPage(ctx context.Context, source string, filter string, pageToken string, mapFn func(ctx context.Context, finding *securitycenterpb.Finding) (*securitycenterpb.Finding, error)) ([]*securitycenterpb.Finding, string, error) {
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 	req := &securitycenterpb.ListFindingsRequest{
 		Parent:    source,
+		Filter:    filter,
 		PageSize:  c.pageSize,
 		PageToken: pageToken,
 	}
-	c.log.V(2).Info("listing findings", "source", req.Parent, "pageSize", req.PageSize, "pageToken", req.PageToken)
+	c.log.V(2).Info("listing findings", "source", req.Parent, "filter", req.Filter, "pageSize", req.PageSize, "pageToken", req.PageToken)
 	it := c.client.ListFindings(ctx, req)
 	pageToken = it.PageInfo().Token
 	var mappedFindings []*securitycenterpb.Finding
@@ -170,17 +338,11 @@ func (c *Client) mapFindingsPage(ctx context.Context, source string, pageToken s
 	return mappedFindings, pageToken, errorutils.NewAggregate(mapFnErrs)
 }
 
-// CreateFinding using the provided CreateFindingRequest
+// CreateFinding using the provided CreateFindingRequest. Findings that
+// already exist are logged and left as is.
 // Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings/create
 func (c *Client) CreateFinding(ctx context.Context, req *securitycenterpb.CreateFindingRequest) error {
-	if c.dryRun {
-		c.log.Info("(dry-run) skip create finding", "findingIDToName", fmt.Sprintf("%v/findings/%v", req.Parent, req.FindingId), "constraintTemplate", req.Finding.Category, "resourceName", req.Finding.ResourceName, "constraintUri", req.Finding.ExternalUri)
-		return nil
-	}
-	c.log.Info("create finding", "findingName", fmt.Sprintf("%v/findings/%v", req.Parent, req.FindingId), "constraintTemplate", req.Finding.Category, "resourceName", req.Finding.ResourceName, "constraintUri", req.Finding.ExternalUri)
-	ctx, cancel := context.WithTimeout(ctx, c.timeout)
-	defer cancel()
-	_, err := c.client.CreateFinding(ctx, req)
+	err := c.createFinding(ctx, req)
 	if err != nil {
 		st, ok := status.FromError(err)
 		if !ok || st.Code() != codes.AlreadyExists {
@@ -192,6 +354,20 @@ func (c *Client) CreateFinding(ctx context.Context, req *securitycenterpb.Create
 	return nil
 }
 
+// createFinding using the provided CreateFindingRequest. Unlike
+// CreateFinding, this returns the AlreadyExists error.
+func (c *Client) createFinding(ctx context.Context, req *securitycenterpb.CreateFindingRequest) error {
+	if c.dryRun {
+		c.log.Info("(dry-run) skip create finding", "findingIDToName", fmt.Sprintf("%v/findings/%v", req.Parent, req.FindingId), "constraintTemplate", req.Finding.Category, "resourceName", req.Finding.ResourceName, "constraintUri", req.Finding.ExternalUri)
+		return nil
+	}
+	c.log.Info("create finding", "findingName", fmt.Sprintf("%v/findings/%v", req.Parent, req.FindingId), "constraintTemplate", req.Finding.Category, "resourceName", req.Finding.ResourceName, "constraintUri", req.Finding.ExternalUri)
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	_, err := c.client.CreateFinding(ctx, req)
+	return err
+}
+
 // ensureFindingState ensures the finding state matches the provided desired state.
 // If the finding is already in the correct state, this is a noop.
 //
@@ -227,5 +403,34 @@ func (c *Client) setFindingState(ctx context.Context, finding *securitycenterpb.
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 	c.log.Info("updating finding state", "findingIDToName", finding.Name, "state", newState.String())
-	return c.client.SetFindingState(ctx, req)
+	return c.client.SetFindingState(ctx, req, retryOption)
+}
+
+// FindingConsoleURL returns the link to the finding in the Security Command
+// Center page of the Google Cloud console. Returns an empty string if the
+// finding name doesn't contain an organization.
+func FindingConsoleURL(findingName string) string {
+	organization, err := organizationForSource(findingName)
+	if err != nil {
+		return ""
+	}
+	query := url.Values{}
+	query.Set("organizationId", strings.TrimPrefix(organization, "organizations/"))
+	query.Set("resourceId", findingName)
+	return consoleFindingsURL + "?" + query.Encode()
+}
+
+// FindingsConsoleURL returns the link to the Security Command Center
+// findings page in the Google Cloud console, filtered using the provided
+// filter. Returns an empty string if the filter source doesn't contain an
+// organization.
+func FindingsConsoleURL(filter *FindingsFilter) string {
+	organization, err := organizationForSource(filter.Source)
+	if err != nil {
+		return ""
+	}
+	query := url.Values{}
+	query.Set("organizationId", strings.TrimPrefix(organization, "organizations/"))
+	query.Set("query", filter.String())
+	return consoleFindingsURL + "?" + query.Encode()
 }
@@ -16,10 +16,14 @@ package securitycenter
 
 import (
 	"context"
+	"errors"
 	"github.com/go-logr/logr/testr"
 	"testing"
 
 	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 const source = "organizations/123/sources/456"
@@ -44,6 +48,7 @@ func Test_SyncFindings(t *testing.T) {
 		},
 	}
 
+	// all findings are listed before any state changes
 	response0ListFindings := &securitycenterpb.ListFindingsResponse{
 		ListFindingsResults: []*securitycenterpb.ListFindingsResponse_ListFindingsResult{
 			{Finding: &securitycenterpb.Finding{
@@ -61,14 +66,7 @@ func Test_SyncFindings(t *testing.T) {
 	}
 	mockSecurityCenter.resps = append(mockSecurityCenter.resps, response0ListFindings)
 
-	response1SetFindingState := &securitycenterpb.Finding{
-		Name:   findingIDToName("2"),
-		Parent: source,
-		State:  securitycenterpb.Finding_INACTIVE,
-	}
-	mockSecurityCenter.resps = append(mockSecurityCenter.resps, response1SetFindingState)
-
-	response2ListFindings := &securitycenterpb.ListFindingsResponse{
+	response1ListFindings := &securitycenterpb.ListFindingsResponse{
 		ListFindingsResults: []*securitycenterpb.ListFindingsResponse_ListFindingsResult{
 			{Finding: &securitycenterpb.Finding{
 				Name:   findingIDToName("3"),
@@ -78,7 +76,14 @@ func Test_SyncFindings(t *testing.T) {
 		},
 		NextPageToken: "",
 	}
-	mockSecurityCenter.resps = append(mockSecurityCenter.resps, response2ListFindings)
+	mockSecurityCenter.resps = append(mockSecurityCenter.resps, response1ListFindings)
+
+	response2SetFindingState := &securitycenterpb.Finding{
+		Name:   findingIDToName("2"),
+		Parent: source,
+		State:  securitycenterpb.Finding_INACTIVE,
+	}
+	mockSecurityCenter.resps = append(mockSecurityCenter.resps, response2SetFindingState)
 
 	response3SetFindingState := &securitycenterpb.Finding{
 		Name:   findingIDToName("3"),
@@ -94,7 +99,8 @@ func Test_SyncFindings(t *testing.T) {
 	}
 	mockSecurityCenter.resps = append(mockSecurityCenter.resps, response4CreateFinding)
 
-	if err = client.SyncFindings(ctx, "source", findingRequests); err != nil {
+	transitions, err := client.SyncFindings(ctx, "source", findingRequests)
+	if err != nil {
 		t.Fatal(err)
 	}
 
@@ -102,15 +108,15 @@ func Test_SyncFindings(t *testing.T) {
 		t.Errorf("unused responses: %+v", mockSecurityCenter.resps)
 	}
 
-	request1SetFindingState, ok := mockSecurityCenter.reqs[1].(*securitycenterpb.SetFindingStateRequest)
+	request2SetFindingState, ok := mockSecurityCenter.reqs[2].(*securitycenterpb.SetFindingStateRequest)
 	if !ok {
-		t.Errorf("expected type securitycenterpb.SetFindingStateRequest, got %T", mockSecurityCenter.reqs[1])
+		t.Errorf("expected type securitycenterpb.SetFindingStateRequest, got %T", mockSecurityCenter.reqs[2])
 	}
-	if request1SetFindingState.Name != findingIDToName("2") {
-		t.Errorf("expected %s, got %s", findingIDToName("2"), request1SetFindingState.Name)
+	if request2SetFindingState.Name != findingIDToName("2") {
+		t.Errorf("expected %s, got %s", findingIDToName("2"), request2SetFindingState.Name)
 	}
-	if request1SetFindingState.State != securitycenterpb.Finding_INACTIVE {
-		t.Errorf("expected state %s, got %s", securitycenterpb.Finding_INACTIVE, request1SetFindingState.State)
+	if request2SetFindingState.State != securitycenterpb.Finding_INACTIVE {
+		t.Errorf("expected state %s, got %s", securitycenterpb.Finding_INACTIVE, request2SetFindingState.State)
 	}
 
 	request3SetFindingState, ok := mockSecurityCenter.reqs[3].(*securitycenterpb.SetFindingStateRequest)
@@ -137,4 +143,245 @@ func Test_SyncFindings(t *testing.T) {
 	if request4CreateFinding.Finding.State != securitycenterpb.Finding_ACTIVE {
 		t.Errorf("expected state %s, got %s", securitycenterpb.Finding_ACTIVE, request4CreateFinding.Finding.State)
 	}
+
+	wantTransitions := []struct {
+		findingName string
+		oldState    securitycenterpb.Finding_State
+		newState    securitycenterpb.Finding_State
+	}{
+		{findingIDToName("2"), securitycenterpb.Finding_ACTIVE, securitycenterpb.Finding_INACTIVE},
+		{findingIDToName("3"), securitycenterpb.Finding_INACTIVE, securitycenterpb.Finding_ACTIVE},
+		{findingIDToName("4"), securitycenterpb.Finding_STATE_UNSPECIFIED, securitycenterpb.Finding_ACTIVE},
+	}
+	if len(transitions) != len(wantTransitions) {
+		t.Fatalf("expected %d transitions, got %d: %+v", len(wantTransitions), len(transitions), transitions)
+	}
+	for i, want := range wantTransitions {
+		got := transitions[i]
+		if got.FindingName != want.findingName || got.OldState != want.oldState || got.NewState != want.newState {
+			t.Errorf("transition %d: expected %s %s->%s, got %s %s->%s", i, want.findingName, want.oldState, want.newState, got.FindingName, got.OldState, got.NewState)
+		}
+		if got.Finding == nil || got.Finding.Name != want.findingName {
+			t.Errorf("transition %d: expected finding %s, got %+v", i, want.findingName, got.Finding)
+		}
+	}
+}
+
+func Test_SyncFindingsPreserving(t *testing.T) {
+	ctx := context.Background()
+	log := testr.New(t)
+	client, err := NewClient(ctx, log, "", false, clientOptionsForMockServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resetMockSecurityCenter()
+	defer resetMockSecurityCenter()
+	findingRequests := map[string]*securitycenterpb.CreateFindingRequest{
+		findingIDToName("1"): {FindingId: "1", Parent: source, Finding: &securitycenterpb.Finding{}}, // should not change
+		// finding 2 is preserved, finding 3 should become inactive
+	}
+	mockSecurityCenter.resps = []proto.Message{
+		&securitycenterpb.ListFindingsResponse{
+			ListFindingsResults: []*securitycenterpb.ListFindingsResponse_ListFindingsResult{
+				{Finding: &securitycenterpb.Finding{Name: findingIDToName("1"), Parent: source, State: securitycenterpb.Finding_ACTIVE}},
+				{Finding: &securitycenterpb.Finding{Name: findingIDToName("2"), Parent: source, State: securitycenterpb.Finding_ACTIVE}},
+				{Finding: &securitycenterpb.Finding{Name: findingIDToName("3"), Parent: source, State: securitycenterpb.Finding_ACTIVE}},
+			},
+		},
+		&securitycenterpb.Finding{Name: findingIDToName("3"), Parent: source, State: securitycenterpb.Finding_INACTIVE},
+	}
+	preserve := func(finding *securitycenterpb.Finding) bool {
+		return finding.Name == findingIDToName("2")
+	}
+
+	transitions, err := client.SyncFindingsPreserving(ctx, source, findingRequests, preserve)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mockSecurityCenter.resps) > 0 {
+		t.Errorf("unused responses: %+v", mockSecurityCenter.resps)
+	}
+	if len(mockSecurityCenter.reqs) != 2 {
+		t.Fatalf("expected 2 requests, got %d: %+v", len(mockSecurityCenter.reqs), mockSecurityCenter.reqs)
+	}
+	setFindingState, ok := mockSecurityCenter.reqs[1].(*securitycenterpb.SetFindingStateRequest)
+	if !ok {
+		t.Fatalf("expected type securitycenterpb.SetFindingStateRequest, got %T", mockSecurityCenter.reqs[1])
+	}
+	if setFindingState.Name != findingIDToName("3") {
+		t.Errorf("expected %s, got %s", findingIDToName("3"), setFindingState.Name)
+	}
+	if len(transitions) != 1 || transitions[0].FindingName != findingIDToName("3") {
+		t.Errorf("expected one transition for finding 3, got %+v", transitions)
+	}
+}
+
+func Test_SyncFindings_deactivationCheck(t *testing.T) {
+	ctx := context.Background()
+	log := testr.New(t)
+	client, err := NewClient(ctx, log, "", false, clientOptionsForMockServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resetMockSecurityCenter()
+	defer resetMockSecurityCenter()
+	findingRequests := map[string]*securitycenterpb.CreateFindingRequest{
+		findingIDToName("1"): {FindingId: "1", Parent: source, Finding: &securitycenterpb.Finding{}}, // should not change
+		// findings 2 and 3 would become inactive, but the check holds the deactivations
+	}
+	mockSecurityCenter.resps = []proto.Message{
+		&securitycenterpb.ListFindingsResponse{
+			ListFindingsResults: []*securitycenterpb.ListFindingsResponse_ListFindingsResult{
+				{Finding: &securitycenterpb.Finding{Name: findingIDToName("1"), Parent: source, State: securitycenterpb.Finding_ACTIVE}},
+				{Finding: &securitycenterpb.Finding{Name: findingIDToName("2"), Parent: source, State: securitycenterpb.Finding_ACTIVE}},
+				{Finding: &securitycenterpb.Finding{Name: findingIDToName("3"), Parent: source, State: securitycenterpb.Finding_ACTIVE}},
+				{Finding: &securitycenterpb.Finding{Name: findingIDToName("4"), Parent: source, State: securitycenterpb.Finding_INACTIVE}},
+			},
+		},
+	}
+	errLimit := errors.New("deactivation limit exceeded")
+	var gotActive, gotDeactivations int
+	client.SetDeactivationCheck(func(active, deactivations int) error {
+		gotActive, gotDeactivations = active, deactivations
+		return errLimit
+	})
+
+	transitions, err := client.SyncFindings(ctx, source, findingRequests)
+	if !errors.Is(err, errLimit) {
+		t.Errorf("expected error %v, got %v", errLimit, err)
+	}
+
+	if gotActive != 3 || gotDeactivations != 2 {
+		t.Errorf("expected check with 3 active findings and 2 deactivations, got %d and %d", gotActive, gotDeactivations)
+	}
+	if len(mockSecurityCenter.reqs) != 1 {
+		t.Errorf("expected only the list request, got %d requests: %+v", len(mockSecurityCenter.reqs), mockSecurityCenter.reqs)
+	}
+	if len(transitions) != 0 {
+		t.Errorf("expected no transitions, got %+v", transitions)
+	}
+}
+
+func Test_SyncFindings_deactivationCheckWithNewFinding(t *testing.T) {
+	ctx := context.Background()
+	log := testr.New(t)
+	client, err := NewClient(ctx, log, "", false, clientOptionsForMockServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resetMockSecurityCenter()
+	defer resetMockSecurityCenter()
+	findingRequests := map[string]*securitycenterpb.CreateFindingRequest{
+		// finding 1 would become inactive, but the check holds the deactivation
+		findingIDToName("2"): {FindingId: "2", Parent: source, Finding: &securitycenterpb.Finding{State: securitycenterpb.Finding_ACTIVE}}, // new finding
+	}
+	mockSecurityCenter.resps = []proto.Message{
+		&securitycenterpb.ListFindingsResponse{
+			ListFindingsResults: []*securitycenterpb.ListFindingsResponse_ListFindingsResult{
+				{Finding: &securitycenterpb.Finding{Name: findingIDToName("1"), Parent: source, State: securitycenterpb.Finding_ACTIVE}},
+			},
+		},
+		&securitycenterpb.Finding{Name: findingIDToName("2"), Parent: source, State: securitycenterpb.Finding_ACTIVE},
+	}
+	errLimit := errors.New("deactivation limit exceeded")
+	client.SetDeactivationCheck(func(_, _ int) error {
+		return errLimit
+	})
+
+	transitions, err := client.SyncFindings(ctx, source, findingRequests)
+	if !errors.Is(err, errLimit) {
+		t.Errorf("expected error %v, got %v", errLimit, err)
+	}
+
+	if len(mockSecurityCenter.reqs) != 2 {
+		t.Fatalf("expected list and create requests, got %d requests: %+v", len(mockSecurityCenter.reqs), mockSecurityCenter.reqs)
+	}
+	if _, ok := mockSecurityCenter.reqs[1].(*securitycenterpb.CreateFindingRequest); !ok {
+		t.Errorf("expected type securitycenterpb.CreateFindingRequest, got %T", mockSecurityCenter.reqs[1])
+	}
+	if len(transitions) != 1 || transitions[0].FindingName != findingIDToName("2") {
+		t.Errorf("expected one transition for finding 2, got %+v", transitions)
+	}
+}
+
+func Test_ListFindings(t *testing.T) {
+	ctx := context.Background()
+	log := testr.New(t)
+	client, err := NewClient(ctx, log, "", false, clientOptionsForMockServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockSecurityCenter.reqs = nil
+	mockSecurityCenter.resps = []proto.Message{
+		&securitycenterpb.ListFindingsResponse{
+			ListFindingsResults: []*securitycenterpb.ListFindingsResponse_ListFindingsResult{
+				{Finding: &securitycenterpb.Finding{Name: findingIDToName("1"), Parent: source}},
+			},
+			NextPageToken: "page1",
+		},
+		&securitycenterpb.ListFindingsResponse{
+			ListFindingsResults: []*securitycenterpb.ListFindingsResponse_ListFindingsResult{
+				{Finding: &securitycenterpb.Finding{Name: findingIDToName("2"), Parent: source}},
+			},
+		},
+	}
+
+	filter := &FindingsFilter{State: "ACTIVE", Cluster: "my-cluster"}
+	findings, err := client.ListFindings(ctx, source, filter.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 2 || findings[0].Name != findingIDToName("1") || findings[1].Name != findingIDToName("2") {
+		t.Errorf("expected findings 1 and 2, got %+v", findings)
+	}
+	for i, req := range mockSecurityCenter.reqs {
+		listFindingsRequest, ok := req.(*securitycenterpb.ListFindingsRequest)
+		if !ok {
+			t.Fatalf("expected type securitycenterpb.ListFindingsRequest, got %T", req)
+		}
+		if listFindingsRequest.Filter != filter.String() {
+			t.Errorf("request %d: expected filter %s, got %s", i, filter.String(), listFindingsRequest.Filter)
+		}
+	}
+}
+
+func Test_GetFinding(t *testing.T) {
+	ctx := context.Background()
+	log := testr.New(t)
+	client, err := NewClient(ctx, log, "", false, clientOptionsForMockServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockSecurityCenter.reqs = nil
+	mockSecurityCenter.resps = []proto.Message{
+		&securitycenterpb.ListFindingsResponse{
+			ListFindingsResults: []*securitycenterpb.ListFindingsResponse_ListFindingsResult{
+				{Finding: &securitycenterpb.Finding{Name: findingIDToName("1"), Parent: source}},
+			},
+		},
+		&securitycenterpb.ListFindingsResponse{},
+	}
+
+	finding, err := client.GetFinding(ctx, findingIDToName("1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if finding.Name != findingIDToName("1") {
+		t.Errorf("expected finding %s, got %s", findingIDToName("1"), finding.Name)
+	}
+	req := mockSecurityCenter.reqs[0].(*securitycenterpb.ListFindingsRequest)
+	if req.Parent != source {
+		t.Errorf("expected parent %s, got %s", source, req.Parent)
+	}
+	if wantFilter := `name="` + findingIDToName("1") + `"`; req.Filter != wantFilter {
+		t.Errorf("expected filter %s, got %s", wantFilter, req.Filter)
+	}
+
+	if _, err := client.GetFinding(ctx, findingIDToName("2")); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound error, got %v", err)
+	}
+	if _, err := client.GetFinding(ctx, "2"); err == nil {
+		t.Error("expected error for invalid finding name")
+	}
 }
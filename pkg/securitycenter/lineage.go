@@ -0,0 +1,114 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"strings"
+	"time"
+
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Source properties that record the lineage of a finding. A violation can be
+// reported by a sequence of findings with different IDs, e.g., when the
+// finding ID depends on the specs of the objects. FirstSeenTime is the time
+// the first finding in the sequence was created, and PreviousFindingId is
+// the ID of the finding that preceded the finding.
+const (
+	FirstSeenTimeProperty     = "FirstSeenTime"
+	PreviousFindingIDProperty = "PreviousFindingId"
+)
+
+// LineageKeyFunc returns the key that identifies the violation of a finding
+// across finding IDs, or an empty string if the finding has no lineage.
+type LineageKeyFunc func(finding *securitycenterpb.Finding) string
+
+// SetLineageKey enables the lineage source properties on findings created by
+// SyncFindings. Use nil to disable.
+func (c *Client) SetLineageKey(key LineageKeyFunc) {
+	c.lineageKey = key
+}
+
+// withLineage returns copies of the finding requests with the lineage source
+// properties. The previous finding is the most recently created existing
+// finding with the same lineage key. Requests without a previous finding
+// keep their FirstSeenTime source property, if set. The existing findings
+// are the findings listed by syncFindingsState, so this doesn't make any API
+// calls.
+func (c *Client) withLineage(findings []*securitycenterpb.Finding, findingRequests []*securitycenterpb.CreateFindingRequest) []*securitycenterpb.CreateFindingRequest {
+	if c.lineageKey == nil {
+		return findingRequests
+	}
+	latest := map[string]*securitycenterpb.Finding{}
+	for _, finding := range findings {
+		key := c.lineageKey(finding)
+		if key == "" {
+			continue
+		}
+		if previous, exists := latest[key]; !exists || CreatedAfter(finding, previous) {
+			latest[key] = finding
+		}
+	}
+	var lineageRequests []*securitycenterpb.CreateFindingRequest
+	for _, req := range findingRequests {
+		req = proto.Clone(req).(*securitycenterpb.CreateFindingRequest)
+		if req.Finding.SourceProperties == nil {
+			req.Finding.SourceProperties = map[string]*structpb.Value{}
+		}
+		firstSeenTime := formatTime(req.Finding.GetEventTime().AsTime())
+		if req.Finding.EventTime == nil {
+			firstSeenTime = formatTime(time.Now())
+		}
+		if requested := stringProperty(req.Finding, FirstSeenTimeProperty); requested != "" {
+			// the request knows when the violation was first seen, e.g., for repeated events
+			firstSeenTime = requested
+		}
+		if key := c.lineageKey(req.Finding); key != "" && latest[key] != nil {
+			previous := latest[key]
+			c.log.V(1).Info("new finding for violation with previous finding", "findingId", req.FindingId, "previousFinding", previous.Name)
+			req.Finding.SourceProperties[PreviousFindingIDProperty] = structpb.NewStringValue(previous.Name[strings.LastIndex(previous.Name, "/")+1:])
+			firstSeenTime = findingFirstSeenTime(previous)
+		}
+		req.Finding.SourceProperties[FirstSeenTimeProperty] = structpb.NewStringValue(firstSeenTime)
+		lineageRequests = append(lineageRequests, req)
+	}
+	return lineageRequests
+}
+
+// CreatedAfter returns true if finding a was created after finding b. The
+// most recently created finding for a violation is the previous finding of
+// the next finding for the violation.
+func CreatedAfter(a, b *securitycenterpb.Finding) bool {
+	return a.GetCreateTime().AsTime().After(b.GetCreateTime().AsTime())
+}
+
+// findingFirstSeenTime returns the FirstSeenTime source property of the
+// finding, or the create time for findings without the property
+func findingFirstSeenTime(finding *securitycenterpb.Finding) string {
+	if firstSeenTime := stringProperty(finding, FirstSeenTimeProperty); firstSeenTime != "" {
+		return firstSeenTime
+	}
+	if finding.CreateTime != nil {
+		return formatTime(finding.CreateTime.AsTime())
+	}
+	return formatTime(finding.GetEventTime().AsTime())
+}
+
+// formatTime as RFC3339 in UTC
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
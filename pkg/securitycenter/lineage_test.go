@@ -0,0 +1,76 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func Test_withLineage(t *testing.T) {
+	day := func(d int) *timestamppb.Timestamp {
+		return timestamppb.New(time.Date(2021, 6, d, 0, 0, 0, 0, time.UTC))
+	}
+	withKey := func(key string, properties map[string]string) map[string]*structpb.Value {
+		values := map[string]*structpb.Value{"Key": structpb.NewStringValue(key)}
+		for name, value := range properties {
+			values[name] = structpb.NewStringValue(value)
+		}
+		return values
+	}
+	findings := []*securitycenterpb.Finding{
+		// a1 has a later event time, but a2 was created later, so a2 is the previous finding
+		{Name: findingIDToName("a1"), CreateTime: day(1), EventTime: day(5), SourceProperties: withKey("a", nil)},
+		{Name: findingIDToName("a2"), CreateTime: day(3), SourceProperties: withKey("a", map[string]string{FirstSeenTimeProperty: "2021-06-01T00:00:00Z"})},
+		{Name: findingIDToName("b1"), CreateTime: day(2), SourceProperties: withKey("b", nil)},
+		{Name: findingIDToName("nokey"), CreateTime: day(2)},
+	}
+	findingRequests := []*securitycenterpb.CreateFindingRequest{
+		{FindingId: "a3", Finding: &securitycenterpb.Finding{EventTime: day(5), SourceProperties: withKey("a", nil)}},
+		{FindingId: "b2", Finding: &securitycenterpb.Finding{EventTime: day(5), SourceProperties: withKey("b", nil)}},
+		{FindingId: "c1", Finding: &securitycenterpb.Finding{EventTime: day(5), SourceProperties: withKey("c", nil)}},
+	}
+	want := map[string]map[string]string{
+		"a3": {FirstSeenTimeProperty: "2021-06-01T00:00:00Z", PreviousFindingIDProperty: "a2"},
+		"b2": {FirstSeenTimeProperty: "2021-06-02T00:00:00Z", PreviousFindingIDProperty: "b1"},
+		"c1": {FirstSeenTimeProperty: "2021-06-05T00:00:00Z"},
+	}
+
+	client := &Client{log: testr.New(t)}
+	client.SetLineageKey(func(finding *securitycenterpb.Finding) string {
+		return stringProperty(finding, "Key")
+	})
+	got := map[string]map[string]string{}
+	for _, req := range client.withLineage(findings, findingRequests) {
+		got[req.FindingId] = map[string]string{}
+		for _, name := range []string{FirstSeenTimeProperty, PreviousFindingIDProperty} {
+			if value := stringProperty(req.Finding, name); value != "" {
+				got[req.FindingId][name] = value
+			}
+		}
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("withLineage() mismatch (-want +got):\n%s", diff)
+	}
+	if _, exists := findingRequests[0].Finding.SourceProperties[FirstSeenTimeProperty]; exists {
+		t.Errorf("withLineage() modified the input finding request")
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// LinkPropertyPrefix is the prefix of source properties that hold links
+// created from link templates. SyncFindings keeps these source properties,
+// and the ExternalUri, of existing findings in sync with the finding
+// requests.
+const LinkPropertyPrefix = "Link"
+
+// ensureFindingLinks ensures the ExternalUri and the link source properties
+// of the finding match the desired finding from the finding request.
+func (c *Client) ensureFindingLinks(ctx context.Context, finding *securitycenterpb.Finding, desired *securitycenterpb.Finding) (*securitycenterpb.Finding, error) {
+	var paths []string
+	if finding.GetExternalUri() != desired.GetExternalUri() {
+		paths = append(paths, "external_uri")
+	}
+	for key, value := range desired.GetSourceProperties() {
+		if isLinkProperty(key) && stringProperty(finding, key) != value.GetStringValue() {
+			paths = append(paths, "source_properties."+key)
+		}
+	}
+	for key := range finding.GetSourceProperties() {
+		if _, exists := desired.GetSourceProperties()[key]; isLinkProperty(key) && !exists {
+			paths = append(paths, "source_properties."+key) // removed link
+		}
+	}
+	if len(paths) == 0 {
+		return finding, nil
+	}
+	sort.Strings(paths)
+	return c.updateLinks(ctx, finding, desired, paths)
+}
+
+// updateLinks sets the ExternalUri and link source properties of the finding
+// from the desired finding, using an update mask so other fields are left
+// unchanged. Source properties in the mask that aren't in the desired
+// finding are removed.
+//
+// Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings/patch
+func (c *Client) updateLinks(ctx context.Context, finding *securitycenterpb.Finding, desired *securitycenterpb.Finding, paths []string) (*securitycenterpb.Finding, error) {
+	if c.dryRun {
+		c.log.Info("(dry-run) skip update finding links", "findingIDToName", finding.Name, "paths", paths)
+		return finding, nil
+	}
+	c.log.Info("update finding links", "findingIDToName", finding.Name, "paths", paths)
+	updatedFinding := proto.Clone(finding).(*securitycenterpb.Finding)
+	updatedFinding.ExternalUri = desired.GetExternalUri()
+	if updatedFinding.SourceProperties == nil {
+		updatedFinding.SourceProperties = map[string]*structpb.Value{}
+	}
+	for key := range updatedFinding.SourceProperties {
+		if isLinkProperty(key) {
+			delete(updatedFinding.SourceProperties, key)
+		}
+	}
+	for key, value := range desired.GetSourceProperties() {
+		if isLinkProperty(key) {
+			updatedFinding.SourceProperties[key] = value
+		}
+	}
+	req := &securitycenterpb.UpdateFindingRequest{
+		Finding:    updatedFinding,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: paths},
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.client.UpdateFinding(ctx, req, retryOption)
+}
+
+// isLinkProperty returns true if the source property holds a link
+func isLinkProperty(key string) bool {
+	return strings.HasPrefix(key, LinkPropertyPrefix)
+}
@@ -0,0 +1,119 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func Test_ensureFindingLinks(t *testing.T) {
+	tests := []struct {
+		name            string
+		currentURI      string
+		currentLinks    map[string]string
+		desiredURI      string
+		desiredLinks    map[string]string
+		wantPaths       []string
+		wantLinks       map[string]string
+		wantExternalURI string
+	}{
+		{
+			name:         "unchanged",
+			currentURI:   "https://example.com/a",
+			currentLinks: map[string]string{"LinkGKE": "https://example.com/gke"},
+			desiredURI:   "https://example.com/a",
+			desiredLinks: map[string]string{"LinkGKE": "https://example.com/gke"},
+		},
+		{
+			name:            "change external URI, add and remove links",
+			currentURI:      "https://10.0.0.1/apis/a",
+			currentLinks:    map[string]string{"LinkOld": "https://example.com/old"},
+			desiredURI:      "https://example.com/a",
+			desiredLinks:    map[string]string{"LinkGKE": "https://example.com/gke"},
+			wantPaths:       []string{"external_uri", "source_properties.LinkGKE", "source_properties.LinkOld"},
+			wantLinks:       map[string]string{"LinkGKE": "https://example.com/gke"},
+			wantExternalURI: "https://example.com/a",
+		},
+	}
+	properties := func(links map[string]string) map[string]*structpb.Value {
+		values := map[string]*structpb.Value{
+			"ScannerName": structpb.NewStringValue("GATEKEEPER"),
+		}
+		for key, value := range links {
+			values[key] = structpb.NewStringValue(value)
+		}
+		return values
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, err := NewClient(ctx, testr.New(t), "", false, clientOptionsForMockServer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resetMockSecurityCenter()
+			defer resetMockSecurityCenter()
+			if tt.wantPaths != nil {
+				mockSecurityCenter.resps = []proto.Message{&securitycenterpb.Finding{}}
+			}
+			finding := &securitycenterpb.Finding{
+				Name:             findingIDToName("1"),
+				ExternalUri:      tt.currentURI,
+				SourceProperties: properties(tt.currentLinks),
+			}
+			desired := &securitycenterpb.Finding{
+				ExternalUri:      tt.desiredURI,
+				SourceProperties: properties(tt.desiredLinks),
+			}
+
+			if _, err := client.ensureFindingLinks(ctx, finding, desired); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.wantPaths == nil {
+				if len(mockSecurityCenter.reqs) > 0 {
+					t.Errorf("expected no requests, got %+v", mockSecurityCenter.reqs)
+				}
+				return
+			}
+			if len(mockSecurityCenter.reqs) != 1 {
+				t.Fatalf("expected 1 request, got %d", len(mockSecurityCenter.reqs))
+			}
+			req := mockSecurityCenter.reqs[0].(*securitycenterpb.UpdateFindingRequest)
+			if diff := cmp.Diff(tt.wantPaths, req.UpdateMask.Paths); diff != "" {
+				t.Errorf("update mask mismatch (-want +got):\n%s", diff)
+			}
+			if req.Finding.ExternalUri != tt.wantExternalURI {
+				t.Errorf("expected external URI %s, got %s", tt.wantExternalURI, req.Finding.ExternalUri)
+			}
+			gotLinks := map[string]string{}
+			for key, value := range req.Finding.SourceProperties {
+				if isLinkProperty(key) {
+					gotLinks[key] = value.GetStringValue()
+				}
+			}
+			if diff := cmp.Diff(tt.wantLinks, gotLinks); diff != "" {
+				t.Errorf("links mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// The SecurityMarks of a finding request contain the desired security marks
+// of the finding. An empty value means that the mark should be removed.
+// Marks with keys that aren't in the request are left unchanged, so marks
+// added by users are kept.
+
+// ensureSecurityMarks ensures the security marks of the finding match the
+// desired finding from the finding request.
+//
+// Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings/updateSecurityMarks
+func (c *Client) ensureSecurityMarks(ctx context.Context, finding *securitycenterpb.Finding, desired *securitycenterpb.Finding) (*securitycenterpb.Finding, error) {
+	current := finding.GetSecurityMarks().GetMarks()
+	var changedKeys []string
+	for key, value := range desired.GetSecurityMarks().GetMarks() {
+		if current[key] != value {
+			changedKeys = append(changedKeys, key)
+		}
+	}
+	if len(changedKeys) == 0 {
+		c.log.V(2).Info("finding already has desired security marks", "findingIDToName", finding.Name)
+		return finding, nil
+	}
+	sort.Strings(changedKeys)
+	securityMarks, err := c.updateSecurityMarks(ctx, finding.Name, desired.GetSecurityMarks().GetMarks(), changedKeys)
+	if err != nil {
+		return finding, err
+	}
+	updatedFinding := proto.Clone(finding).(*securitycenterpb.Finding)
+	updatedFinding.SecurityMarks = securityMarks
+	return updatedFinding, nil
+}
+
+// updateSecurityMarks sets the security marks with the provided keys on the
+// finding. Keys with empty values in the marks map are removed.
+//
+// Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings/updateSecurityMarks
+func (c *Client) updateSecurityMarks(ctx context.Context, findingName string, marks map[string]string, keys []string) (*securitycenterpb.SecurityMarks, error) {
+	securityMarks := &securitycenterpb.SecurityMarks{
+		Name:  fmt.Sprintf("%s/securityMarks", findingName),
+		Marks: map[string]string{},
+	}
+	var paths []string
+	for _, key := range keys {
+		paths = append(paths, "marks."+key)
+		if marks[key] != "" {
+			securityMarks.Marks[key] = marks[key]
+		}
+	}
+	if c.dryRun {
+		c.log.Info("(dry-run) skip update security marks", "findingIDToName", findingName, "keys", keys)
+		return securityMarks, nil
+	}
+	c.log.Info("update security marks", "findingIDToName", findingName, "keys", keys)
+	req := &securitycenterpb.UpdateSecurityMarksRequest{
+		SecurityMarks: securityMarks,
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: paths,
+		},
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.client.UpdateSecurityMarks(ctx, req, retryOption)
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func Test_ensureSecurityMarks(t *testing.T) {
+	tests := []struct {
+		name      string
+		current   map[string]string
+		desired   map[string]string
+		wantMarks map[string]string
+		wantPaths []string
+	}{
+		{
+			name:    "no desired marks",
+			current: map[string]string{"owner": "user"},
+		},
+		{
+			name:    "unchanged",
+			current: map[string]string{"team": "payments", "owner": "user"},
+			desired: map[string]string{"team": "payments", "env": ""},
+		},
+		{
+			name:      "add, change, and remove marks, keep user marks",
+			current:   map[string]string{"team": "payments", "env": "dev", "owner": "user"},
+			desired:   map[string]string{"team": "checkout", "env": "", "cost-center": "123"},
+			wantMarks: map[string]string{"team": "checkout", "cost-center": "123"},
+			wantPaths: []string{"marks.cost-center", "marks.env", "marks.team"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, err := NewClient(ctx, testr.New(t), "", false, clientOptionsForMockServer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resetMockSecurityCenter()
+			defer resetMockSecurityCenter()
+			if tt.wantPaths != nil {
+				mockSecurityCenter.resps = []proto.Message{&securitycenterpb.SecurityMarks{}}
+			}
+			finding := &securitycenterpb.Finding{
+				Name:          findingIDToName("1"),
+				SecurityMarks: &securitycenterpb.SecurityMarks{Marks: tt.current},
+			}
+			desired := &securitycenterpb.Finding{
+				SecurityMarks: &securitycenterpb.SecurityMarks{Marks: tt.desired},
+			}
+
+			if _, err := client.ensureSecurityMarks(ctx, finding, desired); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.wantPaths == nil {
+				if len(mockSecurityCenter.reqs) > 0 {
+					t.Errorf("expected no requests, got %+v", mockSecurityCenter.reqs)
+				}
+				return
+			}
+			if len(mockSecurityCenter.reqs) != 1 {
+				t.Fatalf("expected 1 request, got %d", len(mockSecurityCenter.reqs))
+			}
+			req := mockSecurityCenter.reqs[0].(*securitycenterpb.UpdateSecurityMarksRequest)
+			if wantName := findingIDToName("1") + "/securityMarks"; req.SecurityMarks.Name != wantName {
+				t.Errorf("expected name %s, got %s", wantName, req.SecurityMarks.Name)
+			}
+			if diff := cmp.Diff(tt.wantMarks, req.SecurityMarks.Marks); diff != "" {
+				t.Errorf("marks mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantPaths, req.UpdateMask.Paths); diff != "" {
+				t.Errorf("update mask mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
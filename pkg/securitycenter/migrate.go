@@ -0,0 +1,120 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ReplaceFinding creates a finding with the provided finding ID in the same
+// source as the existing finding, and sets the state of the existing finding
+// to INACTIVE. The new finding is a copy of the existing finding, including
+// the security marks and mute state, with the lineage source properties
+// FirstSeenTime and PreviousFindingId.
+//
+// The lineage source properties are set from the existing finding, not
+// copied, so a replaced finding that was itself a replacement doesn't pass on
+// its own PreviousFindingId. If the new finding already exists, e.g., when a
+// migration is rerun, its source properties, event time, and external URI are
+// updated to match the existing finding, and it's set to ACTIVE.
+func (c *Client) ReplaceFinding(ctx context.Context, finding *securitycenterpb.Finding, findingID string) (*securitycenterpb.Finding, error) {
+	i := strings.LastIndex(finding.Name, "/findings/")
+	if i < 0 {
+		return nil, fmt.Errorf("invalid finding name: [%v]", finding.Name)
+	}
+	source := finding.Name[:i]
+	predecessorID := finding.Name[i+len("/findings/"):]
+
+	replacement := &securitycenterpb.Finding{
+		State:            securitycenterpb.Finding_ACTIVE,
+		ResourceName:     finding.ResourceName,
+		Category:         finding.Category,
+		ExternalUri:      finding.ExternalUri,
+		EventTime:        finding.EventTime,
+		SourceProperties: map[string]*structpb.Value{},
+	}
+	for key, value := range finding.SourceProperties {
+		if key == FirstSeenTimeProperty || key == PreviousFindingIDProperty {
+			continue
+		}
+		replacement.SourceProperties[key] = proto.Clone(value).(*structpb.Value)
+	}
+	replacement.SourceProperties[FirstSeenTimeProperty] = structpb.NewStringValue(findingFirstSeenTime(finding))
+	replacement.SourceProperties[PreviousFindingIDProperty] = structpb.NewStringValue(predecessorID)
+	err := c.createFinding(ctx, &securitycenterpb.CreateFindingRequest{
+		Parent:    source,
+		FindingId: findingID,
+		Finding:   replacement,
+	})
+	replacement.Name = fmt.Sprintf("%s/findings/%s", source, findingID)
+	replacement.Parent = source
+	if status.Code(err) == codes.AlreadyExists {
+		c.log.Info("replacement finding already exists", "findingName", replacement.Name, "replacedFinding", finding.Name)
+		replacement, err = c.updateReplacement(ctx, replacement)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not create replacement for finding %s: %w", finding.Name, err)
+	}
+
+	// security marks are output only when creating findings, see ensureSecurityMarks
+	replacement, err = c.ensureSecurityMarks(ctx, replacement, finding)
+	if err != nil {
+		return replacement, fmt.Errorf("could not copy security marks to finding %s: %w", replacement.Name, err)
+	}
+	if finding.Mute == securitycenterpb.Finding_MUTED && replacement.Mute != securitycenterpb.Finding_MUTED {
+		if _, err := c.setFindingMute(ctx, replacement, securitycenterpb.Finding_MUTED); err != nil {
+			return replacement, fmt.Errorf("could not mute finding %s: %w", replacement.Name, err)
+		}
+		replacement.Mute = securitycenterpb.Finding_MUTED
+	}
+	if _, err := c.ensureFindingState(ctx, finding, false); err != nil {
+		return replacement, fmt.Errorf("could not deactivate replaced finding %s: %w", finding.Name, err)
+	}
+	return replacement, nil
+}
+
+// updateReplacement updates the source properties, event time, and external
+// URI of a replacement finding that already exists, and sets it to ACTIVE.
+// The source properties are replaced, so properties from an earlier
+// migration that the replaced finding doesn't have are removed.
+//
+// Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings/patch
+func (c *Client) updateReplacement(ctx context.Context, replacement *securitycenterpb.Finding) (*securitycenterpb.Finding, error) {
+	if c.dryRun {
+		c.log.Info("(dry-run) skip update replacement finding", "findingName", replacement.Name)
+		return replacement, nil
+	}
+	c.log.Info("update replacement finding", "findingName", replacement.Name)
+	req := &securitycenterpb.UpdateFindingRequest{
+		Finding:    replacement,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"event_time", "external_uri", "source_properties"}},
+	}
+	updateCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	updatedFinding, err := c.client.UpdateFinding(updateCtx, req, retryOption)
+	if err != nil {
+		return nil, err
+	}
+	return c.ensureFindingState(ctx, updatedFinding, true)
+}
@@ -0,0 +1,182 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	statuspb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func Test_ReplaceFinding(t *testing.T) {
+	ctx := context.Background()
+	client, err := NewClient(ctx, testr.New(t), "", false, clientOptionsForMockServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resetMockSecurityCenter()
+	defer resetMockSecurityCenter()
+	mockSecurityCenter.resps = []proto.Message{
+		&securitycenterpb.Finding{Name: findingIDToName("new")},
+		&securitycenterpb.SecurityMarks{Marks: map[string]string{"triage": "accepted-risk"}},
+		&securitycenterpb.Finding{Name: findingIDToName("new"), Mute: securitycenterpb.Finding_MUTED},
+		&securitycenterpb.Finding{Name: findingIDToName("old"), State: securitycenterpb.Finding_INACTIVE},
+	}
+	finding := &securitycenterpb.Finding{
+		Name:          findingIDToName("old"),
+		Parent:        source,
+		State:         securitycenterpb.Finding_ACTIVE,
+		Mute:          securitycenterpb.Finding_MUTED,
+		Category:      "K8sRequiredLabels",
+		ResourceName:  "//container.googleapis.com/projects/p/zones/z/clusters/c/k8s/namespaces/default/pods/p",
+		SecurityMarks: &securitycenterpb.SecurityMarks{Marks: map[string]string{"triage": "accepted-risk"}},
+		SourceProperties: map[string]*structpb.Value{
+			"ConstraintName":          structpb.NewStringValue("ns-must-have-owner"),
+			PreviousFindingIDProperty: structpb.NewStringValue("older"),
+		},
+		CreateTime: timestamppb.New(time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)),
+	}
+
+	replacement, err := client.ReplaceFinding(ctx, finding, "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mockSecurityCenter.resps) > 0 {
+		t.Errorf("unused responses: %+v", mockSecurityCenter.resps)
+	}
+	if replacement.Name != findingIDToName("new") || replacement.Mute != securitycenterpb.Finding_MUTED {
+		t.Errorf("unexpected replacement finding: %+v", replacement)
+	}
+	createReq, ok := mockSecurityCenter.reqs[0].(*securitycenterpb.CreateFindingRequest)
+	if !ok {
+		t.Fatalf("expected type securitycenterpb.CreateFindingRequest, got %T", mockSecurityCenter.reqs[0])
+	}
+	if createReq.Parent != source || createReq.FindingId != "new" {
+		t.Errorf("expected finding %s/findings/new, got %s/findings/%s", source, createReq.Parent, createReq.FindingId)
+	}
+	wantProperties := map[string]string{
+		"ConstraintName":          "ns-must-have-owner",
+		FirstSeenTimeProperty:     "2021-06-01T00:00:00Z",
+		PreviousFindingIDProperty: "old",
+	}
+	gotProperties := map[string]string{}
+	for key, value := range createReq.Finding.SourceProperties {
+		gotProperties[key] = value.GetStringValue()
+	}
+	if diff := cmp.Diff(wantProperties, gotProperties); diff != "" {
+		t.Errorf("source properties mismatch (-want +got):\n%s", diff)
+	}
+	if createReq.Finding.Category != finding.Category || createReq.Finding.ResourceName != finding.ResourceName {
+		t.Errorf("expected copy of finding, got %+v", createReq.Finding)
+	}
+	if _, ok := mockSecurityCenter.reqs[1].(*securitycenterpb.UpdateSecurityMarksRequest); !ok {
+		t.Errorf("expected type securitycenterpb.UpdateSecurityMarksRequest, got %T", mockSecurityCenter.reqs[1])
+	}
+	if _, ok := mockSecurityCenter.reqs[2].(*securitycenterpb.SetMuteRequest); !ok {
+		t.Errorf("expected type securitycenterpb.SetMuteRequest, got %T", mockSecurityCenter.reqs[2])
+	}
+	setStateReq, ok := mockSecurityCenter.reqs[3].(*securitycenterpb.SetFindingStateRequest)
+	if !ok {
+		t.Fatalf("expected type securitycenterpb.SetFindingStateRequest, got %T", mockSecurityCenter.reqs[3])
+	}
+	if setStateReq.Name != findingIDToName("old") || setStateReq.State != securitycenterpb.Finding_INACTIVE {
+		t.Errorf("expected %s to become INACTIVE, got %s %s", findingIDToName("old"), setStateReq.Name, setStateReq.State)
+	}
+}
+
+func Test_ReplaceFinding_alreadyExists(t *testing.T) {
+	ctx := context.Background()
+	client, err := NewClient(ctx, testr.New(t), "", false, clientOptionsForMockServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resetMockSecurityCenter()
+	defer resetMockSecurityCenter()
+	mockSecurityCenter.resps = []proto.Message{
+		&statuspb.Status{Code: int32(codes.AlreadyExists), Message: "already exists"},
+		&securitycenterpb.Finding{Name: findingIDToName("new"), State: securitycenterpb.Finding_INACTIVE},
+		&securitycenterpb.Finding{Name: findingIDToName("new"), State: securitycenterpb.Finding_ACTIVE},
+		&securitycenterpb.Finding{Name: findingIDToName("old"), State: securitycenterpb.Finding_INACTIVE},
+	}
+	finding := &securitycenterpb.Finding{
+		Name:     findingIDToName("old"),
+		Parent:   source,
+		State:    securitycenterpb.Finding_ACTIVE,
+		Category: "K8sAdmissionDenied",
+		SourceProperties: map[string]*structpb.Value{
+			OccurrenceCountProperty: structpb.NewNumberValue(7),
+		},
+		CreateTime: timestamppb.New(time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)),
+	}
+
+	replacement, err := client.ReplaceFinding(ctx, finding, "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mockSecurityCenter.resps) > 0 {
+		t.Errorf("unused responses: %+v", mockSecurityCenter.resps)
+	}
+	if replacement.Name != findingIDToName("new") || replacement.State != securitycenterpb.Finding_ACTIVE {
+		t.Errorf("unexpected replacement finding: %+v", replacement)
+	}
+	updateReq, ok := mockSecurityCenter.reqs[1].(*securitycenterpb.UpdateFindingRequest)
+	if !ok {
+		t.Fatalf("expected type securitycenterpb.UpdateFindingRequest, got %T", mockSecurityCenter.reqs[1])
+	}
+	if updateReq.Finding.Name != findingIDToName("new") {
+		t.Errorf("expected update of %s, got %s", findingIDToName("new"), updateReq.Finding.Name)
+	}
+	if diff := cmp.Diff([]string{"event_time", "external_uri", "source_properties"}, updateReq.UpdateMask.GetPaths()); diff != "" {
+		t.Errorf("update mask mismatch (-want +got):\n%s", diff)
+	}
+	wantProperties := map[string]interface{}{
+		OccurrenceCountProperty:   float64(7),
+		FirstSeenTimeProperty:     "2021-06-01T00:00:00Z",
+		PreviousFindingIDProperty: "old",
+	}
+	gotProperties := map[string]interface{}{}
+	for key, value := range updateReq.Finding.SourceProperties {
+		gotProperties[key] = value.AsInterface()
+	}
+	if diff := cmp.Diff(wantProperties, gotProperties); diff != "" {
+		t.Errorf("source properties mismatch (-want +got):\n%s", diff)
+	}
+	for i, want := range []struct {
+		name  string
+		state securitycenterpb.Finding_State
+	}{
+		{name: findingIDToName("new"), state: securitycenterpb.Finding_ACTIVE},
+		{name: findingIDToName("old"), state: securitycenterpb.Finding_INACTIVE},
+	} {
+		setStateReq, ok := mockSecurityCenter.reqs[i+2].(*securitycenterpb.SetFindingStateRequest)
+		if !ok {
+			t.Fatalf("expected type securitycenterpb.SetFindingStateRequest, got %T", mockSecurityCenter.reqs[i+2])
+		}
+		if setStateReq.Name != want.name || setStateReq.State != want.state {
+			t.Errorf("expected %s to become %s, got %s %s", want.name, want.state, setStateReq.Name, setStateReq.State)
+		}
+	}
+}
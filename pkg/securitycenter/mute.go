@@ -0,0 +1,98 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"context"
+
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Source properties that record why the controller muted a finding.
+// A finding request with a non-empty MuteReason source property should be
+// muted. Findings without this source property are never muted or unmuted by
+// SyncFindings, so mute state set by users or mute rules is left as is.
+const (
+	MuteReasonProperty = "MuteReason"
+	MuteExpiryProperty = "MuteExpiry"
+)
+
+// ensureFindingMute ensures the mute state and mute source properties of the
+// finding match the desired finding from the finding request.
+//
+// Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings/setMute
+func (c *Client) ensureFindingMute(ctx context.Context, finding *securitycenterpb.Finding, desired *securitycenterpb.Finding) (*securitycenterpb.Finding, error) {
+	desiredReason := stringProperty(desired, MuteReasonProperty)
+	desiredExpiry := stringProperty(desired, MuteExpiryProperty)
+	currentReason := stringProperty(finding, MuteReasonProperty)
+	currentExpiry := stringProperty(finding, MuteExpiryProperty)
+	if desiredReason == "" && currentReason == "" {
+		return finding, nil // not muted by the controller
+	}
+	var err error
+	if desiredReason != currentReason || desiredExpiry != currentExpiry {
+		finding, err = c.updateMuteProperties(ctx, finding, desiredReason, desiredExpiry)
+		if err != nil {
+			return finding, err
+		}
+	}
+	desiredMute := securitycenterpb.Finding_MUTED
+	if desiredReason == "" {
+		desiredMute = securitycenterpb.Finding_UNMUTED
+	}
+	if finding.Mute == desiredMute {
+		c.log.V(2).Info("finding already in desired mute state", "findingIDToName", finding.Name, "mute", desiredMute.String())
+		return finding, nil
+	}
+	return c.setFindingMute(ctx, finding, desiredMute)
+}
+
+// updateMuteProperties sets the mute source properties of the finding, using
+// an update mask so other fields are left unchanged.
+//
+// Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings/patch
+func (c *Client) updateMuteProperties(ctx context.Context, finding *securitycenterpb.Finding, reason, expiry string) (*securitycenterpb.Finding, error) {
+	if c.dryRun {
+		c.log.Info("(dry-run) skip update finding mute properties", "findingIDToName", finding.Name, "muteReason", reason, "muteExpiry", expiry)
+		return finding, nil
+	}
+	c.log.Info("update finding mute properties", "findingIDToName", finding.Name, "muteReason", reason, "muteExpiry", expiry)
+	updatedFinding := proto.Clone(finding).(*securitycenterpb.Finding)
+	if updatedFinding.SourceProperties == nil {
+		updatedFinding.SourceProperties = map[string]*structpb.Value{}
+	}
+	updatedFinding.SourceProperties[MuteReasonProperty] = structpb.NewStringValue(reason)
+	updatedFinding.SourceProperties[MuteExpiryProperty] = structpb.NewStringValue(expiry)
+	req := &securitycenterpb.UpdateFindingRequest{
+		Finding: updatedFinding,
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{
+				"source_properties." + MuteReasonProperty,
+				"source_properties." + MuteExpiryProperty,
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.client.UpdateFinding(ctx, req, retryOption)
+}
+
+// stringProperty returns the string value of the finding source property
+func stringProperty(finding *securitycenterpb.Finding, key string) string {
+	return finding.GetSourceProperties()[key].GetStringValue()
+}
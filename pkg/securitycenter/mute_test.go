@@ -0,0 +1,119 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func Test_ensureFindingMute(t *testing.T) {
+	mutedProperties := map[string]*structpb.Value{
+		MuteReasonProperty: structpb.NewStringValue("accepted risk"),
+		MuteExpiryProperty: structpb.NewStringValue("2031-01-01T00:00:00Z"),
+	}
+	tests := []struct {
+		name     string
+		finding  *securitycenterpb.Finding
+		desired  *securitycenterpb.Finding
+		resps    []proto.Message
+		wantMute securitycenterpb.Finding_Mute
+		wantReqs []string
+	}{
+		{
+			name:     "not muted by annotation",
+			finding:  &securitycenterpb.Finding{Name: findingIDToName("1"), Mute: securitycenterpb.Finding_UNDEFINED},
+			desired:  &securitycenterpb.Finding{},
+			wantMute: securitycenterpb.Finding_UNDEFINED,
+		},
+		{
+			name:     "muted by user is left as is",
+			finding:  &securitycenterpb.Finding{Name: findingIDToName("1"), Mute: securitycenterpb.Finding_MUTED},
+			desired:  &securitycenterpb.Finding{},
+			wantMute: securitycenterpb.Finding_MUTED,
+		},
+		{
+			name:    "mute",
+			finding: &securitycenterpb.Finding{Name: findingIDToName("1"), Mute: securitycenterpb.Finding_UNDEFINED},
+			desired: &securitycenterpb.Finding{SourceProperties: mutedProperties},
+			resps: []proto.Message{
+				&securitycenterpb.Finding{Name: findingIDToName("1"), Mute: securitycenterpb.Finding_UNDEFINED, SourceProperties: mutedProperties},
+				&securitycenterpb.Finding{Name: findingIDToName("1"), Mute: securitycenterpb.Finding_MUTED, SourceProperties: mutedProperties},
+			},
+			wantMute: securitycenterpb.Finding_MUTED,
+			wantReqs: []string{"UpdateFindingRequest", "SetMuteRequest"},
+		},
+		{
+			name:     "already muted",
+			finding:  &securitycenterpb.Finding{Name: findingIDToName("1"), Mute: securitycenterpb.Finding_MUTED, SourceProperties: mutedProperties},
+			desired:  &securitycenterpb.Finding{SourceProperties: mutedProperties},
+			wantMute: securitycenterpb.Finding_MUTED,
+		},
+		{
+			name:    "unmute when annotation removed",
+			finding: &securitycenterpb.Finding{Name: findingIDToName("1"), Mute: securitycenterpb.Finding_MUTED, SourceProperties: mutedProperties},
+			desired: &securitycenterpb.Finding{},
+			resps: []proto.Message{
+				&securitycenterpb.Finding{Name: findingIDToName("1"), Mute: securitycenterpb.Finding_MUTED},
+				&securitycenterpb.Finding{Name: findingIDToName("1"), Mute: securitycenterpb.Finding_UNMUTED},
+			},
+			wantMute: securitycenterpb.Finding_UNMUTED,
+			wantReqs: []string{"UpdateFindingRequest", "SetMuteRequest"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, err := NewClient(ctx, testr.New(t), "", false, clientOptionsForMockServer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resetMockSecurityCenter()
+			defer resetMockSecurityCenter()
+			mockSecurityCenter.resps = tt.resps
+
+			got, err := client.ensureFindingMute(ctx, tt.finding, tt.desired)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Mute != tt.wantMute {
+				t.Errorf("ensureFindingMute() mute = %s, want %s", got.Mute, tt.wantMute)
+			}
+			if len(mockSecurityCenter.reqs) != len(tt.wantReqs) {
+				t.Fatalf("expected %d requests, got %d: %+v", len(tt.wantReqs), len(mockSecurityCenter.reqs), mockSecurityCenter.reqs)
+			}
+			for i, req := range mockSecurityCenter.reqs {
+				if gotReq := string(proto.MessageName(req).Name()); gotReq != tt.wantReqs[i] {
+					t.Errorf("request %d: expected %s, got %s", i, tt.wantReqs[i], gotReq)
+				}
+			}
+			if len(tt.wantReqs) > 0 {
+				updateFindingRequest := mockSecurityCenter.reqs[0].(*securitycenterpb.UpdateFindingRequest)
+				wantReason := stringProperty(tt.desired, MuteReasonProperty)
+				if gotReason := stringProperty(updateFindingRequest.Finding, MuteReasonProperty); gotReason != wantReason {
+					t.Errorf("expected MuteReason %q, got %q", wantReason, gotReason)
+				}
+				if len(updateFindingRequest.UpdateMask.GetPaths()) != 2 {
+					t.Errorf("expected update mask with 2 paths, got %+v", updateFindingRequest.UpdateMask)
+				}
+			}
+		})
+	}
+}
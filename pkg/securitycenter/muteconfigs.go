@@ -0,0 +1,289 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/iterator"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	errorutils "k8s.io/apimachinery/pkg/util/errors"
+)
+
+var muteRuleIDRegexp = regexp.MustCompile("^[a-z][a-z0-9-]{0,62}$")
+
+// MuteRule describes a mute config for Gatekeeper findings in a source.
+// Empty fields match all findings.
+//
+// Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.muteConfigs
+type MuteRule struct {
+	// ID of the mute config, e.g., `sandbox-required-labels`
+	ID          string `json:"id"`
+	Description string `json:"description,omitempty"`
+	// Category of the findings, this is the constraint kind
+	Category string `json:"category,omitempty"`
+	// Cluster source property
+	Cluster string `json:"cluster,omitempty"`
+	// Namespace of the resources that violated the constraint
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// Validate returns an error if the mute rule ID is invalid, or if the rule
+// would mute all findings in the source
+func (r *MuteRule) Validate() error {
+	if !muteRuleIDRegexp.MatchString(r.ID) {
+		return fmt.Errorf("invalid mute rule ID: [%v], must match %s", r.ID, muteRuleIDRegexp.String())
+	}
+	if r.Category == "" && r.Cluster == "" && r.Namespace == "" {
+		return fmt.Errorf("mute rule %s must have at least one of category, cluster, or namespace", r.ID)
+	}
+	return nil
+}
+
+// Filter returns the mute config filter expression for findings in the source
+func (r *MuteRule) Filter(source string) string {
+	filter := &FindingsFilter{
+		Source:    source,
+		Category:  r.Category,
+		Cluster:   r.Cluster,
+		Namespace: r.Namespace,
+	}
+	return filter.String()
+}
+
+// MuteConfigSyncResult lists the names of mute configs changed by SyncMuteConfigs
+type MuteConfigSyncResult struct {
+	Created   []string `json:"created"`
+	Updated   []string `json:"updated"`
+	Deleted   []string `json:"deleted"`
+	Unchanged []string `json:"unchanged"`
+}
+
+// MuteConfigName returns the full mute config name for the mute rule ID, in
+// the format `organizations/[organization_id]/muteConfigs/[mute_config_id]`.
+// The organization is taken from the source name.
+func MuteConfigName(source, muteRuleID string) (string, error) {
+	organization, err := organizationForSource(source)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/muteConfigs/%s", organization, muteRuleID), nil
+}
+
+// ListMuteConfigs returns the mute configs for findings in the source. These
+// are the mute configs in the organization that have a filter on the source.
+//
+// Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.muteConfigs/list
+func (c *Client) ListMuteConfigs(ctx context.Context, source string) ([]*securitycenterpb.MuteConfig, error) {
+	organization, err := organizationForSource(source)
+	if err != nil {
+		return nil, err
+	}
+	req := &securitycenterpb.ListMuteConfigsRequest{
+		Parent:   organization,
+		PageSize: c.pageSize,
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	it := c.client.ListMuteConfigs(ctx, req)
+	var muteConfigs []*securitycenterpb.MuteConfig
+	for {
+		muteConfig, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("it.Next error when listing mute configs: %w", err)
+		}
+		if isMuteConfigForSource(muteConfig, source) {
+			muteConfigs = append(muteConfigs, muteConfig)
+		}
+	}
+	return muteConfigs, nil
+}
+
+// GetMuteConfig returns the mute config with the provided full name
+//
+// Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.muteConfigs/get
+func (c *Client) GetMuteConfig(ctx context.Context, name string) (*securitycenterpb.MuteConfig, error) {
+	req := &securitycenterpb.GetMuteConfigRequest{
+		Name: name,
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.client.GetMuteConfig(ctx, req)
+}
+
+// CreateMuteConfig creates a mute config for findings in the source using the mute rule
+//
+// Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.muteConfigs/create
+func (c *Client) CreateMuteConfig(ctx context.Context, source string, rule *MuteRule) (*securitycenterpb.MuteConfig, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	organization, err := organizationForSource(source)
+	if err != nil {
+		return nil, err
+	}
+	muteConfig := &securitycenterpb.MuteConfig{
+		Description: rule.Description,
+		Filter:      rule.Filter(source),
+	}
+	if c.dryRun {
+		c.log.Info("(dry-run) skip create mute config", "muteConfigID", rule.ID, "filter", muteConfig.Filter)
+		muteConfig.Name = fmt.Sprintf("%s/muteConfigs/%s", organization, rule.ID)
+		return muteConfig, nil
+	}
+	c.log.Info("create mute config", "muteConfigID", rule.ID, "filter", muteConfig.Filter)
+	req := &securitycenterpb.CreateMuteConfigRequest{
+		Parent:       organization,
+		MuteConfig:   muteConfig,
+		MuteConfigId: rule.ID,
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.client.CreateMuteConfig(ctx, req)
+}
+
+// UpdateMuteConfig updates the filter and description of the mute config for
+// the mute rule
+//
+// Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.muteConfigs/patch
+func (c *Client) UpdateMuteConfig(ctx context.Context, source string, rule *MuteRule) (*securitycenterpb.MuteConfig, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	name, err := MuteConfigName(source, rule.ID)
+	if err != nil {
+		return nil, err
+	}
+	muteConfig := &securitycenterpb.MuteConfig{
+		Name:        name,
+		Description: rule.Description,
+		Filter:      rule.Filter(source),
+	}
+	if c.dryRun {
+		c.log.Info("(dry-run) skip update mute config", "muteConfigName", name, "filter", muteConfig.Filter)
+		return muteConfig, nil
+	}
+	c.log.Info("update mute config", "muteConfigName", name, "filter", muteConfig.Filter)
+	req := &securitycenterpb.UpdateMuteConfigRequest{
+		MuteConfig: muteConfig,
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"description", "filter"},
+		},
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.client.UpdateMuteConfig(ctx, req)
+}
+
+// DeleteMuteConfig deletes the mute config with the provided full name
+//
+// Ref: https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.muteConfigs/delete
+func (c *Client) DeleteMuteConfig(ctx context.Context, name string) error {
+	if c.dryRun {
+		c.log.Info("(dry-run) skip delete mute config", "muteConfigName", name)
+		return nil
+	}
+	c.log.Info("delete mute config", "muteConfigName", name)
+	req := &securitycenterpb.DeleteMuteConfigRequest{
+		Name: name,
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.client.DeleteMuteConfig(ctx, req)
+}
+
+// SyncMuteConfigs creates and updates the mute configs for findings in the
+// source so they match the provided mute rules. If prune is true, mute configs
+// for the source that don't match any of the rules are deleted.
+//
+// Returns the names of the changed mute configs, even if there were errors.
+func (c *Client) SyncMuteConfigs(ctx context.Context, source string, rules []*MuteRule, prune bool) (*MuteConfigSyncResult, error) {
+	result := &MuteConfigSyncResult{}
+	desired := map[string]*MuteRule{}
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return result, err
+		}
+		name, err := MuteConfigName(source, rule.ID)
+		if err != nil {
+			return result, err
+		}
+		if _, exists := desired[name]; exists {
+			return result, fmt.Errorf("duplicate mute rule ID: [%v]", rule.ID)
+		}
+		desired[name] = rule
+	}
+	existing, err := c.ListMuteConfigs(ctx, source)
+	if err != nil {
+		return result, err
+	}
+	var errs []error
+	for _, muteConfig := range existing {
+		rule, exists := desired[muteConfig.Name]
+		delete(desired, muteConfig.Name)
+		switch {
+		case !exists && prune:
+			if err := c.DeleteMuteConfig(ctx, muteConfig.Name); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			result.Deleted = append(result.Deleted, muteConfig.Name)
+		case !exists:
+			c.log.V(1).Info("skip mute config not in mute rules", "muteConfigName", muteConfig.Name)
+		case muteConfig.Filter == rule.Filter(source) && muteConfig.Description == rule.Description:
+			result.Unchanged = append(result.Unchanged, muteConfig.Name)
+		default:
+			if _, err := c.UpdateMuteConfig(ctx, source, rule); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			result.Updated = append(result.Updated, muteConfig.Name)
+		}
+	}
+	for name, rule := range desired {
+		if _, err := c.CreateMuteConfig(ctx, source, rule); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		result.Created = append(result.Created, name)
+	}
+	sort.Strings(result.Created)
+	return result, errorutils.NewAggregate(errs) // returns nil if errs is empty
+}
+
+// isMuteConfigForSource returns true if the mute config filter starts with
+// the parent term for the source, as created by MuteRule.Filter
+func isMuteConfigForSource(muteConfig *securitycenterpb.MuteConfig, source string) bool {
+	parentTerm := (&FindingsFilter{Source: source}).String()
+	return muteConfig.Filter == parentTerm || strings.HasPrefix(muteConfig.Filter, parentTerm+" AND ")
+}
+
+// organizationForSource returns `organizations/[organization_id]` from the
+// source name `organizations/[organization_id]/sources/[source_id]`
+func organizationForSource(source string) (string, error) {
+	i := strings.Index(source, "/sources/")
+	if i < 0 || !strings.HasPrefix(source, "organizations/") {
+		return "", fmt.Errorf("invalid source name: [%v]", source)
+	}
+	return source[:i], nil
+}
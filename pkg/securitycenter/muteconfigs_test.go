@@ -0,0 +1,113 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestMuteRule_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    *MuteRule
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			rule: &MuteRule{ID: "sandbox-required-labels", Category: "K8sRequiredLabels", Cluster: "sandbox"},
+		},
+		{
+			name:    "invalid ID",
+			rule:    &MuteRule{ID: "Sandbox_Labels", Category: "K8sRequiredLabels"},
+			wantErr: true,
+		},
+		{
+			name:    "mutes all findings in source",
+			rule:    &MuteRule{ID: "all"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rule.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_SyncMuteConfigs(t *testing.T) {
+	ctx := context.Background()
+	client, err := NewClient(ctx, testr.New(t), "", false, clientOptionsForMockServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resetMockSecurityCenter()
+	defer resetMockSecurityCenter()
+
+	unchanged := &MuteRule{ID: "unchanged", Category: "K8sRequiredLabels", Cluster: "sandbox"}
+	changed := &MuteRule{ID: "changed", Description: "new description", Namespace: "dev"}
+	created := &MuteRule{ID: "created", Cluster: "test"}
+	mockSecurityCenter.resps = []proto.Message{
+		&securitycenterpb.ListMuteConfigsResponse{
+			MuteConfigs: []*securitycenterpb.MuteConfig{
+				{Name: "organizations/123/muteConfigs/unchanged", Filter: unchanged.Filter(source)},
+				{Name: "organizations/123/muteConfigs/changed", Filter: changed.Filter(source), Description: "old description"},
+				{Name: "organizations/123/muteConfigs/removed", Filter: `parent="` + source + `" AND category="K8sAllowedRepos"`},
+				{Name: "organizations/123/muteConfigs/other-source", Filter: `parent="organizations/123/sources/789" AND category="K8sAllowedRepos"`},
+				{Name: "organizations/123/muteConfigs/unscoped", Filter: `category="K8sAllowedRepos"`},
+			},
+		},
+		&securitycenterpb.MuteConfig{Name: "organizations/123/muteConfigs/changed"},
+		&emptypb.Empty{},
+		&securitycenterpb.MuteConfig{Name: "organizations/123/muteConfigs/created"},
+	}
+
+	result, err := client.SyncMuteConfigs(ctx, source, []*MuteRule{unchanged, changed, created}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &MuteConfigSyncResult{
+		Created:   []string{"organizations/123/muteConfigs/created"},
+		Updated:   []string{"organizations/123/muteConfigs/changed"},
+		Deleted:   []string{"organizations/123/muteConfigs/removed"},
+		Unchanged: []string{"organizations/123/muteConfigs/unchanged"},
+	}
+	if diff := cmp.Diff(want, result); diff != "" {
+		t.Errorf("SyncMuteConfigs() mismatch (-want +got):\n%s", diff)
+	}
+	if len(mockSecurityCenter.resps) > 0 {
+		t.Errorf("unused responses: %+v", mockSecurityCenter.resps)
+	}
+	listReq := mockSecurityCenter.reqs[0].(*securitycenterpb.ListMuteConfigsRequest)
+	if listReq.Parent != "organizations/123" {
+		t.Errorf("expected parent organizations/123, got %s", listReq.Parent)
+	}
+	createReq := mockSecurityCenter.reqs[3].(*securitycenterpb.CreateMuteConfigRequest)
+	if wantFilter := `parent="` + source + `" AND source_properties.Cluster="test"`; createReq.MuteConfig.Filter != wantFilter {
+		t.Errorf("expected filter %s, got %s", wantFilter, createReq.MuteConfig.Filter)
+	}
+	if createReq.MuteConfigId != "created" {
+		t.Errorf("expected mute config ID created, got %s", createReq.MuteConfigId)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"context"
+	"time"
+
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Source properties of findings that report repeated occurrences of an
+// event, e.g., admission denials. LastSeenTime is the time of the most
+// recent occurrence as an RFC 3339 timestamp, and OccurrenceCount is the
+// number of occurrences. SyncFindings advances the event time and these
+// source properties of existing findings when the finding request has a
+// later LastSeenTime.
+const (
+	LastSeenTimeProperty    = "LastSeenTime"
+	OccurrenceCountProperty = "OccurrenceCount"
+)
+
+// ensureOccurrences ensures the event time, LastSeenTime, and
+// OccurrenceCount of the finding match the desired finding from the finding
+// request, if the desired finding has a later LastSeenTime. Findings
+// without the LastSeenTime source property in the request are unchanged.
+func (c *Client) ensureOccurrences(ctx context.Context, finding *securitycenterpb.Finding, desired *securitycenterpb.Finding) (*securitycenterpb.Finding, error) {
+	desiredLastSeen, err := time.Parse(time.RFC3339, stringProperty(desired, LastSeenTimeProperty))
+	if err != nil {
+		return finding, nil
+	}
+	if currentLastSeen, err := time.Parse(time.RFC3339, stringProperty(finding, LastSeenTimeProperty)); err == nil && !desiredLastSeen.After(currentLastSeen) {
+		return finding, nil
+	}
+	paths := []string{"event_time", "source_properties." + LastSeenTimeProperty, "source_properties." + OccurrenceCountProperty}
+	if c.dryRun {
+		c.log.Info("(dry-run) skip update occurrences", "findingIDToName", finding.Name, "lastSeenTime", desiredLastSeen)
+		return finding, nil
+	}
+	c.log.Info("update occurrences", "findingIDToName", finding.Name, "lastSeenTime", desiredLastSeen)
+	updatedFinding := proto.Clone(finding).(*securitycenterpb.Finding)
+	updatedFinding.EventTime = desired.GetEventTime()
+	if updatedFinding.SourceProperties == nil {
+		updatedFinding.SourceProperties = map[string]*structpb.Value{}
+	}
+	for _, key := range []string{LastSeenTimeProperty, OccurrenceCountProperty} {
+		delete(updatedFinding.SourceProperties, key)
+		if value, exists := desired.GetSourceProperties()[key]; exists {
+			updatedFinding.SourceProperties[key] = value
+		}
+	}
+	req := &securitycenterpb.UpdateFindingRequest{
+		Finding:    updatedFinding,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: paths},
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.client.UpdateFinding(ctx, req, retryOption)
+}
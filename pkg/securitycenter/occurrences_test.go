@@ -0,0 +1,118 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func Test_ensureOccurrences(t *testing.T) {
+	earlier := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+	tests := []struct {
+		name            string
+		currentLastSeen time.Time
+		desiredLastSeen time.Time
+		wantUpdate      bool
+	}{
+		{
+			name:            "later occurrence",
+			currentLastSeen: earlier,
+			desiredLastSeen: later,
+			wantUpdate:      true,
+		},
+		{
+			name:            "same occurrence",
+			currentLastSeen: later,
+			desiredLastSeen: later,
+		},
+		{
+			name:            "earlier occurrence",
+			currentLastSeen: later,
+			desiredLastSeen: earlier,
+		},
+		{
+			name:            "no occurrences in request",
+			currentLastSeen: earlier,
+		},
+		{
+			name:            "no occurrences in finding",
+			desiredLastSeen: later,
+			wantUpdate:      true,
+		},
+	}
+	finding := func(lastSeen time.Time, count float64) *securitycenterpb.Finding {
+		properties := map[string]*structpb.Value{
+			"ScannerName": structpb.NewStringValue("GATEKEEPER"),
+		}
+		if !lastSeen.IsZero() {
+			properties[LastSeenTimeProperty] = structpb.NewStringValue(lastSeen.Format(time.RFC3339))
+			properties[OccurrenceCountProperty] = structpb.NewNumberValue(count)
+		}
+		return &securitycenterpb.Finding{
+			Name:             findingIDToName("1"),
+			EventTime:        timestamppb.New(lastSeen),
+			SourceProperties: properties,
+		}
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, err := NewClient(ctx, testr.New(t), "", false, clientOptionsForMockServer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resetMockSecurityCenter()
+			defer resetMockSecurityCenter()
+			if tt.wantUpdate {
+				mockSecurityCenter.resps = []proto.Message{&securitycenterpb.Finding{}}
+			}
+
+			if _, err := client.ensureOccurrences(ctx, finding(tt.currentLastSeen, 2), finding(tt.desiredLastSeen, 5)); err != nil {
+				t.Fatal(err)
+			}
+
+			if !tt.wantUpdate {
+				if len(mockSecurityCenter.reqs) > 0 {
+					t.Errorf("expected no requests, got %+v", mockSecurityCenter.reqs)
+				}
+				return
+			}
+			if len(mockSecurityCenter.reqs) != 1 {
+				t.Fatalf("expected 1 request, got %d", len(mockSecurityCenter.reqs))
+			}
+			req := mockSecurityCenter.reqs[0].(*securitycenterpb.UpdateFindingRequest)
+			wantPaths := []string{"event_time", "source_properties." + LastSeenTimeProperty, "source_properties." + OccurrenceCountProperty}
+			if diff := cmp.Diff(wantPaths, req.UpdateMask.Paths); diff != "" {
+				t.Errorf("update mask mismatch (-want +got):\n%s", diff)
+			}
+			if !req.Finding.EventTime.AsTime().Equal(tt.desiredLastSeen) {
+				t.Errorf("expected event time %v, got %v", tt.desiredLastSeen, req.Finding.EventTime.AsTime())
+			}
+			if got := req.Finding.SourceProperties[OccurrenceCountProperty].GetNumberValue(); got != 5 {
+				t.Errorf("expected occurrence count 5, got %v", got)
+			}
+		})
+	}
+}
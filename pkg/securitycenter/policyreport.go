@@ -0,0 +1,31 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+)
+
+// PolicyReportScannerName is the ScannerName source property of findings
+// created from policy reports, to tell them apart from findings created from
+// Gatekeeper audit violations in the same source
+const PolicyReportScannerName = "POLICY_REPORT"
+
+// FromPolicyReport returns true if the finding was created from a policy
+// report entry. Such findings name a policy, not a constraint object that
+// exists in the cluster.
+func FromPolicyReport(finding *securitycenterpb.Finding) bool {
+	return stringProperty(finding, "ScannerName") == PolicyReportScannerName
+}
@@ -0,0 +1,58 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitycenter
+
+import (
+	"testing"
+
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestFromPolicyReport(t *testing.T) {
+	tests := []struct {
+		name        string
+		scannerName string
+		want        bool
+	}{
+		{
+			name:        "policy report",
+			scannerName: PolicyReportScannerName,
+			want:        true,
+		},
+		{
+			name:        "gatekeeper",
+			scannerName: "GATEKEEPER",
+			want:        false,
+		},
+		{
+			name: "no scanner name",
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			finding := &securitycenterpb.Finding{
+				SourceProperties: map[string]*structpb.Value{},
+			}
+			if tt.scannerName != "" {
+				finding.SourceProperties["ScannerName"] = structpb.NewStringValue(tt.scannerName)
+			}
+			if got := FromPolicyReport(finding); got != tt.want {
+				t.Errorf("FromPolicyReport() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -33,11 +33,16 @@ const (
 
 // Client for the Security Command Center API v1. Wraps the googleapis client.
 type Client struct {
-	client   *securitycenterv1.Client
-	timeout  time.Duration
-	pageSize int32
-	log      logr.Logger
-	dryRun   bool
+	client      *securitycenterv1.Client
+	timeout     time.Duration
+	pageSize    int32
+	concurrency int
+	log         logr.Logger
+	dryRun      bool
+	// deactivationCheck is called before SyncFindings sets findings to INACTIVE, can be nil
+	deactivationCheck DeactivationCheckFunc
+	// lineageKey enables the lineage source properties on created findings, can be nil
+	lineageKey LineageKeyFunc
 }
 
 // Close cleans up
@@ -75,11 +80,12 @@ func NewClient(ctx context.Context, log logr.Logger, googleServiceAccount string
 		return nil, fmt.Errorf("could not create securitycenter client: %w", err)
 	}
 	return &Client{
-		client:   securitycenterClient,
-		timeout:  defaultTimeout,
-		log:      log,
-		pageSize: defaultPageSize,
-		dryRun:   dryRun,
+		client:      securitycenterClient,
+		timeout:     defaultTimeout,
+		log:         log,
+		pageSize:    defaultPageSize,
+		concurrency: defaultConcurrency,
+		dryRun:      dryRun,
 	}, nil
 }
 
@@ -100,3 +106,19 @@ func (c *Client) SetPageSize(pageSize int) error {
 	c.pageSize = int32(pageSize)
 	return nil
 }
+
+// SetConcurrency sets the maximum number of concurrent calls to the Security
+// Center API for operations on multiple findings
+func (c *Client) SetConcurrency(concurrency int) error {
+	if concurrency < 1 {
+		return fmt.Errorf("invalid concurrency: %v", concurrency)
+	}
+	c.concurrency = concurrency
+	return nil
+}
+
+// SetDeactivationCheck sets the function that SyncFindings calls before
+// setting findings to INACTIVE. Use nil to remove the check.
+func (c *Client) SetDeactivationCheck(check DeactivationCheckFunc) {
+	c.deactivationCheck = check
+}
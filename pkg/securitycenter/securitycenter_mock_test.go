@@ -20,6 +20,7 @@
 // - changed github.com/golang/protobuf/proto import to google.golang.org/protobuf/proto
 // - renamed clientOpt to clientOptionsForMockServer
 // - wrap serv.Serve(lis) in func to avoid errcheck lint error
+// - CreateFinding returns the error of a status response
 
 package securitycenter
 
@@ -35,9 +36,12 @@ import (
 	"google.golang.org/api/option"
 	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
 	iampb "google.golang.org/genproto/googleapis/iam/v1"
+	statuspb "google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 type mockSecurityCenterServer struct {
@@ -55,6 +59,20 @@ type mockSecurityCenterServer struct {
 	resps []proto.Message
 }
 
+func (s *mockSecurityCenterServer) CreateMuteConfig(ctx context.Context, req *securitycenterpb.CreateMuteConfigRequest) (*securitycenterpb.MuteConfig, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if xg := md["x-goog-api-client"]; len(xg) == 0 || !strings.Contains(xg[0], "gl-go/") {
+		return nil, fmt.Errorf("x-goog-api-client = %v, expected gl-go key", xg)
+	}
+	s.reqs = append(s.reqs, req)
+	if s.err != nil {
+		return nil, s.err
+	}
+	var resp proto.Message
+	resp, s.resps = s.resps[0], s.resps[1:]
+	return resp.(*securitycenterpb.MuteConfig), nil
+}
+
 func (s *mockSecurityCenterServer) CreateSource(ctx context.Context, req *securitycenterpb.CreateSourceRequest) (*securitycenterpb.Source, error) {
 	md, _ := metadata.FromIncomingContext(ctx)
 	if xg := md["x-goog-api-client"]; len(xg) == 0 || !strings.Contains(xg[0], "gl-go/") {
@@ -80,9 +98,26 @@ func (s *mockSecurityCenterServer) CreateFinding(ctx context.Context, req *secur
 	}
 	var resp proto.Message
 	resp, s.resps = s.resps[0], s.resps[1:]
+	if st, ok := resp.(*statuspb.Status); ok {
+		return nil, status.ErrorProto(st)
+	}
 	return resp.(*securitycenterpb.Finding), nil
 }
 
+func (s *mockSecurityCenterServer) DeleteMuteConfig(ctx context.Context, req *securitycenterpb.DeleteMuteConfigRequest) (*emptypb.Empty, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if xg := md["x-goog-api-client"]; len(xg) == 0 || !strings.Contains(xg[0], "gl-go/") {
+		return nil, fmt.Errorf("x-goog-api-client = %v, expected gl-go key", xg)
+	}
+	s.reqs = append(s.reqs, req)
+	if s.err != nil {
+		return nil, s.err
+	}
+	var resp proto.Message
+	resp, s.resps = s.resps[0], s.resps[1:]
+	return resp.(*emptypb.Empty), nil
+}
+
 func (s *mockSecurityCenterServer) GetIamPolicy(ctx context.Context, req *iampb.GetIamPolicyRequest) (*iampb.Policy, error) {
 	md, _ := metadata.FromIncomingContext(ctx)
 	if xg := md["x-goog-api-client"]; len(xg) == 0 || !strings.Contains(xg[0], "gl-go/") {
@@ -97,6 +132,20 @@ func (s *mockSecurityCenterServer) GetIamPolicy(ctx context.Context, req *iampb.
 	return resp.(*iampb.Policy), nil
 }
 
+func (s *mockSecurityCenterServer) GetMuteConfig(ctx context.Context, req *securitycenterpb.GetMuteConfigRequest) (*securitycenterpb.MuteConfig, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if xg := md["x-goog-api-client"]; len(xg) == 0 || !strings.Contains(xg[0], "gl-go/") {
+		return nil, fmt.Errorf("x-goog-api-client = %v, expected gl-go key", xg)
+	}
+	s.reqs = append(s.reqs, req)
+	if s.err != nil {
+		return nil, s.err
+	}
+	var resp proto.Message
+	resp, s.resps = s.resps[0], s.resps[1:]
+	return resp.(*securitycenterpb.MuteConfig), nil
+}
+
 func (s *mockSecurityCenterServer) GetSource(ctx context.Context, req *securitycenterpb.GetSourceRequest) (*securitycenterpb.Source, error) {
 	md, _ := metadata.FromIncomingContext(ctx)
 	if xg := md["x-goog-api-client"]; len(xg) == 0 || !strings.Contains(xg[0], "gl-go/") {
@@ -125,6 +174,20 @@ func (s *mockSecurityCenterServer) ListFindings(ctx context.Context, req *securi
 	return resp.(*securitycenterpb.ListFindingsResponse), nil
 }
 
+func (s *mockSecurityCenterServer) ListMuteConfigs(ctx context.Context, req *securitycenterpb.ListMuteConfigsRequest) (*securitycenterpb.ListMuteConfigsResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if xg := md["x-goog-api-client"]; len(xg) == 0 || !strings.Contains(xg[0], "gl-go/") {
+		return nil, fmt.Errorf("x-goog-api-client = %v, expected gl-go key", xg)
+	}
+	s.reqs = append(s.reqs, req)
+	if s.err != nil {
+		return nil, s.err
+	}
+	var resp proto.Message
+	resp, s.resps = s.resps[0], s.resps[1:]
+	return resp.(*securitycenterpb.ListMuteConfigsResponse), nil
+}
+
 func (s *mockSecurityCenterServer) ListSources(ctx context.Context, req *securitycenterpb.ListSourcesRequest) (*securitycenterpb.ListSourcesResponse, error) {
 	md, _ := metadata.FromIncomingContext(ctx)
 	if xg := md["x-goog-api-client"]; len(xg) == 0 || !strings.Contains(xg[0], "gl-go/") {
@@ -153,6 +216,20 @@ func (s *mockSecurityCenterServer) SetFindingState(ctx context.Context, req *sec
 	return resp.(*securitycenterpb.Finding), nil
 }
 
+func (s *mockSecurityCenterServer) SetMute(ctx context.Context, req *securitycenterpb.SetMuteRequest) (*securitycenterpb.Finding, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if xg := md["x-goog-api-client"]; len(xg) == 0 || !strings.Contains(xg[0], "gl-go/") {
+		return nil, fmt.Errorf("x-goog-api-client = %v, expected gl-go key", xg)
+	}
+	s.reqs = append(s.reqs, req)
+	if s.err != nil {
+		return nil, s.err
+	}
+	var resp proto.Message
+	resp, s.resps = s.resps[0], s.resps[1:]
+	return resp.(*securitycenterpb.Finding), nil
+}
+
 func (s *mockSecurityCenterServer) SetIamPolicy(ctx context.Context, req *iampb.SetIamPolicyRequest) (*iampb.Policy, error) {
 	md, _ := metadata.FromIncomingContext(ctx)
 	if xg := md["x-goog-api-client"]; len(xg) == 0 || !strings.Contains(xg[0], "gl-go/") {
@@ -167,6 +244,48 @@ func (s *mockSecurityCenterServer) SetIamPolicy(ctx context.Context, req *iampb.
 	return resp.(*iampb.Policy), nil
 }
 
+func (s *mockSecurityCenterServer) UpdateFinding(ctx context.Context, req *securitycenterpb.UpdateFindingRequest) (*securitycenterpb.Finding, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if xg := md["x-goog-api-client"]; len(xg) == 0 || !strings.Contains(xg[0], "gl-go/") {
+		return nil, fmt.Errorf("x-goog-api-client = %v, expected gl-go key", xg)
+	}
+	s.reqs = append(s.reqs, req)
+	if s.err != nil {
+		return nil, s.err
+	}
+	var resp proto.Message
+	resp, s.resps = s.resps[0], s.resps[1:]
+	return resp.(*securitycenterpb.Finding), nil
+}
+
+func (s *mockSecurityCenterServer) UpdateSecurityMarks(ctx context.Context, req *securitycenterpb.UpdateSecurityMarksRequest) (*securitycenterpb.SecurityMarks, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if xg := md["x-goog-api-client"]; len(xg) == 0 || !strings.Contains(xg[0], "gl-go/") {
+		return nil, fmt.Errorf("x-goog-api-client = %v, expected gl-go key", xg)
+	}
+	s.reqs = append(s.reqs, req)
+	if s.err != nil {
+		return nil, s.err
+	}
+	var resp proto.Message
+	resp, s.resps = s.resps[0], s.resps[1:]
+	return resp.(*securitycenterpb.SecurityMarks), nil
+}
+
+func (s *mockSecurityCenterServer) UpdateMuteConfig(ctx context.Context, req *securitycenterpb.UpdateMuteConfigRequest) (*securitycenterpb.MuteConfig, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if xg := md["x-goog-api-client"]; len(xg) == 0 || !strings.Contains(xg[0], "gl-go/") {
+		return nil, fmt.Errorf("x-goog-api-client = %v, expected gl-go key", xg)
+	}
+	s.reqs = append(s.reqs, req)
+	if s.err != nil {
+		return nil, s.err
+	}
+	var resp proto.Message
+	resp, s.resps = s.resps[0], s.resps[1:]
+	return resp.(*securitycenterpb.MuteConfig), nil
+}
+
 // clientOptionsForMockServer is the option tests should use to connect to the test server.
 // It is initialized by TestMain.
 var clientOptionsForMockServer option.ClientOption
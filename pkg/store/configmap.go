@@ -0,0 +1,121 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	defaultTimeout = 60 * time.Second
+	configMapKey   = "snapshot.json"
+)
+
+// ConfigMapStore persists the snapshot as JSON in a ConfigMap.
+//
+// ConfigMaps are limited to 1 MiB, so use a FileStore on a persistent
+// volume for sources with many thousands of findings.
+type ConfigMapStore struct {
+	client    kubernetes.Interface
+	log       logr.Logger
+	namespace string
+	name      string
+	timeout   time.Duration
+}
+
+// NewConfigMapStore creates a store that uses the ConfigMap with the provided namespace and name
+func NewConfigMapStore(log logr.Logger, config *rest.Config, namespace, name string) (*ConfigMapStore, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &ConfigMapStore{
+		client:    clientset,
+		log:       log,
+		namespace: namespace,
+		name:      name,
+		timeout:   defaultTimeout,
+	}, nil
+}
+
+// Load the snapshot from the ConfigMap. Returns an empty snapshot if the ConfigMap doesn't exist.
+func (s *ConfigMapStore) Load(ctx context.Context) (*Snapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	configMap, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		s.log.V(1).Info("no snapshot ConfigMap", "namespace", s.namespace, "name", s.name)
+		return emptySnapshot(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapshot := emptySnapshot()
+	snapshotJSON, exists := configMap.Data[configMapKey]
+	if !exists {
+		return snapshot, nil
+	}
+	if err := json.Unmarshal([]byte(snapshotJSON), snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Save the snapshot to the ConfigMap, creating it if it doesn't exist
+func (s *ConfigMapStore) Save(ctx context.Context, snapshot *Snapshot) error {
+	snapshotBytes, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	configMaps := s.client.CoreV1().ConfigMaps(s.namespace)
+	configMap, err := configMaps.Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		s.log.V(1).Info("creating snapshot ConfigMap", "namespace", s.namespace, "name", s.name)
+		_, err = configMaps.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      s.name,
+				Namespace: s.namespace,
+				Labels: map[string]string{
+					"gatekeeper-securitycenter/system": "yes",
+				},
+			},
+			Data: map[string]string{
+				configMapKey: string(snapshotBytes),
+			},
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[configMapKey] = string(snapshotBytes)
+	s.log.V(2).Info("saving snapshot", "namespace", s.namespace, "name", s.name, "findings", len(snapshot.Findings))
+	_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+	return err
+}
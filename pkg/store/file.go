@@ -0,0 +1,80 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+)
+
+// FileStore persists the snapshot as a JSON file
+type FileStore struct {
+	log  logr.Logger
+	path string
+}
+
+// NewFileStore creates a store that uses the file at the provided path
+func NewFileStore(log logr.Logger, path string) *FileStore {
+	return &FileStore{
+		log:  log,
+		path: path,
+	}
+}
+
+// Load the snapshot from the file. Returns an empty snapshot if the file doesn't exist.
+func (s *FileStore) Load(_ context.Context) (*Snapshot, error) {
+	snapshotBytes, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		s.log.V(1).Info("no snapshot file", "path", s.path)
+		return emptySnapshot(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapshot := emptySnapshot()
+	if err := json.Unmarshal(snapshotBytes, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Save the snapshot to the file. Writes to a temporary file first, and then
+// renames it, so a crash doesn't leave a partially written file.
+func (s *FileStore) Save(_ context.Context, snapshot *Snapshot) error {
+	snapshotBytes, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(snapshotBytes); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	s.log.V(2).Info("saving snapshot", "path", s.path, "findings", len(snapshot.Findings))
+	return os.Rename(tmp.Name(), s.path)
+}
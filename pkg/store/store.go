@@ -0,0 +1,187 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/client-go/rest"
+)
+
+// Record is the state of a finding after a sync
+type Record struct {
+	State securitycenterpb.Finding_State `json:"state"`
+	// Hash of the finding fields set by the controller, excluding the event time
+	Hash string `json:"hash"`
+}
+
+// Snapshot records the findings after a sync. The map key is the full finding name.
+type Snapshot struct {
+	// SyncTime is the time of the last sync
+	SyncTime time.Time `json:"syncTime"`
+	// FullSyncTime is the time of the last sync that listed all findings in Security Command Center
+	FullSyncTime time.Time          `json:"fullSyncTime"`
+	Findings     map[string]*Record `json:"findings"`
+	// HeldDeactivationSyncs is the number of consecutive syncs that held
+	// deactivations because they exceeded the deactivation limit
+	HeldDeactivationSyncs int `json:"heldDeactivationSyncs,omitempty"`
+}
+
+// Diff between the findings in a snapshot and the findings from a sync.
+// Each slice contains full finding names, sorted.
+type Diff struct {
+	// Added findings are ACTIVE now, but were not ACTIVE in the snapshot
+	Added []string `json:"added"`
+	// Removed findings were ACTIVE in the snapshot, but are not ACTIVE now
+	Removed []string `json:"removed"`
+	// Changed findings are ACTIVE now and in the snapshot, but have a different hash
+	Changed []string `json:"changed"`
+}
+
+// IsEmpty returns true if nothing changed
+func (d *Diff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Store persists the snapshot between syncs
+type Store interface {
+	// Load the last saved snapshot. Returns an empty snapshot if there is none.
+	Load(ctx context.Context) (*Snapshot, error)
+	// Save the snapshot, replacing the previous one
+	Save(ctx context.Context, snapshot *Snapshot) error
+}
+
+// New creates a Store from a URI. Supported formats:
+//
+// - `file:///path/to/file.json` to use a local file
+//
+// - `configmap://[namespace]/[name]` to use a ConfigMap in the cluster
+func New(log logr.Logger, uri string, config *rest.Config) (Store, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid store URI [%v]: %w", uri, err)
+	}
+	switch u.Scheme {
+	case "file":
+		if u.Path == "" {
+			return nil, fmt.Errorf("invalid store URI [%v]: missing path", uri)
+		}
+		return NewFileStore(log, u.Path), nil
+	case "configmap":
+		name := strings.Trim(u.Path, "/")
+		if u.Host == "" || name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid store URI [%v]: must be configmap://[namespace]/[name]", uri)
+		}
+		return NewConfigMapStore(log, config, u.Host, name)
+	default:
+		return nil, fmt.Errorf("invalid store URI [%v]: unsupported scheme %q", uri, u.Scheme)
+	}
+}
+
+// NewSnapshot creates a snapshot from the finding requests of a sync. All
+// requested findings are ACTIVE. Findings that were ACTIVE in the previous
+// snapshot, but aren't requested, are recorded as INACTIVE.
+func NewSnapshot(previous *Snapshot, findingRequests map[string]*securitycenterpb.CreateFindingRequest, syncTime, fullSyncTime time.Time) *Snapshot {
+	snapshot := &Snapshot{
+		SyncTime:     syncTime,
+		FullSyncTime: fullSyncTime,
+		Findings:     map[string]*Record{},
+	}
+	for findingName, req := range findingRequests {
+		snapshot.Findings[findingName] = &Record{
+			State: securitycenterpb.Finding_ACTIVE,
+			Hash:  Hash(req.Finding),
+		}
+	}
+	if previous != nil {
+		for findingName, record := range previous.Findings {
+			if _, exists := snapshot.Findings[findingName]; !exists && record.State == securitycenterpb.Finding_ACTIVE {
+				snapshot.Findings[findingName] = &Record{
+					State: securitycenterpb.Finding_INACTIVE,
+					Hash:  record.Hash,
+				}
+			}
+		}
+	}
+	return snapshot
+}
+
+// KeepActive records findings that the sync kept ACTIVE without a finding
+// request, e.g., findings of unresolved violations. Their hash is kept from
+// the previous snapshot, or is empty for findings that weren't recorded.
+func (s *Snapshot) KeepActive(findingNames ...string) {
+	for _, findingName := range findingNames {
+		if record, exists := s.Findings[findingName]; exists {
+			record.State = securitycenterpb.Finding_ACTIVE
+			continue
+		}
+		s.Findings[findingName] = &Record{State: securitycenterpb.Finding_ACTIVE}
+	}
+}
+
+// Compare the snapshot with the finding requests of a sync
+func (s *Snapshot) Compare(findingRequests map[string]*securitycenterpb.CreateFindingRequest) *Diff {
+	diff := &Diff{}
+	for findingName, req := range findingRequests {
+		record, exists := s.Findings[findingName]
+		switch {
+		case !exists || record.State != securitycenterpb.Finding_ACTIVE:
+			diff.Added = append(diff.Added, findingName)
+		case record.Hash != Hash(req.Finding):
+			diff.Changed = append(diff.Changed, findingName)
+		}
+	}
+	for findingName, record := range s.Findings {
+		if _, exists := findingRequests[findingName]; !exists && record.State == securitycenterpb.Finding_ACTIVE {
+			diff.Removed = append(diff.Removed, findingName)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+// Hash returns a hash of the finding, excluding the event time. The event
+// time is the audit time of the constraint, and changes on each audit.
+func Hash(finding *securitycenterpb.Finding) string {
+	if finding == nil {
+		return ""
+	}
+	finding = proto.Clone(finding).(*securitycenterpb.Finding)
+	finding.EventTime = nil
+	findingBytes, err := proto.MarshalOptions{Deterministic: true}.Marshal(finding)
+	if err != nil {
+		return ""
+	}
+	findingSha := sha256.Sum256(findingBytes)
+	return hex.EncodeToString(findingSha[:])
+}
+
+func emptySnapshot() *Snapshot {
+	return &Snapshot{
+		Findings: map[string]*Record{},
+	}
+}
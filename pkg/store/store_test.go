@@ -0,0 +1,189 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+const source = "organizations/123/sources/456"
+
+func findingIDToName(id string) string {
+	return source + "/findings/" + id
+}
+
+func request(explanation string) *securitycenterpb.CreateFindingRequest {
+	return &securitycenterpb.CreateFindingRequest{
+		Parent: source,
+		Finding: &securitycenterpb.Finding{
+			State:     securitycenterpb.Finding_ACTIVE,
+			EventTime: timestamppb.Now(),
+			SourceProperties: map[string]*structpb.Value{
+				"Explanation": structpb.NewStringValue(explanation),
+			},
+		},
+	}
+}
+
+func TestSnapshot_Compare(t *testing.T) {
+	previous := NewSnapshot(nil, map[string]*securitycenterpb.CreateFindingRequest{
+		findingIDToName("1"): request("unchanged"),
+		findingIDToName("2"): request("removed"),
+		findingIDToName("3"): request("changed"),
+	}, time.Now(), time.Now())
+	previous.Findings[findingIDToName("4")] = &Record{State: securitycenterpb.Finding_INACTIVE}
+
+	got := previous.Compare(map[string]*securitycenterpb.CreateFindingRequest{
+		findingIDToName("1"): request("unchanged"), // different event time
+		findingIDToName("3"): request("changed again"),
+		findingIDToName("4"): request("reactivated"),
+		findingIDToName("5"): request("added"),
+	})
+	want := &Diff{
+		Added:   []string{findingIDToName("4"), findingIDToName("5")},
+		Removed: []string{findingIDToName("2")},
+		Changed: []string{findingIDToName("3")},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Compare() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNewSnapshot(t *testing.T) {
+	previous := NewSnapshot(nil, map[string]*securitycenterpb.CreateFindingRequest{
+		findingIDToName("1"): request("active"),
+		findingIDToName("2"): request("resolved"),
+	}, time.Now(), time.Now())
+	snapshot := NewSnapshot(previous, map[string]*securitycenterpb.CreateFindingRequest{
+		findingIDToName("1"): request("active"),
+	}, time.Now(), time.Now())
+	if got := snapshot.Findings[findingIDToName("1")].State; got != securitycenterpb.Finding_ACTIVE {
+		t.Errorf("expected finding 1 state ACTIVE, got %s", got)
+	}
+	if got := snapshot.Findings[findingIDToName("2")].State; got != securitycenterpb.Finding_INACTIVE {
+		t.Errorf("expected finding 2 state INACTIVE, got %s", got)
+	}
+	if !snapshot.Compare(map[string]*securitycenterpb.CreateFindingRequest{
+		findingIDToName("1"): request("active"),
+	}).IsEmpty() {
+		t.Error("expected empty diff")
+	}
+
+	// INACTIVE findings are only recorded for one sync
+	snapshot = NewSnapshot(snapshot, nil, time.Now(), time.Now())
+	if _, exists := snapshot.Findings[findingIDToName("2")]; exists {
+		t.Error("expected finding 2 to be removed from snapshot")
+	}
+}
+
+func TestSnapshot_KeepActive(t *testing.T) {
+	previous := NewSnapshot(nil, map[string]*securitycenterpb.CreateFindingRequest{
+		findingIDToName("1"): request("active"),
+		findingIDToName("2"): request("preserved"),
+	}, time.Now(), time.Now())
+	snapshot := NewSnapshot(previous, map[string]*securitycenterpb.CreateFindingRequest{
+		findingIDToName("1"): request("active"),
+	}, time.Now(), time.Now())
+	snapshot.KeepActive(findingIDToName("2"), findingIDToName("3"))
+	for _, id := range []string{"2", "3"} {
+		if got := snapshot.Findings[findingIDToName(id)].State; got != securitycenterpb.Finding_ACTIVE {
+			t.Errorf("expected finding %s state ACTIVE, got %s", id, got)
+		}
+	}
+	if got, want := snapshot.Findings[findingIDToName("2")].Hash, previous.Findings[findingIDToName("2")].Hash; got != want {
+		t.Errorf("expected finding 2 hash %s from previous snapshot, got %s", want, got)
+	}
+	// the preserved findings are removed if they aren't requested or kept again
+	diff := snapshot.Compare(map[string]*securitycenterpb.CreateFindingRequest{
+		findingIDToName("1"): request("active"),
+	})
+	if want := []string{findingIDToName("2"), findingIDToName("3")}; !cmp.Equal(want, diff.Removed) {
+		t.Errorf("expected removed %v, got %v", want, diff.Removed)
+	}
+}
+
+func testStore(t *testing.T, s Store) {
+	ctx := context.Background()
+	empty, err := s.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(empty.Findings) != 0 {
+		t.Errorf("expected empty snapshot, got %+v", empty)
+	}
+	syncTime := time.Now().UTC().Truncate(time.Second)
+	want := NewSnapshot(nil, map[string]*securitycenterpb.CreateFindingRequest{
+		findingIDToName("1"): request("one"),
+	}, syncTime, syncTime)
+	// save twice to test both create and update
+	for i := 0; i < 2; i++ {
+		if err := s.Save(ctx, want); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := s.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Load() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	testStore(t, NewFileStore(testr.New(t), filepath.Join(t.TempDir(), "snapshot.json")))
+}
+
+func TestConfigMapStore(t *testing.T) {
+	testStore(t, &ConfigMapStore{
+		client:    fake.NewSimpleClientset(),
+		log:       testr.New(t),
+		namespace: "gatekeeper-securitycenter",
+		name:      "gatekeeper-securitycenter-state",
+		timeout:   defaultTimeout,
+	})
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		uri     string
+		wantErr bool
+	}{
+		{uri: "file:///var/lib/state.json", wantErr: false},
+		{uri: "file://", wantErr: true},
+		{uri: "configmap://gatekeeper-securitycenter/state", wantErr: false},
+		{uri: "configmap://gatekeeper-securitycenter", wantErr: true},
+		{uri: "configmap:///state", wantErr: true},
+		{uri: "bbolt:///var/lib/state.db", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			if _, err := New(testr.New(t), tt.uri, &rest.Config{Host: "https://apiserver:443"}); (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,332 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	securitycenterclient "github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+const (
+	// AdmissionCategory is the category of findings that report Gatekeeper
+	// admission denials and warnings
+	AdmissionCategory = "ADMISSION_VIOLATION"
+
+	// AdmissionEventsAllNamespaces reads admission events in all namespaces,
+	// see SetAdmissionEvents
+	AdmissionEventsAllNamespaces = "*"
+
+	// gatekeeperProcessAdmission is the value of the process annotation on
+	// Gatekeeper admission events
+	gatekeeperProcessAdmission = "admission"
+)
+
+// Reasons of Gatekeeper admission events that become findings. Gatekeeper
+// also emits events with the reason DryrunViolation, for constraints with
+// the dryrun enforcement action. Those violations are reported by audit.
+var admissionEventReasons = map[string]bool{
+	"FailedAdmission":  true,
+	"WarningAdmission": true,
+}
+
+// SetAdmissionEvents enables findings for Gatekeeper admission denials and
+// warnings, using the Kubernetes Events that Gatekeeper emits with the
+// --emit-admission-events flag. Events are read from the namespace, which is
+// the Gatekeeper namespace, unless Gatekeeper runs with the
+// --admission-events-involved-namespace flag. Use
+// AdmissionEventsAllNamespaces to read events in all namespaces, and an
+// empty namespace to disable admission findings.
+//
+// Repeated denials of the same user, constraint, and resource are reported
+// by one finding. The finding is set to INACTIVE when there have been no
+// denials for the ttl.
+func (c *Client) SetAdmissionEvents(namespace string, ttl time.Duration) error {
+	if namespace != "" && ttl <= 0 {
+		return fmt.Errorf("invalid admission finding ttl: %v", ttl)
+	}
+	c.admissionEventsNamespace = namespace
+	c.admissionFindingTTL = ttl
+	return nil
+}
+
+// admissionKey identifies the repeated denials reported by one finding
+type admissionKey struct {
+	constraintKind    string
+	constraintName    string
+	enforcementAction string
+	resourceGVK       schema.GroupVersionKind
+	resourceNamespace string
+	resourceName      string
+	username          string
+	operation         string
+}
+
+// admissionDenials are the occurrences of an admission denial or warning
+type admissionDenials struct {
+	key       admissionKey
+	count     int64
+	firstSeen time.Time
+	lastSeen  time.Time
+	message   string
+}
+
+// getAdmissionFindingRequests returns finding requests for the admission
+// denials and warnings in the Gatekeeper admission events, keyed by full
+// finding name. Denials older than the admission finding ttl are skipped.
+func (c *Client) getAdmissionFindingRequests(ctx context.Context, kindToGVR map[string][]schema.GroupVersionResource, now time.Time) (map[string]*securitycenterpb.CreateFindingRequest, error) {
+	namespace := c.admissionEventsNamespace
+	if namespace == AdmissionEventsAllNamespaces {
+		namespace = ""
+	}
+	events, err := c.dynamicClient.ListWarningEvents(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	findingRequests := map[string]*securitycenterpb.CreateFindingRequest{}
+	for _, denials := range c.groupAdmissionDenials(events, now) {
+		req := c.createAdmissionFindingRequest(denials, kindToGVR)
+		findingRequests[fmt.Sprintf("%s/findings/%s", req.Parent, req.FindingId)] = req
+	}
+	return findingRequests, nil
+}
+
+// groupAdmissionDenials groups the admission denials and warnings in the
+// events by the user, constraint, resource, and operation. Events excluded
+// by the filters, and denials older than the admission finding ttl, are
+// skipped.
+func (c *Client) groupAdmissionDenials(events []unstructured.Unstructured, now time.Time) map[admissionKey]*admissionDenials {
+	denials := map[admissionKey]*admissionDenials{}
+	for i := range events {
+		occurrence, ok := parseAdmissionEvent(&events[i])
+		if !ok {
+			continue
+		}
+		key := occurrence.key
+		if !c.filters.includesConstraintKind(key.constraintKind) || !c.filters.includesNamespace(key.resourceNamespace) {
+			continue
+		}
+		if now.Sub(occurrence.lastSeen) > c.admissionFindingTTL {
+			continue
+		}
+		existing, exists := denials[key]
+		if !exists {
+			denials[key] = occurrence
+			continue
+		}
+		existing.count += occurrence.count
+		if occurrence.firstSeen.Before(existing.firstSeen) {
+			existing.firstSeen = occurrence.firstSeen
+		}
+		if occurrence.lastSeen.After(existing.lastSeen) {
+			existing.lastSeen = occurrence.lastSeen
+			existing.message = occurrence.message
+		}
+	}
+	return denials
+}
+
+// parseAdmissionEvent returns the denial or warning of a Gatekeeper
+// admission event. Returns false if the event isn't a Gatekeeper admission
+// denial or warning.
+func parseAdmissionEvent(event *unstructured.Unstructured) (*admissionDenials, bool) {
+	annotations := event.GetAnnotations()
+	reason, _, _ := unstructured.NestedString(event.UnstructuredContent(), "reason")
+	if annotations["process"] != gatekeeperProcessAdmission || !admissionEventReasons[reason] {
+		return nil, false
+	}
+	key := admissionKey{
+		constraintKind:    annotations["constraint_kind"],
+		constraintName:    annotations["constraint_name"],
+		enforcementAction: annotations["constraint_action"],
+		resourceGVK: schema.GroupVersionKind{
+			Group:   annotations["resource_group"],
+			Version: annotations["resource_api_version"],
+			Kind:    annotations["resource_kind"],
+		},
+		resourceNamespace: annotations["resource_namespace"],
+		resourceName:      annotations["resource_name"],
+		username:          annotations["request_username"],
+		operation:         admissionOperation(event),
+	}
+	firstSeen := eventTime(event, "firstTimestamp")
+	lastSeen := eventTime(event, "lastTimestamp")
+	if lastSeen.IsZero() {
+		lastSeen = eventTime(event, "eventTime")
+	}
+	if lastSeen.IsZero() {
+		lastSeen = event.GetCreationTimestamp().Time
+	}
+	if firstSeen.IsZero() {
+		firstSeen = lastSeen
+	}
+	count, _, _ := unstructured.NestedInt64(event.UnstructuredContent(), "count")
+	if count < 1 {
+		count = 1
+	}
+	message, _, _ := unstructured.NestedString(event.UnstructuredContent(), "message")
+	return &admissionDenials{
+		key:       key,
+		count:     count,
+		firstSeen: firstSeen,
+		lastSeen:  lastSeen,
+		message:   message,
+	}, true
+}
+
+// admissionOperation returns the operation of the request that was denied.
+// Gatekeeper admission events don't include the operation, but when
+// Gatekeeper emits events in the namespace of the involved object, the
+// involved object has a resource version for updates of existing objects,
+// and no UID for new objects. Returns an empty string if the operation
+// can't be determined.
+func admissionOperation(event *unstructured.Unstructured) string {
+	if _, exists := event.UnstructuredContent()["involvedObject"]; !exists {
+		return ""
+	}
+	uid, _, _ := unstructured.NestedString(event.UnstructuredContent(), "involvedObject", "uid")
+	resourceVersion, _, _ := unstructured.NestedString(event.UnstructuredContent(), "involvedObject", "resourceVersion")
+	switch {
+	case resourceVersion != "":
+		return "UPDATE"
+	case uid == "":
+		return "CREATE"
+	default:
+		return ""
+	}
+}
+
+// eventTime returns the time in the field of the event, or the zero time
+func eventTime(event *unstructured.Unstructured, field string) time.Time {
+	value, _, _ := unstructured.NestedString(event.UnstructuredContent(), field)
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		// eventTime uses microsecond precision
+		t, err = time.Parse(time.RFC3339Nano, value)
+	}
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// createAdmissionFindingRequest creates a CreateFindingRequest for the
+// occurrences of an admission denial or warning
+func (c *Client) createAdmissionFindingRequest(denials *admissionDenials, kindToGVR map[string][]schema.GroupVersionResource) *securitycenterpb.CreateFindingRequest {
+	key := denials.key
+	// Add API server host to all Kubernetes object links and limit to max 255 characters
+	resourceSelfLink := fmt.Sprintf("%.255s", c.host+objectPath(key.resourceGVK, key.resourceNamespace, key.resourceName, kindToGVR))
+	constraintSelfLink := fmt.Sprintf("%.255s", c.host+objectPath(schema.GroupVersionKind{Group: "constraints.gatekeeper.sh", Version: "v1beta1", Kind: key.constraintKind}, "", key.constraintName, kindToGVR))
+	stringValue := func(value string) *structpb.Value {
+		return &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: fmt.Sprintf("%.255s", value)}}
+	}
+	return &securitycenterpb.CreateFindingRequest{
+		Parent:    c.source,
+		FindingId: admissionFindingID(c.cluster, key),
+		Finding: &securitycenterpb.Finding{
+			State:        securitycenterpb.Finding_ACTIVE,
+			ResourceName: resourceSelfLink,
+			Category:     AdmissionCategory,
+			EventTime:    timestamppb.New(denials.lastSeen),
+			ExternalUri:  constraintSelfLink,
+			SourceProperties: map[string]*structpb.Value{
+				// each source property value must be max 255 chars
+				"ScannerName":        stringValue(scannerName),
+				"Explanation":        stringValue(denials.message),
+				"Cluster":            stringValue(c.cluster),
+				"ConstraintKind":     stringValue(key.constraintKind),
+				"ConstraintName":     stringValue(key.constraintName),
+				"ConstraintSelfLink": stringValue(constraintSelfLink),
+				"EnforcementAction":  stringValue(key.enforcementAction),
+				"RequestUsername":    stringValue(key.username),
+				"RequestOperation":   stringValue(key.operation),
+				"ResourceName":       stringValue(key.resourceName),
+				"ResourceNamespace":  stringValue(key.resourceNamespace),
+				"ResourceSelfLink":   stringValue(resourceSelfLink),
+				"ResourceAPIGroup":   stringValue(key.resourceGVK.Group),
+				"ResourceAPIVersion": stringValue(key.resourceGVK.Version),
+				"ResourceKind":       stringValue(key.resourceGVK.Kind),
+				securitycenterclient.FirstSeenTimeProperty:   stringValue(denials.firstSeen.UTC().Format(time.RFC3339)),
+				securitycenterclient.LastSeenTimeProperty:    stringValue(denials.lastSeen.UTC().Format(time.RFC3339)),
+				securitycenterclient.OccurrenceCountProperty: {Kind: &structpb.Value_NumberValue{NumberValue: float64(denials.count)}},
+			},
+		},
+	}
+}
+
+// objectPath returns the API server path of the object, or an empty string
+// if the kind isn't served by the API server
+func objectPath(gvk schema.GroupVersionKind, namespace, name string, kindToGVR map[string][]schema.GroupVersionResource) string {
+	for _, gvr := range kindToGVR[gvk.Kind] {
+		if gvr.Group != gvk.Group {
+			continue
+		}
+		segments := []string{"/apis", gvr.Group, gvr.Version}
+		if gvr.Group == "" {
+			segments = []string{"/api", gvr.Version}
+		}
+		if namespace != "" {
+			segments = append(segments, "namespaces", namespace)
+		}
+		return strings.Join(append(segments, gvr.Resource, name), "/")
+	}
+	return ""
+}
+
+// preservesAdmissionFinding returns true for admission findings whose last
+// denial is more recent than the admission finding ttl. Kubernetes deletes
+// events after one hour by default, so admission findings are kept ACTIVE
+// after their events are deleted.
+func (c *Client) preservesAdmissionFinding(finding *securitycenterpb.Finding, now time.Time) bool {
+	if c.admissionEventsNamespace == "" || finding.GetCategory() != AdmissionCategory {
+		return false
+	}
+	lastSeen, err := time.Parse(time.RFC3339, property(finding, securitycenterclient.LastSeenTimeProperty))
+	if err != nil {
+		return false
+	}
+	return now.Sub(lastSeen) <= c.admissionFindingTTL
+}
+
+// admissionFindingID creates a deterministic finding ID for the repeated
+// denials of the same user, constraint, and resource
+func admissionFindingID(cluster string, key admissionKey) string {
+	uidSha := sha256.Sum256([]byte(strings.Join([]string{
+		cluster,
+		AdmissionCategory,
+		key.constraintKind,
+		key.constraintName,
+		key.enforcementAction,
+		key.resourceGVK.Group,
+		key.resourceGVK.Version,
+		key.resourceGVK.Kind,
+		key.resourceNamespace,
+		key.resourceName,
+		key.username,
+		key.operation,
+	}, "/")))
+	return hex.EncodeToString(uidSha[:])[:32]
+}
@@ -0,0 +1,287 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	securitycenterclient "github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+// newAdmissionEvent returns a Gatekeeper admission event for a denial of the
+// pod by the K8sRequiredLabels constraint
+func newAdmissionEvent(reason, username string, lastTimestamp time.Time, count int64, involvedObject map[string]interface{}) unstructured.Unstructured {
+	event := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Event",
+		"metadata": map[string]interface{}{
+			"name":      "event",
+			"namespace": "gatekeeper-system",
+			"annotations": map[string]interface{}{
+				"process":              "admission",
+				"event_type":           "violation",
+				"constraint_kind":      "K8sRequiredLabels",
+				"constraint_name":      "must-have-owner",
+				"constraint_action":    "deny",
+				"resource_group":       "",
+				"resource_api_version": "v1",
+				"resource_kind":        "Pod",
+				"resource_namespace":   "default",
+				"resource_name":        "my-pod",
+				"request_username":     username,
+			},
+		},
+		"type":           "Warning",
+		"reason":         reason,
+		"message":        "Admission webhook \"validation.gatekeeper.sh\" denied request, Resource Namespace: default, Constraint: must-have-owner, Message: " + lastTimestamp.Format(time.RFC3339),
+		"firstTimestamp": lastTimestamp.Add(-time.Minute).Format(time.RFC3339),
+		"lastTimestamp":  lastTimestamp.Format(time.RFC3339),
+		"count":          count,
+	}}
+	if involvedObject != nil {
+		event.Object["involvedObject"] = involvedObject
+	}
+	return event
+}
+
+func Test_parseAdmissionEvent(t *testing.T) {
+	lastSeen := time.Date(2021, 3, 1, 2, 3, 4, 0, time.UTC)
+	tests := []struct {
+		name          string
+		event         unstructured.Unstructured
+		wantOK        bool
+		wantOperation string
+	}{
+		{
+			name:   "denial",
+			event:  newAdmissionEvent("FailedAdmission", "alice", lastSeen, 3, nil),
+			wantOK: true,
+		},
+		{
+			name:   "warning",
+			event:  newAdmissionEvent("WarningAdmission", "alice", lastSeen, 3, nil),
+			wantOK: true,
+		},
+		{
+			name:  "dryrun violation",
+			event: newAdmissionEvent("DryrunViolation", "alice", lastSeen, 3, nil),
+		},
+		{
+			name: "audit event",
+			event: func() unstructured.Unstructured {
+				event := newAdmissionEvent("FailedAdmission", "alice", lastSeen, 3, nil)
+				annotations := event.GetAnnotations()
+				annotations["process"] = "audit"
+				event.SetAnnotations(annotations)
+				return event
+			}(),
+		},
+		{
+			name:          "create in involved namespace",
+			event:         newAdmissionEvent("FailedAdmission", "alice", lastSeen, 3, map[string]interface{}{"kind": "Pod", "namespace": "default", "name": "my-pod"}),
+			wantOK:        true,
+			wantOperation: "CREATE",
+		},
+		{
+			name:          "update in involved namespace",
+			event:         newAdmissionEvent("FailedAdmission", "alice", lastSeen, 3, map[string]interface{}{"kind": "Pod", "namespace": "default", "name": "my-pod", "uid": "pod-uid", "resourceVersion": "42"}),
+			wantOK:        true,
+			wantOperation: "UPDATE",
+		},
+		{
+			name:   "synthetic involved object in gatekeeper namespace",
+			event:  newAdmissionEvent("FailedAdmission", "alice", lastSeen, 3, map[string]interface{}{"kind": "Pod", "namespace": "gatekeeper-system", "name": "my-pod", "uid": "Pod/default/my-pod/K8sRequiredLabels//must-have-owner"}),
+			wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseAdmissionEvent(&tt.event)
+			if ok != tt.wantOK {
+				t.Fatalf("parseAdmissionEvent() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			want := admissionKey{
+				constraintKind:    "K8sRequiredLabels",
+				constraintName:    "must-have-owner",
+				enforcementAction: "deny",
+				resourceGVK:       schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+				resourceNamespace: "default",
+				resourceName:      "my-pod",
+				username:          "alice",
+				operation:         tt.wantOperation,
+			}
+			if diff := cmp.Diff(want, got.key, cmp.AllowUnexported(admissionKey{})); diff != "" {
+				t.Errorf("parseAdmissionEvent() key mismatch (-want +got):\n%s", diff)
+			}
+			if got.count != 3 || !got.lastSeen.Equal(lastSeen) || !got.firstSeen.Equal(lastSeen.Add(-time.Minute)) {
+				t.Errorf("parseAdmissionEvent() count = %d, firstSeen = %v, lastSeen = %v", got.count, got.firstSeen, got.lastSeen)
+			}
+		})
+	}
+}
+
+func TestClient_groupAdmissionDenials(t *testing.T) {
+	now := time.Date(2021, 3, 1, 2, 3, 4, 0, time.UTC)
+	events := []unstructured.Unstructured{
+		newAdmissionEvent("FailedAdmission", "alice", now.Add(-time.Hour), 2, nil),
+		newAdmissionEvent("FailedAdmission", "alice", now.Add(-time.Minute), 3, nil),
+		newAdmissionEvent("FailedAdmission", "bob", now.Add(-time.Minute), 1, nil),
+		newAdmissionEvent("FailedAdmission", "carol", now.Add(-48*time.Hour), 1, nil),
+	}
+	c := &Client{log: testr.New(t)}
+	if err := c.SetAdmissionEvents("gatekeeper-system", 24*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]int64{}
+	for key, denials := range c.groupAdmissionDenials(events, now) {
+		got[key.username] = denials.count
+		if key.username == "alice" {
+			if !denials.firstSeen.Equal(now.Add(-time.Hour-time.Minute)) || !denials.lastSeen.Equal(now.Add(-time.Minute)) {
+				t.Errorf("groupAdmissionDenials() firstSeen = %v, lastSeen = %v", denials.firstSeen, denials.lastSeen)
+			}
+			if want := events[1].Object["message"]; denials.message != want {
+				t.Errorf("groupAdmissionDenials() message = %q, want %q", denials.message, want)
+			}
+		}
+	}
+	if diff := cmp.Diff(map[string]int64{"alice": 5, "bob": 1}, got); diff != "" {
+		t.Errorf("groupAdmissionDenials() count mismatch (-want +got):\n%s", diff)
+	}
+
+	c.SetFilters(Filters{ExcludedNamespaces: []string{"default"}})
+	if got := c.groupAdmissionDenials(events, now); len(got) != 0 {
+		t.Errorf("groupAdmissionDenials() with excluded namespace returned %d groups, want 0", len(got))
+	}
+}
+
+func TestClient_createAdmissionFindingRequest(t *testing.T) {
+	lastSeen := time.Date(2021, 3, 1, 2, 3, 4, 0, time.UTC)
+	event := newAdmissionEvent("FailedAdmission", "alice", lastSeen, 3, nil)
+	denials, ok := parseAdmissionEvent(&event)
+	if !ok {
+		t.Fatal("parseAdmissionEvent() returned false")
+	}
+	kindToGVR := map[string][]schema.GroupVersionResource{
+		"Pod":               {{Version: "v1", Resource: "pods"}},
+		"K8sRequiredLabels": {{Group: "constraints.gatekeeper.sh", Version: "v1beta1", Resource: "k8srequiredlabels"}},
+	}
+	c := &Client{log: testr.New(t), host: host, source: source, cluster: cluster}
+	req := c.createAdmissionFindingRequest(denials, kindToGVR)
+
+	if req.Finding.Category != AdmissionCategory {
+		t.Errorf("Category = %s, want %s", req.Finding.Category, AdmissionCategory)
+	}
+	if want := host + "/api/v1/namespaces/default/pods/my-pod"; req.Finding.ResourceName != want {
+		t.Errorf("ResourceName = %s, want %s", req.Finding.ResourceName, want)
+	}
+	if want := host + "/apis/constraints.gatekeeper.sh/v1beta1/k8srequiredlabels/must-have-owner"; req.Finding.ExternalUri != want {
+		t.Errorf("ExternalUri = %s, want %s", req.Finding.ExternalUri, want)
+	}
+	if !req.Finding.EventTime.AsTime().Equal(lastSeen) {
+		t.Errorf("EventTime = %v, want %v", req.Finding.EventTime.AsTime(), lastSeen)
+	}
+	wantProperties := map[string]string{
+		"Cluster":           cluster,
+		"ConstraintKind":    "K8sRequiredLabels",
+		"ConstraintName":    "must-have-owner",
+		"EnforcementAction": "deny",
+		"RequestUsername":   "alice",
+		"RequestOperation":  "",
+		"ResourceKind":      "Pod",
+		securitycenterclient.FirstSeenTimeProperty: "2021-03-01T02:02:04Z",
+		securitycenterclient.LastSeenTimeProperty:  "2021-03-01T02:03:04Z",
+	}
+	for key, want := range wantProperties {
+		if got := property(req.Finding, key); got != want {
+			t.Errorf("source property %s = %q, want %q", key, got, want)
+		}
+	}
+	if got := req.Finding.SourceProperties[securitycenterclient.OccurrenceCountProperty].GetNumberValue(); got != 3 {
+		t.Errorf("source property %s = %v, want 3", securitycenterclient.OccurrenceCountProperty, got)
+	}
+	if property(req.Finding, "ConstraintUID") != "" {
+		t.Errorf("admission findings must not have a ConstraintUID source property")
+	}
+	otherUser := denials.key
+	otherUser.username = "bob"
+	if req.FindingId == admissionFindingID(cluster, otherUser) {
+		t.Errorf("FindingId is the same for denials of different users")
+	}
+}
+
+func TestClient_preservesAdmissionFinding(t *testing.T) {
+	now := time.Date(2021, 3, 1, 2, 3, 4, 0, time.UTC)
+	tests := []struct {
+		name      string
+		namespace string
+		category  string
+		lastSeen  time.Time
+		want      bool
+	}{
+		{
+			name:      "within ttl",
+			namespace: "gatekeeper-system",
+			category:  AdmissionCategory,
+			lastSeen:  now.Add(-2 * time.Hour),
+			want:      true,
+		},
+		{
+			name:      "expired",
+			namespace: "gatekeeper-system",
+			category:  AdmissionCategory,
+			lastSeen:  now.Add(-25 * time.Hour),
+		},
+		{
+			name:      "audit finding",
+			namespace: "gatekeeper-system",
+			category:  "K8sRequiredLabels",
+			lastSeen:  now.Add(-2 * time.Hour),
+		},
+		{
+			name:     "admission findings disabled",
+			category: AdmissionCategory,
+			lastSeen: now.Add(-2 * time.Hour),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{log: testr.New(t)}
+			if err := c.SetAdmissionEvents(tt.namespace, 24*time.Hour); err != nil {
+				t.Fatal(err)
+			}
+			finding := &securitycenterpb.Finding{
+				Category: tt.category,
+				SourceProperties: map[string]*structpb.Value{
+					securitycenterclient.LastSeenTimeProperty: structpb.NewStringValue(tt.lastSeen.Format(time.RFC3339)),
+				},
+			}
+			if got := c.preservesAdmissionFinding(finding, now); got != tt.want {
+				t.Errorf("preservesAdmissionFinding() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"context"
+	"strconv"
+
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/dynamic"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+// Annotations on constraints, see SetAnnotateConstraints
+const (
+	AnnotationActiveFindings = "gatekeeper-securitycenter/active-findings"
+	AnnotationSource         = "gatekeeper-securitycenter/source"
+	AnnotationFindingsURL    = "gatekeeper-securitycenter/findings-url"
+
+	// fieldManager owns the constraint annotations in server-side apply
+	fieldManager = "gatekeeper-securitycenter"
+)
+
+// SetAnnotateConstraints enables annotating each constraint with the count
+// of ACTIVE findings, the source name, and a link to the findings in the
+// Google Cloud console, after each sync.
+func (c *Client) SetAnnotateConstraints(enabled bool) {
+	c.annotateConstraints = enabled
+}
+
+// updateConstraintAnnotations applies the annotations to constraints that
+// have findings, or that were annotated before. The count includes the
+// ACTIVE findings that the last sync with Security Command Center preserved
+// for unresolved violations. Constraints with unchanged annotations are
+// skipped. Errors are logged, they don't fail the sync.
+func (c *Client) updateConstraintAnnotations(ctx context.Context, groupResources []schema.GroupResource, findingRequests map[string]*securitycenterpb.CreateFindingRequest) {
+	if !c.annotateConstraints || c.dryRun {
+		return
+	}
+	constraints, err := c.dynamicClient.GetConstraints(ctx, groupResources)
+	if err != nil {
+		c.log.Error(err, "could not list constraints to update annotations")
+		return
+	}
+	counts := countFindingsByConstraint(findingRequests, c.preservedFindings)
+	for i := range constraints {
+		constraint := &constraints[i]
+		annotations := c.constraintAnnotations(constraint, counts[string(constraint.GetUID())])
+		if !needsAnnotations(constraint.GetAnnotations(), annotations) {
+			continue
+		}
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(constraint.GetAPIVersion())
+		obj.SetKind(constraint.GetKind())
+		obj.SetName(constraint.GetName())
+		obj.SetAnnotations(annotations)
+		if _, err := c.dynamicClient.ApplyResource(ctx, dynamic.ConstraintGVR(constraint.GetKind()), obj, fieldManager); err != nil {
+			c.log.Error(err, "could not annotate constraint", "kind", constraint.GetKind(), "name", constraint.GetName())
+		}
+	}
+}
+
+// constraintAnnotations returns the annotations for the constraint
+func (c *Client) constraintAnnotations(constraint *unstructured.Unstructured, activeFindings int) map[string]string {
+	filter := &securitycenter.FindingsFilter{
+		Source:         c.source,
+		State:          securitycenterpb.Finding_ACTIVE.String(),
+		Category:       constraint.GetKind(),
+		Cluster:        c.cluster,
+		ConstraintName: constraint.GetName(),
+	}
+	return map[string]string{
+		AnnotationActiveFindings: strconv.Itoa(activeFindings),
+		AnnotationSource:         c.source,
+		AnnotationFindingsURL:    securitycenter.FindingsConsoleURL(filter),
+	}
+}
+
+// needsAnnotations returns true if the existing annotations differ from the
+// desired annotations. Constraints without findings that were never
+// annotated don't need annotations.
+func needsAnnotations(existing, desired map[string]string) bool {
+	if _, annotated := existing[AnnotationActiveFindings]; !annotated && desired[AnnotationActiveFindings] == "0" {
+		return false
+	}
+	for key, value := range desired {
+		if existing[key] != value {
+			return true
+		}
+	}
+	return false
+}
+
+// countFindingsByConstraint returns the number of finding requests and
+// preserved findings for each constraint UID. The key of preserved is the
+// finding name, and the value is the constraint UID.
+func countFindingsByConstraint(findingRequests map[string]*securitycenterpb.CreateFindingRequest, preserved map[string]string) map[string]int {
+	counts := map[string]int{}
+	for _, req := range findingRequests {
+		counts[property(req.GetFinding(), "ConstraintUID")]++
+	}
+	for findingName, constraintUID := range preserved {
+		if _, requested := findingRequests[findingName]; !requested {
+			counts[constraintUID]++
+		}
+	}
+	return counts
+}
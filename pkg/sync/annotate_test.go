@@ -0,0 +1,115 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_needsAnnotations(t *testing.T) {
+	desired := map[string]string{
+		AnnotationActiveFindings: "2",
+		AnnotationSource:         "organizations/123/sources/456",
+	}
+	tests := []struct {
+		name     string
+		existing map[string]string
+		desired  map[string]string
+		want     bool
+	}{
+		{
+			name:    "not annotated with findings",
+			desired: desired,
+			want:    true,
+		},
+		{
+			name:    "not annotated without findings",
+			desired: map[string]string{AnnotationActiveFindings: "0"},
+			want:    false,
+		},
+		{
+			name:     "annotated and unchanged",
+			existing: map[string]string{AnnotationActiveFindings: "2", AnnotationSource: "organizations/123/sources/456", "other": "value"},
+			desired:  desired,
+			want:     false,
+		},
+		{
+			name:     "annotated and findings resolved",
+			existing: map[string]string{AnnotationActiveFindings: "2"},
+			desired:  map[string]string{AnnotationActiveFindings: "0"},
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := needsAnnotations(tt.existing, tt.desired); got != tt.want {
+				t.Errorf("needsAnnotations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_countFindingsByConstraint(t *testing.T) {
+	request := func(constraintUID string) *securitycenterpb.CreateFindingRequest {
+		return &securitycenterpb.CreateFindingRequest{
+			Finding: &securitycenterpb.Finding{
+				SourceProperties: map[string]*structpb.Value{
+					"ConstraintUID": structpb.NewStringValue(constraintUID),
+				},
+			},
+		}
+	}
+	got := countFindingsByConstraint(map[string]*securitycenterpb.CreateFindingRequest{
+		"a": request("uid-1"),
+		"b": request("uid-1"),
+		"c": request("uid-2"),
+	}, map[string]string{
+		"c": "uid-2",
+		"d": "uid-2",
+		"e": "uid-3",
+	})
+	want := map[string]int{"uid-1": 2, "uid-2": 2, "uid-3": 1}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("countFindingsByConstraint() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_constraintAnnotations(t *testing.T) {
+	c := &Client{source: "organizations/123/sources/456", cluster: "my-cluster"}
+	constraint := &unstructured.Unstructured{}
+	constraint.SetKind("K8sRequiredLabels")
+	constraint.SetName("ns-must-have-owner")
+	got := c.constraintAnnotations(constraint, 3)
+	if got[AnnotationActiveFindings] != "3" || got[AnnotationSource] != c.source {
+		t.Errorf("constraintAnnotations() = %v", got)
+	}
+	u, err := url.Parse(got[AnnotationFindingsURL])
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantQuery := `parent="organizations/123/sources/456" AND state="ACTIVE" AND category="K8sRequiredLabels" AND source_properties.Cluster="my-cluster" AND source_properties.ConstraintName="ns-must-have-owner"`
+	if got := u.Query().Get("query"); got != wantQuery {
+		t.Errorf("constraintAnnotations() findings URL query = %v, want %v", got, wantQuery)
+	}
+	if got := u.Query().Get("organizationId"); got != "123" {
+		t.Errorf("constraintAnnotations() findings URL organizationId = %v, want 123", got)
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Ref: https://cloud.google.com/asset-inventory/docs/resource-name-format
+
+const configConnectorGroupSuffix = ".cnrm.cloud.google.com"
+
+// assetNameRule creates the Cloud Asset Inventory resource name for a Config
+// Connector kind
+type assetNameRule struct {
+	// service is the API service name, e.g., compute.googleapis.com
+	service string
+	// format is the resource path after the service name. The placeholders
+	// {project}, {location}, {region}, {zone}, and {id} are replaced with
+	// values from the resource. {locationCollection} is `zones` if
+	// {location} is a zone, and `regions` otherwise. The placeholders {projectNumber} and
+	// {uniqueId} are replaced with values from the resource status, for
+	// services that use those in their asset names.
+	format string
+}
+
+var (
+	// assetNameRules are keyed by Config Connector kind
+	assetNameRules = map[string]assetNameRule{
+		"ArtifactRegistryRepository": {"artifactregistry.googleapis.com", "projects/{project}/locations/{location}/repositories/{id}"},
+		"BigQueryDataset":            {"bigquery.googleapis.com", "projects/{project}/datasets/{id}"},
+		"ComputeDisk":                {"compute.googleapis.com", "projects/{project}/{locationCollection}/{location}/disks/{id}"},
+		"ComputeFirewall":            {"compute.googleapis.com", "projects/{project}/global/firewalls/{id}"},
+		"ComputeInstance":            {"compute.googleapis.com", "projects/{project}/zones/{zone}/instances/{id}"},
+		"ComputeNetwork":             {"compute.googleapis.com", "projects/{project}/global/networks/{id}"},
+		"ComputeSubnetwork":          {"compute.googleapis.com", "projects/{project}/regions/{region}/subnetworks/{id}"},
+		"ContainerCluster":           {"container.googleapis.com", "projects/{project}/locations/{location}/clusters/{id}"},
+		"DNSManagedZone":             {"dns.googleapis.com", "projects/{project}/managedZones/{id}"},
+		"IAMServiceAccount":          {"iam.googleapis.com", "projects/{project}/serviceAccounts/{uniqueId}"},
+		"KMSKeyRing":                 {"cloudkms.googleapis.com", "projects/{project}/locations/{location}/keyRings/{id}"},
+		"Project":                    {"cloudresourcemanager.googleapis.com", "projects/{projectNumber}"},
+		"PubSubSubscription":         {"pubsub.googleapis.com", "projects/{project}/subscriptions/{id}"},
+		"PubSubTopic":                {"pubsub.googleapis.com", "projects/{project}/topics/{id}"},
+		"RedisInstance":              {"redis.googleapis.com", "projects/{project}/locations/{region}/instances/{id}"},
+		"SecretManagerSecret":        {"secretmanager.googleapis.com", "projects/{projectNumber}/secrets/{id}"},
+		"SpannerInstance":            {"spanner.googleapis.com", "projects/{project}/instances/{id}"},
+		"SQLInstance":                {"cloudsql.googleapis.com", "projects/{project}/instances/{id}"},
+		"StorageBucket":              {"storage.googleapis.com", "{id}"},
+	}
+
+	placeholderRegexp = regexp.MustCompile(`{[a-zA-Z]+}`)
+
+	// statusNameProjectNumberRegexp matches the project number in
+	// `status.name`, e.g., projects/123456789012/secrets/api-key
+	statusNameProjectNumberRegexp = regexp.MustCompile(`^projects/([0-9]+)/`)
+
+	// computeSelfLinkRegexp matches Compute Engine self links, e.g.,
+	// https://www.googleapis.com/compute/v1/projects/p/zones/z/instances/i
+	computeSelfLinkRegexp = regexp.MustCompile(`^https://(?:www|compute)\.googleapis\.com/compute/(?:v1|beta|alpha)/(projects/.+)$`)
+
+	// zoneRegexp matches zone names, e.g., us-central1-a. Region names, such
+	// as us-central1, don't have the zone suffix.
+	zoneRegexp = regexp.MustCompile(`^[a-z]+-[a-z]+[0-9]+-[a-z]$`)
+)
+
+// isConfigConnectorResource returns true if the resource is managed by Config Connector
+func isConfigConnectorResource(resource *unstructured.Unstructured) bool {
+	return strings.HasSuffix(resource.GroupVersionKind().Group, configConnectorGroupSuffix)
+}
+
+// resolveAssetName returns the full Cloud Asset Inventory resource name of
+// the Google Cloud resource managed by a Config Connector resource, e.g.,
+// `//storage.googleapis.com/my-bucket`. Returns an empty string if the name
+// can't be resolved.
+//
+// The name is resolved from, in order:
+//  1. `status.externalRef`, for kinds in the rules table whose asset names
+//     don't use values from the status
+//  2. the rules table, using `spec.resourceID` or the metadata name
+//  3. a Compute Engine `status.selfLink`
+func resolveAssetName(resource *unstructured.Unstructured) string {
+	if !isConfigConnectorResource(resource) {
+		return ""
+	}
+	rule, hasRule := assetNameRules[resource.GetKind()]
+	if externalRef, _, _ := unstructured.NestedString(resource.UnstructuredContent(), "status", "externalRef"); externalRef != "" && hasRule && !rule.usesStatus() {
+		if strings.HasPrefix(externalRef, "//") {
+			return externalRef
+		}
+		return "//" + rule.service + "/" + strings.TrimPrefix(externalRef, "/")
+	}
+	if hasRule {
+		if name := rule.resolve(resource); name != "" {
+			return name
+		}
+	}
+	if selfLink, _, _ := unstructured.NestedString(resource.UnstructuredContent(), "status", "selfLink"); selfLink != "" {
+		if match := computeSelfLinkRegexp.FindStringSubmatch(selfLink); match != nil {
+			return "//compute.googleapis.com/" + match[1]
+		}
+	}
+	return ""
+}
+
+// usesStatus returns true if the rule format uses values from the resource
+// status. The `status.externalRef` of those kinds uses the project ID, so it
+// isn't the asset name.
+func (r assetNameRule) usesStatus() bool {
+	return strings.Contains(r.format, "{projectNumber}") || strings.Contains(r.format, "{uniqueId}")
+}
+
+// resolve replaces the placeholders in the rule format with values from
+// the resource. Returns an empty string if any value is missing.
+func (r assetNameRule) resolve(resource *unstructured.Unstructured) string {
+	location := specString(resource, "location")
+	values := map[string]string{
+		"{project}":            configConnectorProject(resource),
+		"{projectNumber}":      configConnectorProjectNumber(resource),
+		"{location}":           location,
+		"{locationCollection}": locationCollection(location),
+		"{region}":             specString(resource, "region"),
+		"{zone}":               specString(resource, "zone"),
+		"{id}":                 configConnectorResourceID(resource),
+		"{uniqueId}":           statusString(resource, "uniqueId"),
+	}
+	missing := false
+	path := placeholderRegexp.ReplaceAllStringFunc(r.format, func(placeholder string) string {
+		value := values[placeholder]
+		if value == "" {
+			missing = true
+		}
+		return url.PathEscape(value)
+	})
+	if missing {
+		return ""
+	}
+	return "//" + r.service + "/" + path
+}
+
+// locationCollection returns the Compute Engine collection of the location,
+// `zones` for zonal resources and `regions` for regional resources. Returns
+// an empty string if the location isn't set.
+func locationCollection(location string) string {
+	switch {
+	case location == "":
+		return ""
+	case zoneRegexp.MatchString(location):
+		return "zones"
+	default:
+		return "regions"
+	}
+}
+
+// configConnectorProject returns the project ID from `spec.projectRef.external`,
+// or from the project ID annotation
+func configConnectorProject(resource *unstructured.Unstructured) string {
+	if external, _, _ := unstructured.NestedString(resource.UnstructuredContent(), "spec", "projectRef", "external"); external != "" {
+		return strings.TrimPrefix(external, "projects/")
+	}
+	return resource.GetAnnotations()[cnrmAnnotationProjectID]
+}
+
+// configConnectorProjectNumber returns the project number from
+// `status.number` of a Project, or from the start of `status.name`. Config
+// Connector doesn't record the project number of other resources.
+func configConnectorProjectNumber(resource *unstructured.Unstructured) string {
+	if number := statusString(resource, "number"); number != "" {
+		return number
+	}
+	if match := statusNameProjectNumberRegexp.FindStringSubmatch(statusString(resource, "name")); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// configConnectorResourceID returns `spec.resourceID`, or the metadata name
+// if the resource ID isn't set
+func configConnectorResourceID(resource *unstructured.Unstructured) string {
+	if resourceID := specString(resource, "resourceID"); resourceID != "" {
+		return resourceID
+	}
+	return resource.GetName()
+}
+
+// specString returns a string field from the resource spec
+func specString(resource *unstructured.Unstructured, field string) string {
+	value, _, _ := unstructured.NestedString(resource.UnstructuredContent(), "spec", field)
+	return value
+}
+
+// statusString returns a string field from the resource status
+func statusString(resource *unstructured.Unstructured, field string) string {
+	value, _, _ := unstructured.NestedString(resource.UnstructuredContent(), "status", field)
+	return value
+}
@@ -0,0 +1,219 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func kccResource(kind, name string, spec, status map[string]interface{}, annotations map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetAPIVersion("example.cnrm.cloud.google.com/v1beta1")
+	obj.SetKind(kind)
+	obj.SetName(name)
+	obj.SetNamespace("config-connector")
+	obj.SetAnnotations(annotations)
+	if spec != nil {
+		obj.Object["spec"] = spec
+	}
+	if status != nil {
+		obj.Object["status"] = status
+	}
+	return obj
+}
+
+func Test_resolveAssetName(t *testing.T) {
+	project := map[string]string{cnrmAnnotationProjectID: "my-project"}
+	tests := []struct {
+		name     string
+		resource *unstructured.Unstructured
+		want     string
+	}{
+		{
+			name:     "ArtifactRegistryRepository",
+			resource: kccResource("ArtifactRegistryRepository", "images", map[string]interface{}{"location": "us-central1"}, nil, project),
+			want:     "//artifactregistry.googleapis.com/projects/my-project/locations/us-central1/repositories/images",
+		},
+		{
+			name:     "BigQueryDataset",
+			resource: kccResource("BigQueryDataset", "analytics", map[string]interface{}{"resourceID": "analytics_prod"}, nil, project),
+			want:     "//bigquery.googleapis.com/projects/my-project/datasets/analytics_prod",
+		},
+		{
+			name:     "ComputeDisk",
+			resource: kccResource("ComputeDisk", "data", map[string]interface{}{"location": "us-central1-a"}, nil, project),
+			want:     "//compute.googleapis.com/projects/my-project/zones/us-central1-a/disks/data",
+		},
+		{
+			name:     "regional ComputeDisk",
+			resource: kccResource("ComputeDisk", "data", map[string]interface{}{"location": "us-central1"}, nil, project),
+			want:     "//compute.googleapis.com/projects/my-project/regions/us-central1/disks/data",
+		},
+		{
+			name:     "ComputeFirewall",
+			resource: kccResource("ComputeFirewall", "allow-ssh", nil, nil, project),
+			want:     "//compute.googleapis.com/projects/my-project/global/firewalls/allow-ssh",
+		},
+		{
+			name:     "ComputeInstance",
+			resource: kccResource("ComputeInstance", "vm", map[string]interface{}{"zone": "us-central1-a"}, nil, project),
+			want:     "//compute.googleapis.com/projects/my-project/zones/us-central1-a/instances/vm",
+		},
+		{
+			name:     "ComputeNetwork",
+			resource: kccResource("ComputeNetwork", "vpc", nil, nil, project),
+			want:     "//compute.googleapis.com/projects/my-project/global/networks/vpc",
+		},
+		{
+			name:     "ComputeSubnetwork",
+			resource: kccResource("ComputeSubnetwork", "subnet", map[string]interface{}{"region": "us-central1"}, nil, project),
+			want:     "//compute.googleapis.com/projects/my-project/regions/us-central1/subnetworks/subnet",
+		},
+		{
+			name:     "ContainerCluster",
+			resource: kccResource("ContainerCluster", "gke", map[string]interface{}{"location": "us-central1"}, nil, project),
+			want:     "//container.googleapis.com/projects/my-project/locations/us-central1/clusters/gke",
+		},
+		{
+			name:     "DNSManagedZone",
+			resource: kccResource("DNSManagedZone", "example-com", nil, nil, project),
+			want:     "//dns.googleapis.com/projects/my-project/managedZones/example-com",
+		},
+		{
+			name:     "IAMServiceAccount",
+			resource: kccResource("IAMServiceAccount", "app", nil, map[string]interface{}{"uniqueId": "104623751938465019283"}, project),
+			want:     "//iam.googleapis.com/projects/my-project/serviceAccounts/104623751938465019283",
+		},
+		{
+			name:     "IAMServiceAccount without unique ID",
+			resource: kccResource("IAMServiceAccount", "app", nil, nil, project),
+		},
+		{
+			name:     "KMSKeyRing",
+			resource: kccResource("KMSKeyRing", "keys", map[string]interface{}{"location": "global"}, nil, project),
+			want:     "//cloudkms.googleapis.com/projects/my-project/locations/global/keyRings/keys",
+		},
+		{
+			name:     "Project",
+			resource: kccResource("Project", "my-new-project", nil, map[string]interface{}{"number": "123456789012"}, nil),
+			want:     "//cloudresourcemanager.googleapis.com/projects/123456789012",
+		},
+		{
+			name:     "Project ignores externalRef with project ID",
+			resource: kccResource("Project", "my-new-project", nil, map[string]interface{}{"externalRef": "projects/my-new-project", "number": "123456789012"}, nil),
+			want:     "//cloudresourcemanager.googleapis.com/projects/123456789012",
+		},
+		{
+			name:     "Project without number",
+			resource: kccResource("Project", "my-new-project", nil, nil, nil),
+		},
+		{
+			name:     "PubSubSubscription",
+			resource: kccResource("PubSubSubscription", "sub", nil, nil, project),
+			want:     "//pubsub.googleapis.com/projects/my-project/subscriptions/sub",
+		},
+		{
+			name:     "PubSubTopic",
+			resource: kccResource("PubSubTopic", "topic", nil, nil, project),
+			want:     "//pubsub.googleapis.com/projects/my-project/topics/topic",
+		},
+		{
+			name:     "RedisInstance",
+			resource: kccResource("RedisInstance", "cache", map[string]interface{}{"region": "us-central1"}, nil, project),
+			want:     "//redis.googleapis.com/projects/my-project/locations/us-central1/instances/cache",
+		},
+		{
+			name:     "SecretManagerSecret",
+			resource: kccResource("SecretManagerSecret", "api-key", nil, map[string]interface{}{"name": "projects/123456789012/secrets/api-key"}, project),
+			want:     "//secretmanager.googleapis.com/projects/123456789012/secrets/api-key",
+		},
+		{
+			name:     "SecretManagerSecret without project number",
+			resource: kccResource("SecretManagerSecret", "api-key", nil, nil, project),
+		},
+		{
+			name:     "SpannerInstance",
+			resource: kccResource("SpannerInstance", "db", nil, nil, project),
+			want:     "//spanner.googleapis.com/projects/my-project/instances/db",
+		},
+		{
+			name:     "SQLInstance",
+			resource: kccResource("SQLInstance", "postgres", nil, nil, project),
+			want:     "//cloudsql.googleapis.com/projects/my-project/instances/postgres",
+		},
+		{
+			name:     "StorageBucket",
+			resource: kccResource("StorageBucket", "my-bucket", nil, nil, nil),
+			want:     "//storage.googleapis.com/my-bucket",
+		},
+		{
+			name:     "project from projectRef overrides annotation",
+			resource: kccResource("PubSubTopic", "topic", map[string]interface{}{"projectRef": map[string]interface{}{"external": "projects/other-project"}}, nil, project),
+			want:     "//pubsub.googleapis.com/projects/other-project/topics/topic",
+		},
+		{
+			name:     "externalRef takes precedence",
+			resource: kccResource("KMSKeyRing", "keys", map[string]interface{}{"location": "global"}, map[string]interface{}{"externalRef": "projects/p/locations/us/keyRings/actual"}, project),
+			want:     "//cloudkms.googleapis.com/projects/p/locations/us/keyRings/actual",
+		},
+		{
+			name:     "missing location",
+			resource: kccResource("ContainerCluster", "gke", nil, nil, project),
+		},
+		{
+			name:     "unknown kind with compute selfLink",
+			resource: kccResource("ComputeRouter", "router", nil, map[string]interface{}{"selfLink": "https://www.googleapis.com/compute/v1/projects/p/regions/r/routers/router"}, project),
+			want:     "//compute.googleapis.com/projects/p/regions/r/routers/router",
+		},
+		{
+			name:     "unknown kind",
+			resource: kccResource("LoggingLogSink", "sink", nil, nil, project),
+		},
+		{
+			name: "not a Config Connector resource",
+			resource: func() *unstructured.Unstructured {
+				obj := kccResource("StorageBucket", "my-bucket", nil, nil, nil)
+				obj.SetAPIVersion("example.com/v1")
+				return obj
+			}(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveAssetName(tt.resource); got != tt.want {
+				t.Errorf("resolveAssetName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_assetNameRules validates the service names and placeholders in the
+// rules table
+func Test_assetNameRules(t *testing.T) {
+	for kind, rule := range assetNameRules {
+		if rule.service == "" || rule.format == "" {
+			t.Errorf("incomplete rule for kind %s: %+v", kind, rule)
+		}
+		for _, placeholder := range placeholderRegexp.FindAllString(rule.format, -1) {
+			switch placeholder {
+			case "{project}", "{projectNumber}", "{location}", "{locationCollection}", "{region}", "{zone}", "{id}", "{uniqueId}":
+			default:
+				t.Errorf("unknown placeholder %s in rule for kind %s", placeholder, kind)
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/store"
+)
+
+// deactivationBreaker holds the deactivation of findings when a sync would
+// set a large share of the ACTIVE findings to INACTIVE. This can happen when
+// the sync sees no violations because Gatekeeper was uninstalled, or its
+// CRDs are briefly missing during an upgrade.
+type deactivationBreaker struct {
+	log logr.Logger
+	// maxDeactivations is the number of deactivations that never trip the breaker
+	maxDeactivations int
+	// maxPercent is the percentage of active findings that never trips the breaker
+	maxPercent int
+	// confirmations is the number of consecutive syncs that must trip the
+	// breaker before the deactivations are applied, zero means never
+	confirmations int
+	// tripped is the number of consecutive syncs that tripped the breaker,
+	// persisted in the snapshot if there is a store
+	tripped int
+}
+
+// load restores the number of consecutive syncs that tripped the breaker
+// from the snapshot, so one-off syncs can confirm deactivations
+func (b *deactivationBreaker) load(snapshot *store.Snapshot) {
+	if b == nil || snapshot == nil {
+		return
+	}
+	b.tripped = snapshot.HeldDeactivationSyncs
+}
+
+// save records the number of consecutive syncs that tripped the breaker in
+// the snapshot
+func (b *deactivationBreaker) save(snapshot *store.Snapshot) {
+	if b == nil || snapshot == nil {
+		return
+	}
+	snapshot.HeldDeactivationSyncs = b.tripped
+}
+
+// check implements securitycenter.DeactivationCheckFunc. It returns an error
+// if the deactivations exceed both the count and the percentage limits, until
+// the number of consecutive syncs that exceeded the limits reaches the number
+// of confirmations.
+func (b *deactivationBreaker) check(active, deactivations int) error {
+	if !b.exceeds(active, deactivations) {
+		b.tripped = 0
+		return nil
+	}
+	b.tripped++
+	if b.confirmations > 0 && b.tripped >= b.confirmations {
+		b.log.Info("applying deactivations above the limit after consecutive confirming syncs", "activeFindings", active, "deactivations", deactivations, "confirmations", b.tripped)
+		b.tripped = 0
+		return nil
+	}
+	if b.confirmations == 0 {
+		return fmt.Errorf("sync would deactivate %d of %d active findings, exceeding max-deactivations=%d and max-deactivation-percent=%d, holding deactivations until the limit is raised", deactivations, active, b.maxDeactivations, b.maxPercent)
+	}
+	return fmt.Errorf("sync would deactivate %d of %d active findings, exceeding max-deactivations=%d and max-deactivation-percent=%d, holding deactivations (%d of %d confirming syncs)", deactivations, active, b.maxDeactivations, b.maxPercent, b.tripped, b.confirmations)
+}
+
+// exceeds returns true if the deactivations are above both the count and the
+// percentage limits
+func (b *deactivationBreaker) exceeds(active, deactivations int) bool {
+	if deactivations <= b.maxDeactivations || active == 0 {
+		return false
+	}
+	return deactivations*100 > b.maxPercent*active
+}
+
+// SetDeactivationLimit holds the deactivation of findings when a sync would
+// set more than maxDeactivations findings and more than maxPercent percent of
+// the ACTIVE findings to INACTIVE. The held deactivations are applied when
+// the number of consecutive syncs that exceed the limit reaches
+// confirmations. Use zero confirmations to hold the deactivations until the
+// limit is raised, and a maxPercent of 100 to disable the limit.
+//
+// The number of consecutive syncs is kept in the snapshot if there is a
+// store, see SetStore, and in memory otherwise.
+func (c *Client) SetDeactivationLimit(maxDeactivations, maxPercent, confirmations int) error {
+	if maxDeactivations < 0 {
+		return fmt.Errorf("invalid maxDeactivations: %v", maxDeactivations)
+	}
+	if maxPercent < 0 || maxPercent > 100 {
+		return fmt.Errorf("invalid maxPercent: %v", maxPercent)
+	}
+	if confirmations < 0 {
+		return fmt.Errorf("invalid confirmations: %v", confirmations)
+	}
+	if maxPercent == 100 {
+		c.breaker = nil
+		c.securitycenterClient.SetDeactivationCheck(nil)
+		return nil
+	}
+	breaker := &deactivationBreaker{
+		log:              c.log.WithName("deactivation-limit"),
+		maxDeactivations: maxDeactivations,
+		maxPercent:       maxPercent,
+		confirmations:    confirmations,
+	}
+	c.breaker = breaker
+	c.securitycenterClient.SetDeactivationCheck(breaker.check)
+	return nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/store"
+)
+
+func Test_deactivationBreaker_check(t *testing.T) {
+	type call struct {
+		active        int
+		deactivations int
+		wantErr       bool
+	}
+	tests := []struct {
+		name          string
+		confirmations int
+		calls         []call
+	}{
+		{
+			name:          "below count limit",
+			confirmations: 3,
+			calls:         []call{{active: 12, deactivations: 10, wantErr: false}},
+		},
+		{
+			name:          "below percentage limit",
+			confirmations: 3,
+			calls:         []call{{active: 100, deactivations: 50, wantErr: false}},
+		},
+		{
+			name:          "no active findings",
+			confirmations: 3,
+			calls:         []call{{active: 0, deactivations: 0, wantErr: false}},
+		},
+		{
+			name:          "applied after confirmations",
+			confirmations: 3,
+			calls: []call{
+				{active: 100, deactivations: 100, wantErr: true},
+				{active: 100, deactivations: 100, wantErr: true},
+				{active: 100, deactivations: 100, wantErr: false},
+				{active: 100, deactivations: 100, wantErr: true},
+			},
+		},
+		{
+			name:          "confirmations reset by sync below limit",
+			confirmations: 2,
+			calls: []call{
+				{active: 100, deactivations: 100, wantErr: true},
+				{active: 100, deactivations: 1, wantErr: false},
+				{active: 100, deactivations: 100, wantErr: true},
+				{active: 100, deactivations: 100, wantErr: false},
+			},
+		},
+		{
+			name:          "held until limit is raised",
+			confirmations: 0,
+			calls: []call{
+				{active: 100, deactivations: 100, wantErr: true},
+				{active: 100, deactivations: 100, wantErr: true},
+				{active: 100, deactivations: 100, wantErr: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := &deactivationBreaker{
+				log:              testr.New(t),
+				maxDeactivations: 10,
+				maxPercent:       50,
+				confirmations:    tt.confirmations,
+			}
+			for i, c := range tt.calls {
+				err := breaker.check(c.active, c.deactivations)
+				if (err != nil) != c.wantErr {
+					t.Errorf("call %d: check(%d, %d) error = %v, wantErr %v", i, c.active, c.deactivations, err, c.wantErr)
+				}
+			}
+		})
+	}
+}
+
+// Test_deactivationBreaker_snapshot simulates one-off syncs, where each sync
+// creates a new breaker that loads the count from the snapshot
+func Test_deactivationBreaker_snapshot(t *testing.T) {
+	snapshot := &store.Snapshot{}
+	for i, wantErr := range []bool{true, true, false} {
+		breaker := &deactivationBreaker{
+			log:              testr.New(t),
+			maxDeactivations: 10,
+			maxPercent:       50,
+			confirmations:    3,
+		}
+		breaker.load(snapshot)
+		err := breaker.check(100, 100)
+		if (err != nil) != wantErr {
+			t.Errorf("sync %d: check() error = %v, wantErr %v", i, err, wantErr)
+		}
+		breaker.save(snapshot)
+	}
+	if snapshot.HeldDeactivationSyncs != 0 {
+		t.Errorf("HeldDeactivationSyncs = %d after applying deactivations, want 0", snapshot.HeldDeactivationSyncs)
+	}
+	// the breaker is nil when the limit is disabled
+	var breaker *deactivationBreaker
+	breaker.load(snapshot)
+	breaker.save(snapshot)
+}
@@ -0,0 +1,252 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Condition types in the status of the config resource
+const (
+	ConditionReady         = "Ready"
+	ConditionConfigApplied = "ConfigApplied"
+)
+
+// maxConditionMessageLength truncates long error messages in conditions
+const maxConditionMessageLength = 1024
+
+var (
+	// ConfigResourceGVR is the GatekeeperSecurityCenterConfig custom resource
+	ConfigResourceGVR = schema.GroupVersionResource{
+		Group:    "gatekeeper-securitycenter.cloud.google.com",
+		Version:  "v1alpha1",
+		Resource: "gatekeepersecuritycenterconfigs",
+	}
+
+	sourceNameRegexp = regexp.MustCompile("^organizations/[0-9]+/sources/[0-9]+$")
+)
+
+// ConfigResourceSpec is the spec of the GatekeeperSecurityCenterConfig
+// custom resource. Empty fields keep the values from the command line.
+type ConfigResourceSpec struct {
+	Source   string                 `json:"source,omitempty"`
+	Cluster  string                 `json:"cluster,omitempty"`
+	Interval int                    `json:"interval,omitempty"`
+	Filters  *ConfigResourceFilters `json:"filters,omitempty"`
+}
+
+// ConfigResourceFilters are the filters in the spec of the custom resource
+type ConfigResourceFilters struct {
+	ConstraintKinds    []string `json:"constraintKinds,omitempty"`
+	ExcludedNamespaces []string `json:"excludedNamespaces,omitempty"`
+}
+
+// ConfigResourceStatus is the status of the GatekeeperSecurityCenterConfig
+// custom resource
+type ConfigResourceStatus struct {
+	ObservedGeneration int64              `json:"observedGeneration,omitempty"`
+	LastSyncTime       *metav1.Time       `json:"lastSyncTime,omitempty"`
+	Result             string             `json:"result,omitempty"`
+	Findings           int64              `json:"findings"`
+	Created            int64              `json:"created"`
+	Activated          int64              `json:"activated"`
+	Deactivated        int64              `json:"deactivated"`
+	Unresolved         int64              `json:"unresolved"`
+	Conditions         []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// configResourceDefaults are the client settings from the command line,
+// used for fields that are empty in the spec
+type configResourceDefaults struct {
+	source  string
+	cluster string
+	filters Filters
+}
+
+// SetConfigResource enables reading the sync configuration from the
+// cluster-scoped GatekeeperSecurityCenterConfig resource with the provided
+// name. Sync applies the spec before each sync, and records the result in
+// the status.
+func (c *Client) SetConfigResource(name string) {
+	c.configResource = name
+	c.configResourceDefaults = configResourceDefaults{
+		source:  c.source,
+		cluster: c.cluster,
+		filters: c.filters,
+	}
+}
+
+// ConfigResourceInterval returns the interval in seconds from the spec of
+// the config resource. Returns 0 if there is no config resource, or if the
+// spec doesn't set the interval.
+func (c *Client) ConfigResourceInterval() int {
+	if c.configResourceSpec == nil {
+		return 0
+	}
+	return c.configResourceSpec.Interval
+}
+
+// applyConfigResource reads the spec of the config resource and applies it
+// to the client. If the spec can't be read or is invalid, the client keeps
+// the previous settings.
+func (c *Client) applyConfigResource(ctx context.Context) {
+	if c.configResource == "" {
+		return
+	}
+	obj, err := c.dynamicClient.GetResource(ctx, ConfigResourceGVR, c.configResource, "")
+	if err != nil {
+		c.log.Error(err, "could not get config resource, using previous settings", "name", c.configResource)
+		c.configResourceErr = err
+		return
+	}
+	spec, err := parseConfigResourceSpec(obj)
+	if err != nil {
+		c.log.Error(err, "invalid config resource, using previous settings", "name", c.configResource)
+		c.configResourceErr = err
+		return
+	}
+	c.configResourceErr = nil
+	c.configResourceGeneration = obj.GetGeneration()
+	c.configResourceSpec = spec
+	c.source = c.configResourceDefaults.source
+	if spec.Source != "" {
+		c.source = spec.Source
+	}
+	c.cluster = c.configResourceDefaults.cluster
+	if spec.Cluster != "" {
+		c.cluster = spec.Cluster
+	}
+	c.filters = c.configResourceDefaults.filters
+	if spec.Filters != nil {
+		c.filters = Filters{
+			ConstraintKinds:    spec.Filters.ConstraintKinds,
+			ExcludedNamespaces: spec.Filters.ExcludedNamespaces,
+		}
+	}
+}
+
+// parseConfigResourceSpec converts and validates the spec of the config
+// resource
+func parseConfigResourceSpec(obj *unstructured.Unstructured) (*ConfigResourceSpec, error) {
+	spec := &ConfigResourceSpec{}
+	specMap, _, err := unstructured.NestedMap(obj.UnstructuredContent(), "spec")
+	if err != nil {
+		return nil, fmt.Errorf("could not read spec: %w", err)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(specMap, spec); err != nil {
+		return nil, fmt.Errorf("could not convert spec: %w", err)
+	}
+	if spec.Source != "" && !sourceNameRegexp.MatchString(spec.Source) {
+		return nil, fmt.Errorf("invalid source name: [%v]", spec.Source)
+	}
+	if spec.Interval < 0 {
+		return nil, fmt.Errorf("invalid interval: [%v]", spec.Interval)
+	}
+	return spec, nil
+}
+
+// updateConfigResourceStatus records the sync result in the status of the
+// config resource. Errors are logged, they don't fail the sync.
+func (c *Client) updateConfigResourceStatus(ctx context.Context, result *Result) {
+	if c.configResource == "" || c.dryRun {
+		return
+	}
+	obj, err := c.dynamicClient.GetResource(ctx, ConfigResourceGVR, c.configResource, "")
+	if err != nil {
+		c.log.Error(err, "could not get config resource to update status", "name", c.configResource)
+		return
+	}
+	status := &ConfigResourceStatus{}
+	if statusMap, exists, _ := unstructured.NestedMap(obj.UnstructuredContent(), "status"); exists {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(statusMap, status); err != nil {
+			c.log.V(1).Info("could not convert existing status, replacing it", "error", err.Error())
+			status = &ConfigResourceStatus{}
+		}
+	}
+	setConfigResourceStatus(status, c.configResourceGeneration, result, c.configResourceErr)
+	statusMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(status)
+	if err != nil {
+		c.log.Error(err, "could not convert status")
+		return
+	}
+	if err := unstructured.SetNestedMap(obj.Object, statusMap, "status"); err != nil {
+		c.log.Error(err, "could not set status")
+		return
+	}
+	if _, err := c.dynamicClient.UpdateResourceStatus(ctx, ConfigResourceGVR, obj); err != nil {
+		c.log.Error(err, "could not update config resource status", "name", c.configResource)
+	}
+}
+
+// setConfigResourceStatus updates the status fields and conditions from the
+// sync result and the outcome of applying the spec
+func setConfigResourceStatus(status *ConfigResourceStatus, generation int64, result *Result, specErr error) {
+	lastSyncTime := metav1.NewTime(result.Time)
+	status.ObservedGeneration = generation
+	status.LastSyncTime = &lastSyncTime
+	status.Result = result.Result
+	status.Findings = int64(result.Findings)
+	status.Created = int64(result.Created)
+	status.Activated = int64(result.Activated)
+	status.Deactivated = int64(result.Deactivated)
+	status.Unresolved = int64(result.Unresolved)
+
+	ready := metav1.Condition{
+		Type:               ConditionReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             result.Result,
+		Message:            fmt.Sprintf("synced %d findings", result.Findings),
+	}
+	if result.Unresolved > 0 {
+		ready.Message = fmt.Sprintf("synced %d findings, %d unresolved constraints or violations", result.Findings, result.Unresolved)
+	}
+	if result.Err != nil {
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = "SyncFailed"
+		ready.Message = truncate(result.Err.Error(), maxConditionMessageLength)
+	}
+	meta.SetStatusCondition(&status.Conditions, ready)
+
+	applied := metav1.Condition{
+		Type:               ConditionConfigApplied,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             "Applied",
+		Message:            "spec applied",
+	}
+	if specErr != nil {
+		applied.Status = metav1.ConditionFalse
+		applied.Reason = "Invalid"
+		applied.Message = truncate(specErr.Error(), maxConditionMessageLength)
+	}
+	meta.SetStatusCondition(&status.Conditions, applied)
+}
+
+// truncate s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,189 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+func Test_parseConfigResourceSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    map[string]interface{}
+		want    *ConfigResourceSpec
+		wantErr bool
+	}{
+		{
+			name: "no spec",
+			want: &ConfigResourceSpec{},
+		},
+		{
+			name: "all fields",
+			spec: map[string]interface{}{
+				"source":   "organizations/123/sources/456",
+				"cluster":  "my-cluster",
+				"interval": int64(60),
+				"filters": map[string]interface{}{
+					"constraintKinds":    []interface{}{"K8sRequiredLabels"},
+					"excludedNamespaces": []interface{}{"kube-system"},
+				},
+			},
+			want: &ConfigResourceSpec{
+				Source:   "organizations/123/sources/456",
+				Cluster:  "my-cluster",
+				Interval: 60,
+				Filters: &ConfigResourceFilters{
+					ConstraintKinds:    []string{"K8sRequiredLabels"},
+					ExcludedNamespaces: []string{"kube-system"},
+				},
+			},
+		},
+		{
+			name: "invalid source",
+			spec: map[string]interface{}{
+				"source": "projects/123/sources/456",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative interval",
+			spec: map[string]interface{}{
+				"interval": int64(-1),
+			},
+			wantErr: true,
+		},
+		{
+			name: "wrong type",
+			spec: map[string]interface{}{
+				"interval": "often",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			if tt.spec != nil {
+				obj.Object["spec"] = tt.spec
+			}
+			got, err := parseConfigResourceSpec(obj)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseConfigResourceSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseConfigResourceSpec() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_setConfigResourceStatus(t *testing.T) {
+	syncTime := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		result      *Result
+		specErr     error
+		wantReady   metav1.ConditionStatus
+		wantApplied metav1.ConditionStatus
+		wantMessage string
+	}{
+		{
+			name:        "succeeded",
+			result:      &Result{Time: syncTime, Result: ResultSucceeded, Findings: 3, Created: 1},
+			wantReady:   metav1.ConditionTrue,
+			wantApplied: metav1.ConditionTrue,
+			wantMessage: "synced 3 findings",
+		},
+		{
+			name:        "failed",
+			result:      &Result{Time: syncTime, Result: ResultFailed, Err: errors.New("permission denied")},
+			wantReady:   metav1.ConditionFalse,
+			wantApplied: metav1.ConditionTrue,
+			wantMessage: "permission denied",
+		},
+		{
+			name:        "invalid spec",
+			result:      &Result{Time: syncTime, Result: ResultUnchanged, Findings: 2},
+			specErr:     errors.New("invalid source name"),
+			wantReady:   metav1.ConditionTrue,
+			wantApplied: metav1.ConditionFalse,
+			wantMessage: "synced 2 findings",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := &ConfigResourceStatus{}
+			setConfigResourceStatus(status, 2, tt.result, tt.specErr)
+			if status.Result != tt.result.Result || status.Findings != int64(tt.result.Findings) || status.Created != int64(tt.result.Created) {
+				t.Errorf("setConfigResourceStatus() status = %+v, want values from %+v", status, tt.result)
+			}
+			if !status.LastSyncTime.Time.Equal(syncTime) || status.ObservedGeneration != 2 {
+				t.Errorf("setConfigResourceStatus() lastSyncTime = %v, observedGeneration = %v", status.LastSyncTime, status.ObservedGeneration)
+			}
+			ready := meta.FindStatusCondition(status.Conditions, ConditionReady)
+			if ready == nil || ready.Status != tt.wantReady || ready.Message != tt.wantMessage {
+				t.Errorf("setConfigResourceStatus() Ready condition = %+v, want status %v message %q", ready, tt.wantReady, tt.wantMessage)
+			}
+			applied := meta.FindStatusCondition(status.Conditions, ConditionConfigApplied)
+			if applied == nil || applied.Status != tt.wantApplied {
+				t.Errorf("setConfigResourceStatus() ConfigApplied condition = %+v, want status %v", applied, tt.wantApplied)
+			}
+		})
+	}
+}
+
+func Test_newResult(t *testing.T) {
+	transitions := []*securitycenter.Transition{
+		{OldState: securitycenterpb.Finding_STATE_UNSPECIFIED, NewState: securitycenterpb.Finding_ACTIVE},
+		{OldState: securitycenterpb.Finding_STATE_UNSPECIFIED, NewState: securitycenterpb.Finding_ACTIVE},
+		{OldState: securitycenterpb.Finding_INACTIVE, NewState: securitycenterpb.Finding_ACTIVE},
+		{OldState: securitycenterpb.Finding_ACTIVE, NewState: securitycenterpb.Finding_INACTIVE},
+	}
+	got := newResult(time.Time{}, 5, transitions, nil)
+	want := &Result{Result: ResultSucceeded, Findings: 5, Created: 2, Activated: 1, Deactivated: 1}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("newResult() mismatch (-want +got):\n%s", diff)
+	}
+	if got := newResult(time.Time{}, 0, nil, errors.New("failed")); got.Result != ResultFailed {
+		t.Errorf("newResult() with error Result = %v, want %v", got.Result, ResultFailed)
+	}
+}
+
+func Test_Filters(t *testing.T) {
+	filters := Filters{
+		ConstraintKinds:    []string{"K8sRequiredLabels"},
+		ExcludedNamespaces: []string{"kube-system"},
+	}
+	if !filters.includesConstraintKind("K8sRequiredLabels") || filters.includesConstraintKind("K8sAllowedRepos") {
+		t.Errorf("includesConstraintKind() with filters %+v", filters)
+	}
+	if filters.includesNamespace("kube-system") || !filters.includesNamespace("default") || !filters.includesNamespace("") {
+		t.Errorf("includesNamespace() with filters %+v", filters)
+	}
+	empty := Filters{}
+	if !empty.includesConstraintKind("K8sAllowedRepos") || !empty.includesNamespace("kube-system") {
+		t.Errorf("empty filters should include everything")
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+// Verdicts of an Explanation
+const (
+	// VerdictCurrent means that the violation still exists, and the finding
+	// ID is the same as the ID the controller would create for it now.
+	VerdictCurrent = "CURRENT"
+	// VerdictSuperseded means that the violation still exists, but the
+	// controller would create it with a different finding ID now.
+	VerdictSuperseded = "SUPERSEDED"
+	// VerdictResolved means that the violation no longer exists.
+	VerdictResolved = "RESOLVED"
+)
+
+// Explanation links a finding to the Kubernetes objects that caused it
+type Explanation struct {
+	FindingName        string        `json:"findingName"`
+	FindingState       string        `json:"findingState"`
+	Constraint         *ObjectStatus `json:"constraint"`
+	ConstraintTemplate *ObjectStatus `json:"constraintTemplate"`
+	Resource           *ObjectStatus `json:"resource"`
+	Audit              *AuditStatus  `json:"audit,omitempty"`
+	// CurrentFindingID is the finding ID the controller would create for the
+	// violation now. Empty if the objects could not be found.
+	CurrentFindingID string `json:"currentFindingId,omitempty"`
+	// FindingIDStrategy used to determine the CurrentFindingID
+	FindingIDStrategy string `json:"findingIdStrategy"`
+	Verdict           string `json:"verdict"`
+	Reason            string `json:"reason"`
+}
+
+// ObjectStatus describes a Kubernetes object referenced by a finding
+type ObjectStatus struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// UID from the finding source properties
+	UID string `json:"uid"`
+	// CurrentUID of the object in the cluster, empty if not found
+	CurrentUID string `json:"currentUid,omitempty"`
+	Found      bool   `json:"found"`
+	Error      string `json:"error,omitempty"`
+}
+
+// AuditStatus of the constraint from the last Gatekeeper audit
+type AuditStatus struct {
+	AuditTimestamp  string `json:"auditTimestamp"`
+	TotalViolations int64  `json:"totalViolations"`
+	// ViolationListed is true if the resource is in the constraint status.violations
+	ViolationListed  bool   `json:"violationListed"`
+	ViolationMessage string `json:"violationMessage,omitempty"`
+	// ViolationsTruncated is true if Gatekeeper reported fewer violations
+	// than the total, see the audit --constraint-violations-limit flag
+	ViolationsTruncated bool `json:"violationsTruncated"`
+}
+
+// ExplainFinding fetches the finding with the provided full name and resolves
+// the constraint, constraint template, and resource that caused it. It then
+// determines if the finding is still current, if it has been superseded by a
+// finding with a different ID because of a spec change, or if the violation
+// no longer exists.
+func (c *Client) ExplainFinding(ctx context.Context, findingName string) (*Explanation, error) {
+	finding, err := c.securitycenterClient.GetFinding(ctx, findingName)
+	if err != nil {
+		return nil, err
+	}
+	if finding.GetCategory() == PolicyHealthCategory {
+		return nil, fmt.Errorf("finding %s is a %s finding, not a violation: %s", findingName, PolicyHealthCategory, property(finding, "Explanation"))
+	}
+	if securitycenter.FromPolicyReport(finding) {
+		return nil, fmt.Errorf("finding %s is from a policy report, not a Gatekeeper audit violation: %s", findingName, property(finding, "Explanation"))
+	}
+	if finding.GetCategory() == AdmissionCategory {
+		return nil, fmt.Errorf("finding %s is an %s finding, not an audit violation: %s", findingName, AdmissionCategory, property(finding, "Explanation"))
+	}
+	if len(affectedObjects(finding)) > 0 && !c.aggregatesViolations() {
+		return nil, fmt.Errorf("finding %s aggregates %s violations, use the same finding granularity and owner aggregation settings as the controller", findingName, property(finding, securitycenter.AffectedObjectCountProperty))
+	}
+	kindToGVR, err := c.discoveryClient.CreateKindToGVRMap()
+	if err != nil {
+		return nil, err
+	}
+	explanation := newExplanation(finding)
+	explanation.FindingIDStrategy = c.findingIDStrategy
+
+	constraint, err := c.getObjectForFinding(ctx, property(finding, "ConstraintSelfLink"), explanation.Constraint, kindToGVR)
+	explanation.Constraint.setObject(constraint, err)
+	var template *unstructured.Unstructured
+	if templateSelfLink := property(finding, "ConstraintTemplateSelfLink"); parseable(templateSelfLink) {
+		template, err = c.getObjectForFinding(ctx, templateSelfLink, explanation.ConstraintTemplate, kindToGVR)
+	} else {
+		template, err = c.dynamicClient.GetConstraintTemplate(ctx, finding.Category)
+	}
+	explanation.ConstraintTemplate.setObject(template, err)
+
+	if constraint != nil {
+		explanation.Audit = getAuditStatus(constraint, explanation.Resource, affectedObjects(finding))
+		violation := map[string]interface{}{
+			"kind":      explanation.Resource.Kind,
+			"name":      explanation.Resource.Name,
+			"namespace": explanation.Resource.Namespace,
+		}
+		resource, err := c.getResource(ctx, violation, kindToGVR)
+		if err != nil {
+			explanation.Resource.Error = err.Error()
+		} else {
+			explanation.Resource.Found = true
+			explanation.Resource.CurrentUID = string(resource.UID)
+			if c.aggregatesViolations() {
+				resource = c.currentAggregate(ctx, constraint, explanation.Resource, kindToGVR)
+				explanation.Audit.ViolationListed = resource != nil
+				explanation.Audit.ViolationMessage = ""
+				if resource != nil {
+					explanation.Audit.ViolationMessage = resource.Message
+				}
+			}
+			constraintInfo, err := c.getConstraint(ctx, constraint)
+			switch {
+			case err != nil:
+				explanation.Constraint.Error = err.Error()
+			case resource != nil:
+				explanation.CurrentFindingID = c.findingID(property(finding, "Cluster"), constraintInfo, resource)
+			}
+		}
+	}
+	explanation.Verdict, explanation.Reason = explanation.determineVerdict(findingIDFromName(finding.Name))
+	return explanation, nil
+}
+
+// currentAggregate returns the resource that the controller would create
+// now for the aggregated violations of the constraint that are reported for
+// the object, see SetAggregateOwners and SetFindingGranularity. Returns nil
+// if none of the current violations of the constraint are aggregated for
+// the object.
+func (c *Client) currentAggregate(ctx context.Context, constraint *unstructured.Unstructured, object *ObjectStatus, kindToGVR map[string][]schema.GroupVersionResource) *Resource {
+	unresolved := newUnresolvedViolations()
+	resources := c.getViolatingResourcesForConstraint(ctx, constraint, kindToGVR, unresolved, map[types.UID]*unstructured.Unstructured{})
+	for _, resource := range c.aggregateResources(ctx, constraint, resources, kindToGVR, unresolved) {
+		if resource.GVK.Kind == object.Kind && resource.Namespace == object.Namespace && resource.Name == object.Name {
+			return resource
+		}
+	}
+	return nil
+}
+
+// getObjectForFinding gets the object using the self link from the finding
+// source properties if possible, otherwise by kind, name, and namespace.
+func (c *Client) getObjectForFinding(ctx context.Context, selfLink string, object *ObjectStatus, kindToGVR map[string][]schema.GroupVersionResource) (*unstructured.Unstructured, error) {
+	if gvr, namespace, name, ok := parseSelfLink(selfLink); ok {
+		c.log.V(1).Info("getting object using self link", "selfLink", selfLink)
+		return c.dynamicClient.GetResource(ctx, gvr, name, namespace)
+	}
+	return c.dynamicClient.GetResourceByKind(ctx, object.Kind, object.Name, object.Namespace, kindToGVR)
+}
+
+// newExplanation creates an Explanation with values from the finding
+func newExplanation(finding *securitycenterpb.Finding) *Explanation {
+	return &Explanation{
+		FindingName:  finding.Name,
+		FindingState: finding.State.String(),
+		Constraint: &ObjectStatus{
+			Kind: finding.Category,
+			Name: property(finding, "ConstraintName"),
+			UID:  property(finding, "ConstraintUID"),
+		},
+		ConstraintTemplate: &ObjectStatus{
+			Kind: "ConstraintTemplate",
+			Name: strings.ToLower(finding.Category),
+			UID:  property(finding, "ConstraintTemplateUID"),
+		},
+		Resource: &ObjectStatus{
+			Kind:      property(finding, "ResourceKind"),
+			Namespace: property(finding, "ResourceNamespace"),
+			Name:      property(finding, "ResourceName"),
+			UID:       property(finding, "ResourceUID"),
+		},
+	}
+}
+
+// setObject records the result of looking up the object
+func (o *ObjectStatus) setObject(obj *unstructured.Unstructured, err error) {
+	if err != nil {
+		o.Error = err.Error()
+		return
+	}
+	if obj == nil {
+		return
+	}
+	o.Found = true
+	o.CurrentUID = string(obj.GetUID())
+}
+
+// affectedObjects returns the objects listed in the affected objects source
+// property of a finding that aggregates violations, in the format of
+// ObjectReference.String(). The last object can be incomplete because the
+// property is truncated.
+func affectedObjects(finding *securitycenterpb.Finding) []string {
+	affected := property(finding, securitycenter.AffectedObjectsProperty)
+	if affected == "" {
+		return nil
+	}
+	return strings.Split(affected, ",")
+}
+
+// getAuditStatus returns the audit status of the constraint for the
+// resource. For findings that aggregate violations, the violation is listed
+// if any of the affected objects is in the constraint status.violations.
+func getAuditStatus(constraint *unstructured.Unstructured, resource *ObjectStatus, affected []string) *AuditStatus {
+	content := constraint.UnstructuredContent()
+	auditTimestamp, _, _ := unstructured.NestedString(content, "status", "auditTimestamp")
+	totalViolations, _, _ := unstructured.NestedInt64(content, "status", "totalViolations")
+	violations, _, _ := unstructured.NestedSlice(content, "status", "violations")
+	status := &AuditStatus{
+		AuditTimestamp:      auditTimestamp,
+		TotalViolations:     totalViolations,
+		ViolationsTruncated: totalViolations > int64(len(violations)),
+	}
+	isAffected := map[string]bool{}
+	for _, ref := range affected {
+		isAffected[ref] = true
+	}
+	for _, rawViolation := range violations {
+		violation, ok := rawViolation.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		kind, _, _ := unstructured.NestedString(violation, "kind")
+		name, _, _ := unstructured.NestedString(violation, "name")
+		namespace, _, _ := unstructured.NestedString(violation, "namespace")
+		ref := ObjectReference{GVK: schema.GroupVersionKind{Kind: kind}, Namespace: namespace, Name: name}
+		if kind == resource.Kind && name == resource.Name && namespace == resource.Namespace || isAffected[ref.String()] {
+			status.ViolationListed = true
+			status.ViolationMessage, _, _ = unstructured.NestedString(violation, "message")
+			break
+		}
+	}
+	return status
+}
+
+// determineVerdict returns the verdict and the reason for it
+func (e *Explanation) determineVerdict(findingID string) (string, string) {
+	switch {
+	case !e.Constraint.Found:
+		return VerdictResolved, "the constraint no longer exists"
+	case e.Constraint.UID != e.Constraint.CurrentUID:
+		return VerdictResolved, "the constraint was deleted and created again"
+	case !e.Resource.Found:
+		return VerdictResolved, "the resource no longer exists"
+	case e.Resource.UID != e.Resource.CurrentUID && e.FindingIDStrategy != FindingIDStrategyStable:
+		return VerdictResolved, "the resource was deleted and created again"
+	case e.Audit != nil && !e.Audit.ViolationListed && e.Audit.ViolationsTruncated:
+		return VerdictResolved, "the resource is not in the audit violations of the constraint, but Gatekeeper reported fewer violations than the total"
+	case e.Audit != nil && !e.Audit.ViolationListed:
+		return VerdictResolved, "the resource is not in the audit violations of the constraint"
+	case e.CurrentFindingID != findingID && e.FindingIDStrategy == FindingIDStrategyStable:
+		return VerdictSuperseded, fmt.Sprintf("the finding was created using a different finding ID strategy, the violation is now finding ID %s", e.CurrentFindingID)
+	case e.CurrentFindingID != findingID:
+		return VerdictSuperseded, fmt.Sprintf("the spec of the constraint, constraint template, or resource changed, the violation is now finding ID %s", e.CurrentFindingID)
+	default:
+		return VerdictCurrent, "the resource violates the constraint, and the specs haven't changed since the finding was created"
+	}
+}
+
+// parseable returns true if the self link contains a path that can be parsed
+func parseable(selfLink string) bool {
+	_, _, _, ok := parseSelfLink(selfLink)
+	return ok
+}
+
+// parseSelfLink returns the GVR, namespace, and name from a Kubernetes object
+// link, such as `https://[apiserver]/apis/[group]/[version]/namespaces/[namespace]/[resource]/[name]`.
+// The link is empty, or contains only the API server host, for clusters
+// that don't set the deprecated metadata.selfLink field.
+func parseSelfLink(selfLink string) (schema.GroupVersionResource, string, string, bool) {
+	var gvr schema.GroupVersionResource
+	u, err := url.Parse(selfLink)
+	if err != nil {
+		return gvr, "", "", false
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case len(segments) >= 2 && segments[0] == "api":
+		gvr.Version = segments[1]
+		segments = segments[2:]
+	case len(segments) >= 3 && segments[0] == "apis":
+		gvr.Group = segments[1]
+		gvr.Version = segments[2]
+		segments = segments[3:]
+	default:
+		return gvr, "", "", false
+	}
+	var namespace string
+	if len(segments) == 4 && segments[0] == "namespaces" {
+		namespace = segments[1]
+		segments = segments[2:]
+	}
+	if len(segments) != 2 {
+		return gvr, "", "", false
+	}
+	gvr.Resource = segments[0]
+	return gvr, namespace, segments[1], true
+}
+
+// property returns a string source property of the finding
+func property(finding *securitycenterpb.Finding, key string) string {
+	return finding.GetSourceProperties()[key].GetStringValue()
+}
+
+// findingIDFromName returns the last segment of the full finding name
+func findingIDFromName(findingName string) string {
+	return findingName[strings.LastIndex(findingName, "/")+1:]
+}
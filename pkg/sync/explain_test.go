@@ -0,0 +1,221 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func Test_parseSelfLink(t *testing.T) {
+	tests := []struct {
+		name          string
+		selfLink      string
+		wantGVR       schema.GroupVersionResource
+		wantNamespace string
+		wantName      string
+		wantOK        bool
+	}{
+		{
+			name:     "cluster-scoped constraint",
+			selfLink: host + "/apis/constraints.gatekeeper.sh/v1beta1/k8srequiredlabels/ns-must-have-geo",
+			wantGVR: schema.GroupVersionResource{
+				Group:    "constraints.gatekeeper.sh",
+				Version:  "v1beta1",
+				Resource: "k8srequiredlabels",
+			},
+			wantName: "ns-must-have-geo",
+			wantOK:   true,
+		},
+		{
+			name:     "namespaced core resource",
+			selfLink: host + "/api/v1/namespaces/default/pods/nginx",
+			wantGVR: schema.GroupVersionResource{
+				Version:  "v1",
+				Resource: "pods",
+			},
+			wantNamespace: "default",
+			wantName:      "nginx",
+			wantOK:        true,
+		},
+		{
+			name:     "host only",
+			selfLink: host,
+		},
+		{
+			name: "empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotGVR, gotNamespace, gotName, gotOK := parseSelfLink(tt.selfLink)
+			if gotOK != tt.wantOK {
+				t.Fatalf("parseSelfLink() ok = %v, want %v", gotOK, tt.wantOK)
+			}
+			if !tt.wantOK {
+				return
+			}
+			if gotGVR != tt.wantGVR || gotNamespace != tt.wantNamespace || gotName != tt.wantName {
+				t.Errorf("parseSelfLink() = %v, %q, %q, want %v, %q, %q", gotGVR, gotNamespace, gotName, tt.wantGVR, tt.wantNamespace, tt.wantName)
+			}
+		})
+	}
+}
+
+func Test_getAuditStatus(t *testing.T) {
+	constraint := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"status": map[string]interface{}{
+				"auditTimestamp":  "2021-03-01T02:03:04Z",
+				"totalViolations": int64(2),
+				"violations": []interface{}{
+					map[string]interface{}{
+						"kind":    "Namespace",
+						"name":    "default",
+						"message": "you must provide labels: {\"geo\"}",
+					},
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name     string
+		resource *ObjectStatus
+		affected []string
+		want     *AuditStatus
+	}{
+		{
+			name:     "violation listed",
+			resource: &ObjectStatus{Kind: "Namespace", Name: "default"},
+			want: &AuditStatus{
+				AuditTimestamp:      "2021-03-01T02:03:04Z",
+				TotalViolations:     2,
+				ViolationListed:     true,
+				ViolationMessage:    "you must provide labels: {\"geo\"}",
+				ViolationsTruncated: true,
+			},
+		},
+		{
+			name:     "affected object of aggregated finding listed",
+			resource: &ObjectStatus{Kind: "K8sRequiredLabels", Name: "ns-must-have-geo"},
+			affected: []string{"Namespace/kube-system", "Namespace/default"},
+			want: &AuditStatus{
+				AuditTimestamp:      "2021-03-01T02:03:04Z",
+				TotalViolations:     2,
+				ViolationListed:     true,
+				ViolationMessage:    "you must provide labels: {\"geo\"}",
+				ViolationsTruncated: true,
+			},
+		},
+		{
+			name:     "violation not listed",
+			resource: &ObjectStatus{Kind: "Namespace", Name: "kube-system"},
+			want: &AuditStatus{
+				AuditTimestamp:      "2021-03-01T02:03:04Z",
+				TotalViolations:     2,
+				ViolationsTruncated: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getAuditStatus(constraint, tt.resource, tt.affected)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("getAuditStatus() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExplanation_determineVerdict(t *testing.T) {
+	found := func(uid string) *ObjectStatus {
+		return &ObjectStatus{UID: uid, CurrentUID: uid, Found: true}
+	}
+	tests := []struct {
+		name        string
+		explanation *Explanation
+		want        string
+	}{
+		{
+			name: "current",
+			explanation: &Explanation{
+				Constraint:       found("c"),
+				Resource:         found("r"),
+				Audit:            &AuditStatus{ViolationListed: true},
+				CurrentFindingID: "id",
+			},
+			want: VerdictCurrent,
+		},
+		{
+			name: "superseded",
+			explanation: &Explanation{
+				Constraint:       found("c"),
+				Resource:         found("r"),
+				Audit:            &AuditStatus{ViolationListed: true},
+				CurrentFindingID: "other",
+			},
+			want: VerdictSuperseded,
+		},
+		{
+			name: "constraint not found",
+			explanation: &Explanation{
+				Constraint: &ObjectStatus{UID: "c"},
+				Resource:   found("r"),
+			},
+			want: VerdictResolved,
+		},
+		{
+			name: "resource recreated",
+			explanation: &Explanation{
+				Constraint: found("c"),
+				Resource:   &ObjectStatus{UID: "r", CurrentUID: "r2", Found: true},
+				Audit:      &AuditStatus{ViolationListed: true},
+			},
+			want: VerdictResolved,
+		},
+		{
+			name: "resource recreated with stable finding ID",
+			explanation: &Explanation{
+				Constraint:        found("c"),
+				Resource:          &ObjectStatus{UID: "r", CurrentUID: "r2", Found: true},
+				Audit:             &AuditStatus{ViolationListed: true},
+				CurrentFindingID:  "id",
+				FindingIDStrategy: FindingIDStrategyStable,
+			},
+			want: VerdictCurrent,
+		},
+		{
+			name: "violation not listed",
+			explanation: &Explanation{
+				Constraint:       found("c"),
+				Resource:         found("r"),
+				Audit:            &AuditStatus{},
+				CurrentFindingID: "id",
+			},
+			want: VerdictResolved,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := tt.explanation.determineVerdict("id")
+			if got != tt.want {
+				t.Errorf("determineVerdict() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
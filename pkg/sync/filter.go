@@ -0,0 +1,54 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+// Filters limit the audit violations that become findings
+type Filters struct {
+	// ConstraintKinds to include, all kinds if empty
+	ConstraintKinds []string
+	// ExcludedNamespaces are namespaces whose violating resources are skipped
+	ExcludedNamespaces []string
+}
+
+// SetFilters replaces the filters applied to audit violations
+func (c *Client) SetFilters(filters Filters) {
+	c.filters = filters
+}
+
+// includesConstraintKind returns true if violations of constraints with the
+// provided kind should become findings
+func (f *Filters) includesConstraintKind(kind string) bool {
+	if len(f.ConstraintKinds) == 0 {
+		return true
+	}
+	for _, k := range f.ConstraintKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// includesNamespace returns true if violating resources in the provided
+// namespace should become findings. Cluster-scoped resources are always
+// included.
+func (f *Filters) includesNamespace(namespace string) bool {
+	for _, excluded := range f.ExcludedNamespaces {
+		if excluded != "" && excluded == namespace {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+)
+
+// Finding ID strategies, see SetFindingIDStrategy
+const (
+	// FindingIDStrategySpec creates a new finding when the constraint, the
+	// constraint template, or the resource changes, see determineFindingID
+	FindingIDStrategySpec = "spec"
+	// FindingIDStrategyStable keeps the finding for as long as the cluster,
+	// the constraint, and the resource name stay the same, see
+	// stableFindingID
+	FindingIDStrategyStable = "stable"
+)
+
+// SetFindingIDStrategy sets how the finding ID is determined for each
+// violation. The default is FindingIDStrategySpec.
+func (c *Client) SetFindingIDStrategy(strategy string) error {
+	switch strategy {
+	case FindingIDStrategySpec, FindingIDStrategyStable:
+		c.findingIDStrategy = strategy
+		return nil
+	default:
+		return fmt.Errorf("invalid finding ID strategy: [%v], must be one of %s, %s", strategy, FindingIDStrategySpec, FindingIDStrategyStable)
+	}
+}
+
+// findingID returns the finding ID for the violation in the cluster using
+// the configured strategy
+func (c *Client) findingID(cluster string, constraint *Constraint, resource *Resource) string {
+	if c.findingIDStrategy == FindingIDStrategyStable {
+		return stableFindingID(cluster, string(constraint.UID), resource.GVK.Group, resource.GVK.Kind, resource.Namespace, resource.Name)
+	}
+	return determineFindingID(constraint, resource)
+}
+
+// stableFindingID creates a deterministic finding ID that doesn't change when
+// the constraint, the constraint template, or the resource spec change, or
+// when the resource is recreated with the same name.
+//
+// Inputs:
+// - cluster name
+// - constraint UID
+// - resource API group and kind
+// - resource namespace and name
+func stableFindingID(cluster, constraintUID, group, kind, namespace, name string) string {
+	uidSha := sha256.Sum256([]byte(strings.Join([]string{cluster, constraintUID, group, kind, namespace, name}, "/")))
+	return hex.EncodeToString(uidSha[:])[:32]
+}
+
+// StableFindingID returns the finding ID that the stable strategy creates
+// for the violation of an existing finding, using the source properties of
+// the finding. Returns an error if the finding doesn't have the required
+// source properties.
+func StableFindingID(finding *securitycenterpb.Finding) (string, error) {
+	constraintUID := property(finding, "ConstraintUID")
+	kind := property(finding, "ResourceKind")
+	name := property(finding, "ResourceName")
+	if constraintUID == "" || kind == "" || name == "" {
+		return "", fmt.Errorf("finding %s is missing the ConstraintUID, ResourceKind, or ResourceName source property", finding.Name)
+	}
+	return stableFindingID(property(finding, "Cluster"), constraintUID, property(finding, "ResourceAPIGroup"), kind, property(finding, "ResourceNamespace"), name), nil
+}
+
+// lineageKey identifies the violation of a finding across finding IDs, using
+// the cluster, constraint UID, and resource UID source properties. Implements
+// securitycenter.LineageKeyFunc.
+func lineageKey(finding *securitycenterpb.Finding) string {
+	constraintUID := property(finding, "ConstraintUID")
+	resourceUID := property(finding, "ResourceUID")
+	if constraintUID == "" || resourceUID == "" {
+		return ""
+	}
+	return strings.Join([]string{property(finding, "Cluster"), constraintUID, resourceUID}, "/")
+}
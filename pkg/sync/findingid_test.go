@@ -0,0 +1,108 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"testing"
+
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func Test_findingID(t *testing.T) {
+	constraint := &Constraint{UID: "constraintUID", SpecJSON: "constraintSpecJSON"}
+	resource := &Resource{
+		Name:      "my-deployment",
+		Namespace: "default",
+		GVK:       schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		UID:       "resourceUID",
+		SpecJSON:  `{"replicas":1}`,
+	}
+	changedSpec := &Resource{
+		Name:      "my-deployment",
+		Namespace: "default",
+		GVK:       schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		UID:       "recreatedResourceUID",
+		SpecJSON:  `{"replicas":2}`,
+	}
+
+	specClient := &Client{findingIDStrategy: FindingIDStrategySpec}
+	if specClient.findingID("cluster", constraint, resource) == specClient.findingID("cluster", constraint, changedSpec) {
+		t.Errorf("findingID() with spec strategy didn't change when the resource changed")
+	}
+	stableClient := &Client{findingIDStrategy: FindingIDStrategyStable}
+	got := stableClient.findingID("cluster", constraint, resource)
+	if got != stableClient.findingID("cluster", constraint, changedSpec) {
+		t.Errorf("findingID() with stable strategy changed when the resource changed")
+	}
+	if got == stableClient.findingID("other-cluster", constraint, resource) {
+		t.Errorf("findingID() with stable strategy didn't change when the cluster changed")
+	}
+	if len(got) != 32 {
+		t.Errorf("findingID() length %v, want 32", len(got))
+	}
+}
+
+func Test_StableFindingID(t *testing.T) {
+	properties := map[string]*structpb.Value{
+		"Cluster":           structpb.NewStringValue("cluster"),
+		"ConstraintUID":     structpb.NewStringValue("constraintUID"),
+		"ResourceAPIGroup":  structpb.NewStringValue("apps"),
+		"ResourceKind":      structpb.NewStringValue("Deployment"),
+		"ResourceNamespace": structpb.NewStringValue("default"),
+		"ResourceName":      structpb.NewStringValue("my-deployment"),
+	}
+	got, err := StableFindingID(&securitycenterpb.Finding{SourceProperties: properties})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := stableFindingID("cluster", "constraintUID", "apps", "Deployment", "default", "my-deployment")
+	if got != want {
+		t.Errorf("StableFindingID() = %v, want %v", got, want)
+	}
+
+	delete(properties, "ConstraintUID")
+	if _, err := StableFindingID(&securitycenterpb.Finding{SourceProperties: properties}); err == nil {
+		t.Errorf("StableFindingID() expected error for finding without ConstraintUID")
+	}
+}
+
+func TestClient_SetFindingIDStrategy(t *testing.T) {
+	c := &Client{}
+	if err := c.SetFindingIDStrategy(FindingIDStrategyStable); err != nil || c.findingIDStrategy != FindingIDStrategyStable {
+		t.Errorf("SetFindingIDStrategy(%s) error = %v, strategy = %v", FindingIDStrategyStable, err, c.findingIDStrategy)
+	}
+	if err := c.SetFindingIDStrategy("uid"); err == nil {
+		t.Errorf("SetFindingIDStrategy(uid) expected error")
+	}
+}
+
+func Test_lineageKey(t *testing.T) {
+	finding := func(properties map[string]string) *securitycenterpb.Finding {
+		values := map[string]*structpb.Value{}
+		for name, value := range properties {
+			values[name] = structpb.NewStringValue(value)
+		}
+		return &securitycenterpb.Finding{SourceProperties: values}
+	}
+	got := lineageKey(finding(map[string]string{"Cluster": "cluster", "ConstraintUID": "constraintUID", "ResourceUID": "resourceUID", "ResourceName": "ignored"}))
+	if want := "cluster/constraintUID/resourceUID"; got != want {
+		t.Errorf("lineageKey() = %v, want %v", got, want)
+	}
+	if got := lineageKey(finding(map[string]string{"Cluster": "cluster", "ConstraintUID": "constraintUID"})); got != "" {
+		t.Errorf("lineageKey() = %v, want empty for finding without ResourceUID", got)
+	}
+}
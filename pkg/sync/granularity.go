@@ -0,0 +1,179 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Finding granularity options, see SetFindingGranularity
+const (
+	// FindingGranularityViolation creates one finding per violation
+	FindingGranularityViolation = "violation"
+	// FindingGranularityConstraint creates one finding per constraint, for
+	// the constraint resource, that summarizes all its violations
+	FindingGranularityConstraint = "constraint"
+	// FindingGranularityNamespace creates one finding per constraint and
+	// namespace, for the Namespace resource, that summarizes the violations
+	// of the constraint in the namespace. Violations of cluster-scoped
+	// resources are summarized in one finding for the constraint resource.
+	FindingGranularityNamespace = "namespace"
+)
+
+// SetFindingGranularity sets how many violations each finding reports. The
+// default is FindingGranularityViolation.
+//
+// With the aggregated granularities, a finding stays ACTIVE as long as at
+// least one of its violations remains, and is set to INACTIVE when all of
+// its violations are gone.
+func (c *Client) SetFindingGranularity(granularity string) error {
+	switch granularity {
+	case FindingGranularityViolation, FindingGranularityConstraint, FindingGranularityNamespace:
+		c.findingGranularity = granularity
+		return nil
+	default:
+		return fmt.Errorf("invalid finding granularity: [%v], must be one of %s, %s, %s", granularity, FindingGranularityViolation, FindingGranularityConstraint, FindingGranularityNamespace)
+	}
+}
+
+// aggregatesViolations returns true if findings can report more than one
+// violation, because of the finding granularity or owner aggregation
+func (c *Client) aggregatesViolations() bool {
+	return c.aggregateOwners || c.findingGranularity == FindingGranularityConstraint || c.findingGranularity == FindingGranularityNamespace
+}
+
+// aggregateNamespace returns the namespace of the aggregate finding for a
+// violating object in the given namespace, or empty string if the finding
+// is for the constraint resource
+func (c *Client) aggregateNamespace(namespace string) string {
+	if c.findingGranularity == FindingGranularityNamespace {
+		return namespace
+	}
+	return ""
+}
+
+// aggregateViolation returns the violation that identifies the finding that
+// reports the violation, for recording unresolved violations. This is the
+// violation itself for FindingGranularityViolation, and the constraint or
+// the namespace for the aggregated granularities.
+func (c *Client) aggregateViolation(constraint *unstructured.Unstructured, violation map[string]interface{}) map[string]interface{} {
+	if c.findingGranularity == FindingGranularityViolation || c.findingGranularity == "" {
+		return violation
+	}
+	namespace, _, _ := unstructured.NestedString(violation, "namespace")
+	if namespace = c.aggregateNamespace(namespace); namespace != "" {
+		return map[string]interface{}{
+			"kind": "Namespace",
+			"name": namespace,
+		}
+	}
+	return map[string]interface{}{
+		"kind":      constraint.GetKind(),
+		"namespace": constraint.GetNamespace(),
+		"name":      constraint.GetName(),
+	}
+}
+
+// aggregateResources summarizes the resources of the violations of the
+// constraint into one resource per finding, according to the finding
+// granularity. The affected objects of each summary resource are the
+// violating objects. Resources that already aggregate violations, see
+// SetAggregateOwners, contribute their affected objects.
+//
+// If a Namespace can't be retrieved, the violations in the namespace are
+// recorded in unresolved.
+func (c *Client) aggregateResources(ctx context.Context, constraint *unstructured.Unstructured, resources []*Resource, kindToGVR map[string][]schema.GroupVersionResource, unresolved *unresolvedViolations) []*Resource {
+	if c.findingGranularity == FindingGranularityViolation || c.findingGranularity == "" || len(resources) == 0 {
+		return resources
+	}
+	affected := map[string][]ObjectReference{} // key is the namespace of the aggregate finding
+	messages := map[string]string{}            // violation messages keyed by affected object
+	for _, resource := range resources {
+		refs := resource.Affected
+		if len(refs) == 0 {
+			refs = []ObjectReference{{GVK: resource.GVK, Namespace: resource.Namespace, Name: resource.Name}}
+		}
+		for _, ref := range refs {
+			namespace := c.aggregateNamespace(ref.Namespace)
+			if _, exists := messages[ref.String()]; !exists {
+				affected[namespace] = append(affected[namespace], ref)
+				messages[ref.String()] = resource.Message
+			}
+		}
+	}
+	var namespaces []string
+	for namespace := range affected {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	var aggregates []*Resource
+	for _, namespace := range namespaces {
+		refs := affected[namespace]
+		sort.Slice(refs, func(i, j int) bool {
+			return refs[i].String() < refs[j].String()
+		})
+		obj := constraint
+		if namespace != "" {
+			var err error
+			obj, err = c.dynamicClient.GetResourceByKind(ctx, "Namespace", namespace, "", kindToGVR)
+			if err != nil {
+				c.log.Error(err, "could not get namespace, preserving existing findings", "namespace", namespace)
+				unresolved.addViolation(constraint.GetUID(), map[string]interface{}{"kind": "Namespace", "name": namespace})
+				continue
+			}
+		}
+		resource := c.newResource(ctx, obj, aggregateMessage(constraint, namespace, refs, messages[refs[0].String()]))
+		resource.Affected = refs
+		aggregates = append(aggregates, resource)
+	}
+	return aggregates
+}
+
+// aggregateMessage returns the explanation of a finding that summarizes
+// the violations of the constraint, including the message of the first
+// violation
+func aggregateMessage(constraint *unstructured.Unstructured, namespace string, affected []ObjectReference, message string) string {
+	scope := ""
+	if namespace != "" {
+		scope = " in namespace " + namespace
+	}
+	objects := "objects violate"
+	if len(affected) == 1 {
+		objects = "object violates"
+	}
+	return fmt.Sprintf("%d %s %s%s, %s: %s", len(affected), objects, constraint.GetName(), scope, affected[0], message)
+}
+
+// affectedNamespacesProperty returns the namespaces of the affected objects
+// as a sorted comma-separated list, truncated to the max source property
+// length. Returns empty string if all affected objects are cluster-scoped.
+func affectedNamespacesProperty(affected []ObjectReference) string {
+	seen := map[string]bool{}
+	var namespaces []string
+	for _, ref := range affected {
+		if ref.Namespace != "" && !seen[ref.Namespace] {
+			seen[ref.Namespace] = true
+			namespaces = append(namespaces, ref.Namespace)
+		}
+	}
+	sort.Strings(namespaces)
+	return fmt.Sprintf("%.255s", strings.Join(namespaces, ","))
+}
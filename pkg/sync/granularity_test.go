@@ -0,0 +1,167 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestClient_SetFindingGranularity(t *testing.T) {
+	c := &Client{}
+	for _, granularity := range []string{FindingGranularityViolation, FindingGranularityConstraint, FindingGranularityNamespace} {
+		if err := c.SetFindingGranularity(granularity); err != nil {
+			t.Errorf("SetFindingGranularity(%q) returned error: %v", granularity, err)
+		}
+	}
+	if err := c.SetFindingGranularity("cluster"); err == nil {
+		t.Errorf("SetFindingGranularity(%q) expected error", "cluster")
+	}
+}
+
+func TestClient_aggregateViolation(t *testing.T) {
+	constraint := newOwnedObject("constraints.gatekeeper.sh/v1beta1", "K8sRequiredLabels", "", "ns-must-have-geo", "constraint-uid", nil)
+	namespacedViolation := map[string]interface{}{"kind": "Pod", "namespace": "default", "name": "pod-1"}
+	clusterViolation := map[string]interface{}{"kind": "Namespace", "name": "default"}
+	tests := []struct {
+		name        string
+		granularity string
+		violation   map[string]interface{}
+		want        map[string]interface{}
+	}{
+		{
+			name:        "violation",
+			granularity: FindingGranularityViolation,
+			violation:   namespacedViolation,
+			want:        namespacedViolation,
+		},
+		{
+			name:        "constraint",
+			granularity: FindingGranularityConstraint,
+			violation:   namespacedViolation,
+			want:        map[string]interface{}{"kind": "K8sRequiredLabels", "namespace": "", "name": "ns-must-have-geo"},
+		},
+		{
+			name:        "namespace",
+			granularity: FindingGranularityNamespace,
+			violation:   namespacedViolation,
+			want:        map[string]interface{}{"kind": "Namespace", "name": "default"},
+		},
+		{
+			name:        "namespace, cluster-scoped resource",
+			granularity: FindingGranularityNamespace,
+			violation:   clusterViolation,
+			want:        map[string]interface{}{"kind": "K8sRequiredLabels", "namespace": "", "name": "ns-must-have-geo"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{findingGranularity: tt.granularity}
+			got := c.aggregateViolation(constraint, tt.violation)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("aggregateViolation() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestClient_aggregateResources(t *testing.T) {
+	constraint := newOwnedObject("constraints.gatekeeper.sh/v1beta1", "K8sRequiredLabels", "", "must-have-owner", "constraint-uid", nil)
+	podGVK := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	nsGVK := schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+	deploymentGVK := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	resources := []*Resource{
+		{GVK: podGVK, Namespace: "team-b", Name: "pod-1", UID: "pod-uid-1", Message: "missing owner label"},
+		{GVK: nsGVK, Name: "team-a", UID: "ns-uid", Message: "missing owner label on namespace"},
+		{
+			GVK:       deploymentGVK,
+			Namespace: "team-a",
+			Name:      "app",
+			UID:       "deployment-uid",
+			Message:   "2 objects owned by Deployment app violate the constraint",
+			Affected: []ObjectReference{
+				{GVK: podGVK, Namespace: "team-a", Name: "app-1"},
+				{GVK: podGVK, Namespace: "team-a", Name: "app-2"},
+			},
+		},
+	}
+
+	t.Run("violation", func(t *testing.T) {
+		c := &Client{log: testr.New(t), findingGranularity: FindingGranularityViolation}
+		got := c.aggregateResources(context.Background(), constraint, resources, nil, newUnresolvedViolations())
+		if len(got) != len(resources) {
+			t.Errorf("aggregateResources() returned %d resources, want %d", len(got), len(resources))
+		}
+	})
+
+	t.Run("constraint", func(t *testing.T) {
+		c := &Client{log: testr.New(t), findingGranularity: FindingGranularityConstraint}
+		got := c.aggregateResources(context.Background(), constraint, resources, nil, newUnresolvedViolations())
+		if len(got) != 1 {
+			t.Fatalf("aggregateResources() returned %d resources, want 1", len(got))
+		}
+		if got[0].UID != types.UID("constraint-uid") || got[0].GVK.Kind != "K8sRequiredLabels" {
+			t.Errorf("aggregateResources() resource = %s %s, want the constraint", got[0].GVK.Kind, got[0].UID)
+		}
+		wantAffected := []ObjectReference{
+			{GVK: nsGVK, Name: "team-a"},
+			{GVK: podGVK, Namespace: "team-a", Name: "app-1"},
+			{GVK: podGVK, Namespace: "team-a", Name: "app-2"},
+			{GVK: podGVK, Namespace: "team-b", Name: "pod-1"},
+		}
+		if diff := cmp.Diff(wantAffected, got[0].Affected); diff != "" {
+			t.Errorf("aggregateResources() affected mismatch (-want +got):\n%s", diff)
+		}
+		wantMessage := "4 objects violate must-have-owner, Namespace/team-a: missing owner label on namespace"
+		if got[0].Message != wantMessage {
+			t.Errorf("aggregateResources() message = %q, want %q", got[0].Message, wantMessage)
+		}
+		if namespaces := affectedNamespacesProperty(got[0].Affected); namespaces != "team-a,team-b" {
+			t.Errorf("affectedNamespacesProperty() = %q, want %q", namespaces, "team-a,team-b")
+		}
+	})
+
+	t.Run("namespace, cluster-scoped resources", func(t *testing.T) {
+		c := &Client{log: testr.New(t), findingGranularity: FindingGranularityNamespace}
+		got := c.aggregateResources(context.Background(), constraint, resources[1:2], nil, newUnresolvedViolations())
+		if len(got) != 1 {
+			t.Fatalf("aggregateResources() returned %d resources, want 1", len(got))
+		}
+		if got[0].UID != types.UID("constraint-uid") {
+			t.Errorf("aggregateResources() resource UID = %s, want the constraint", got[0].UID)
+		}
+		wantMessage := "1 object violates must-have-owner, Namespace/team-a: missing owner label on namespace"
+		if got[0].Message != wantMessage {
+			t.Errorf("aggregateResources() message = %q, want %q", got[0].Message, wantMessage)
+		}
+	})
+}
+
+func Test_aggregateMessage_namespace(t *testing.T) {
+	constraint := &unstructured.Unstructured{}
+	constraint.SetName("must-have-owner")
+	affected := []ObjectReference{{GVK: schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, Namespace: "team-b", Name: "pod-1"}}
+	got := aggregateMessage(constraint, "team-b", affected, "missing owner label")
+	want := "1 object violates must-have-owner in namespace team-b, Pod/team-b/pod-1: missing owner label"
+	if got != want {
+		t.Errorf("aggregateMessage() = %q, want %q", got, want)
+	}
+}
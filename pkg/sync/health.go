@@ -0,0 +1,232 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	errorutils "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	// PolicyHealthCategory is the category of findings that report
+	// constraint templates and constraints that don't work as intended
+	PolicyHealthCategory = "POLICY_HEALTH"
+
+	// PolicyHealthIssueProperty is the source property of policy health
+	// findings that identifies the issue
+	PolicyHealthIssueProperty = "PolicyHealthIssue"
+
+	// neverAuditedGracePeriod is the time after creation before a constraint
+	// without an audit timestamp is reported. Gatekeeper audits new
+	// constraints in the next audit run, by default within 60 seconds.
+	neverAuditedGracePeriod = 10 * time.Minute
+)
+
+// Policy health issues
+const (
+	// PolicyHealthTemplateError is a constraint template with errors in its
+	// status, e.g., because the Rego source doesn't compile
+	PolicyHealthTemplateError = "TemplateError"
+	// PolicyHealthConstraintError is a constraint with errors in its status,
+	// e.g., because the parameters don't match the template schema
+	PolicyHealthConstraintError = "ConstraintError"
+	// PolicyHealthNeverAudited is a constraint without an audit timestamp
+	PolicyHealthNeverAudited = "NeverAudited"
+	// PolicyHealthStaleAudit is a constraint whose last audit is older than
+	// the staleness threshold, see SetAuditStaleness
+	PolicyHealthStaleAudit = "StaleAudit"
+)
+
+// SetPolicyHealth enables findings for constraint templates and constraints
+// with errors in their status, and for constraints that have never been
+// audited or have stale audits, see SetAuditStaleness. These findings have
+// the category PolicyHealthCategory, and are set to INACTIVE when the issue
+// is resolved. Disabled by default.
+func (c *Client) SetPolicyHealth(enabled bool) {
+	c.policyHealth = enabled
+}
+
+// policyHealthIssue is a constraint template or constraint that doesn't
+// work as intended
+type policyHealthIssue struct {
+	issue   string
+	obj     *unstructured.Unstructured
+	message string
+}
+
+// getPolicyHealthFindingRequests returns finding requests for the policy
+// health issues of the constraint templates and constraints in the cluster,
+// keyed by full finding name. It also records the audit ages of the
+// constraints in the metrics.
+func (c *Client) getPolicyHealthFindingRequests(ctx context.Context, groupResources []schema.GroupResource) (map[string]*securitycenterpb.CreateFindingRequest, error) {
+	templates, templatesErr := c.dynamicClient.GetConstraintTemplates(ctx)
+	constraints, constraintsErr := c.dynamicClient.GetConstraints(ctx, groupResources)
+	if err := errorutils.NewAggregate([]error{templatesErr, constraintsErr}); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	c.recordAuditAges(constraints, now)
+	var issues []*policyHealthIssue
+	for i := range templates {
+		template := &templates[i]
+		kind, _, _ := unstructured.NestedString(template.UnstructuredContent(), "spec", "crd", "spec", "names", "kind")
+		if !c.policyHealth || kind != "" && !c.filters.includesConstraintKind(kind) {
+			continue
+		}
+		issues = append(issues, templateHealthIssues(template)...)
+	}
+	for i := range constraints {
+		constraint := &constraints[i]
+		if !c.filters.includesConstraintKind(constraint.GetKind()) {
+			continue
+		}
+		if c.policyHealth {
+			issues = append(issues, constraintHealthIssues(constraint, now)...)
+			issues = append(issues, c.staleAuditIssues(constraint, now)...)
+		}
+	}
+	findingRequests := map[string]*securitycenterpb.CreateFindingRequest{}
+	for _, issue := range issues {
+		c.log.V(1).Info("policy health issue", "issue", issue.issue, "kind", issue.obj.GetKind(), "name", issue.obj.GetName(), "message", issue.message)
+		req := c.createPolicyHealthFindingRequest(issue)
+		findingRequests[fmt.Sprintf("%s/findings/%s", req.Parent, req.FindingId)] = req
+	}
+	return findingRequests, nil
+}
+
+// templateHealthIssues returns the issues of the constraint template
+func templateHealthIssues(template *unstructured.Unstructured) []*policyHealthIssue {
+	if errs := byPodErrors(template); len(errs) > 0 {
+		return []*policyHealthIssue{{
+			issue:   PolicyHealthTemplateError,
+			obj:     template,
+			message: "constraint template has errors: " + strings.Join(errs, "; "),
+		}}
+	}
+	return nil
+}
+
+// constraintHealthIssues returns the issues of the constraint
+func constraintHealthIssues(constraint *unstructured.Unstructured, now time.Time) []*policyHealthIssue {
+	var issues []*policyHealthIssue
+	if errs := byPodErrors(constraint); len(errs) > 0 {
+		issues = append(issues, &policyHealthIssue{
+			issue:   PolicyHealthConstraintError,
+			obj:     constraint,
+			message: "constraint has errors: " + strings.Join(errs, "; "),
+		})
+	}
+	auditTimestamp, _, _ := unstructured.NestedString(constraint.UnstructuredContent(), "status", "auditTimestamp")
+	if auditTimestamp == "" && now.Sub(constraint.GetCreationTimestamp().Time) > neverAuditedGracePeriod {
+		issues = append(issues, &policyHealthIssue{
+			issue:   PolicyHealthNeverAudited,
+			obj:     constraint,
+			message: fmt.Sprintf("constraint has not been audited since it was created at %s", constraint.GetCreationTimestamp().UTC().Format(time.RFC3339)),
+		})
+	}
+	return issues
+}
+
+// byPodErrors returns the distinct error messages in the status.byPod
+// entries of a constraint template or constraint, sorted. Each Gatekeeper
+// pod reports its own errors, so the same error is usually reported by
+// multiple pods.
+func byPodErrors(obj *unstructured.Unstructured) []string {
+	byPod, _, _ := unstructured.NestedSlice(obj.UnstructuredContent(), "status", "byPod")
+	seen := map[string]bool{}
+	var errs []string
+	for _, rawPodStatus := range byPod {
+		podStatus, ok := rawPodStatus.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		podErrors, _, _ := unstructured.NestedSlice(podStatus, "errors")
+		for _, rawPodError := range podErrors {
+			podError, ok := rawPodError.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			code, _, _ := unstructured.NestedString(podError, "code")
+			message, _, _ := unstructured.NestedString(podError, "message")
+			if code != "" {
+				message = code + ": " + message
+			}
+			if !seen[message] {
+				seen[message] = true
+				errs = append(errs, message)
+			}
+		}
+	}
+	sort.Strings(errs)
+	return errs
+}
+
+// createPolicyHealthFindingRequest creates a CreateFindingRequest for the
+// policy health issue
+func (c *Client) createPolicyHealthFindingRequest(issue *policyHealthIssue) *securitycenterpb.CreateFindingRequest {
+	// Add API server host to all Kubernetes object links and limit to max 255 characters
+	selfLink := fmt.Sprintf("%.255s", fmt.Sprintf("%v%v", c.host, issue.obj.GetSelfLink()))
+	gvk := issue.obj.GroupVersionKind()
+	return &securitycenterpb.CreateFindingRequest{
+		Parent:    c.source,
+		FindingId: policyHealthFindingID(c.cluster, issue.issue, string(issue.obj.GetUID())),
+		Finding: &securitycenterpb.Finding{
+			State:        securitycenterpb.Finding_ACTIVE,
+			ResourceName: selfLink,
+			Category:     PolicyHealthCategory,
+			EventTime:    timestamppb.Now(),
+			ExternalUri:  selfLink,
+			SourceProperties: map[string]*structpb.Value{
+				// each source property value must be max 255 chars
+				"ScannerName":             {Kind: &structpb.Value_StringValue{StringValue: scannerName}},
+				"Explanation":             {Kind: &structpb.Value_StringValue{StringValue: fmt.Sprintf("%.255s", issue.message)}},
+				"Cluster":                 {Kind: &structpb.Value_StringValue{StringValue: c.cluster}},
+				PolicyHealthIssueProperty: {Kind: &structpb.Value_StringValue{StringValue: issue.issue}},
+				"ResourceName":            {Kind: &structpb.Value_StringValue{StringValue: issue.obj.GetName()}},
+				"ResourceNamespace":       {Kind: &structpb.Value_StringValue{StringValue: issue.obj.GetNamespace()}},
+				"ResourceSelfLink":        {Kind: &structpb.Value_StringValue{StringValue: selfLink}},
+				"ResourceUID":             {Kind: &structpb.Value_StringValue{StringValue: string(issue.obj.GetUID())}},
+				"ResourceAPIGroup":        {Kind: &structpb.Value_StringValue{StringValue: gvk.Group}},
+				"ResourceAPIVersion":      {Kind: &structpb.Value_StringValue{StringValue: gvk.Version}},
+				"ResourceKind":            {Kind: &structpb.Value_StringValue{StringValue: gvk.Kind}},
+			},
+		},
+	}
+}
+
+// policyHealthFindingID creates a deterministic finding ID for a policy
+// health issue. The finding ID doesn't change when the error messages
+// change, so the finding stays the same until the issue is resolved.
+//
+// Inputs:
+// - cluster name
+// - policy health issue
+// - constraint template or constraint UID
+func policyHealthFindingID(cluster, issue, uid string) string {
+	uidSha := sha256.Sum256([]byte(strings.Join([]string{cluster, PolicyHealthCategory, issue, uid}, "/")))
+	return hex.EncodeToString(uidSha[:])[:32]
+}
@@ -0,0 +1,159 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newPolicyObject(kind string, created time.Time, status map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "constraints.gatekeeper.sh/v1beta1",
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name": "policy",
+			"uid":  "policy-uid",
+		},
+	}}
+	obj.SetCreationTimestamp(metav1.NewTime(created))
+	if status != nil {
+		obj.Object["status"] = status
+	}
+	return obj
+}
+
+func Test_byPodErrors(t *testing.T) {
+	obj := newPolicyObject("ConstraintTemplate", time.Now(), map[string]interface{}{
+		"byPod": []interface{}{
+			map[string]interface{}{
+				"id": "gatekeeper-audit-1",
+				"errors": []interface{}{
+					map[string]interface{}{"code": "ingest_error", "message": "rego_parse_error: unexpected eof token"},
+				},
+			},
+			map[string]interface{}{
+				"id": "gatekeeper-controller-manager-1",
+				"errors": []interface{}{
+					map[string]interface{}{"code": "ingest_error", "message": "rego_parse_error: unexpected eof token"},
+					map[string]interface{}{"message": "another error"},
+				},
+			},
+			map[string]interface{}{
+				"id": "gatekeeper-controller-manager-2",
+			},
+		},
+	})
+	want := []string{"another error", "ingest_error: rego_parse_error: unexpected eof token"}
+	if diff := cmp.Diff(want, byPodErrors(obj)); diff != "" {
+		t.Errorf("byPodErrors() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_constraintHealthIssues(t *testing.T) {
+	now := time.Date(2021, 3, 1, 2, 3, 4, 0, time.UTC)
+	audited := map[string]interface{}{"auditTimestamp": "2021-03-01T02:03:00Z"}
+	tests := []struct {
+		name       string
+		constraint *unstructured.Unstructured
+		want       []string
+	}{
+		{
+			name:       "healthy",
+			constraint: newPolicyObject("K8sRequiredLabels", now.Add(-time.Hour), audited),
+		},
+		{
+			name:       "new constraint not audited yet",
+			constraint: newPolicyObject("K8sRequiredLabels", now.Add(-time.Minute), nil),
+		},
+		{
+			name:       "never audited",
+			constraint: newPolicyObject("K8sRequiredLabels", now.Add(-time.Hour), nil),
+			want:       []string{PolicyHealthNeverAudited},
+		},
+		{
+			name: "errors",
+			constraint: newPolicyObject("K8sRequiredLabels", now.Add(-time.Hour), map[string]interface{}{
+				"auditTimestamp": "2021-03-01T02:03:00Z",
+				"byPod": []interface{}{
+					map[string]interface{}{
+						"id":     "gatekeeper-audit-1",
+						"errors": []interface{}{map[string]interface{}{"message": "invalid parameters"}},
+					},
+				},
+			}),
+			want: []string{PolicyHealthConstraintError},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, issue := range constraintHealthIssues(tt.constraint, now) {
+				got = append(got, issue.issue)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("constraintHealthIssues() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestClient_createPolicyHealthFindingRequest(t *testing.T) {
+	template := newPolicyObject("ConstraintTemplate", time.Now(), map[string]interface{}{
+		"byPod": []interface{}{
+			map[string]interface{}{
+				"id":     "gatekeeper-audit-1",
+				"errors": []interface{}{map[string]interface{}{"code": "ingest_error", "message": "rego_parse_error"}},
+			},
+		},
+	})
+	template.SetAPIVersion("templates.gatekeeper.sh/v1beta1")
+	issues := templateHealthIssues(template)
+	if len(issues) != 1 {
+		t.Fatalf("templateHealthIssues() returned %d issues, want 1", len(issues))
+	}
+	c := &Client{log: testr.New(t), host: host, source: source, cluster: cluster}
+	req := c.createPolicyHealthFindingRequest(issues[0])
+	if req.Finding.Category != PolicyHealthCategory {
+		t.Errorf("Category = %s, want %s", req.Finding.Category, PolicyHealthCategory)
+	}
+	wantProperties := map[string]string{
+		"Explanation":             "constraint template has errors: ingest_error: rego_parse_error",
+		"Cluster":                 cluster,
+		PolicyHealthIssueProperty: PolicyHealthTemplateError,
+		"ResourceKind":            "ConstraintTemplate",
+		"ResourceAPIGroup":        "templates.gatekeeper.sh",
+		"ResourceUID":             "policy-uid",
+	}
+	for key, want := range wantProperties {
+		if got := property(req.Finding, key); got != want {
+			t.Errorf("source property %s = %q, want %q", key, got, want)
+		}
+	}
+	if want := policyHealthFindingID(cluster, PolicyHealthTemplateError, "policy-uid"); req.FindingId != want {
+		t.Errorf("FindingId = %s, want %s", req.FindingId, want)
+	}
+	if req.FindingId == policyHealthFindingID(cluster, PolicyHealthNeverAudited, "policy-uid") {
+		t.Errorf("FindingId is the same for different issues of the same object")
+	}
+	if property(req.Finding, "ConstraintUID") != "" {
+		t.Errorf("policy health findings must not have a ConstraintUID source property")
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	securitycenterclient "github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+// ExternalURILink is the link template name that sets the finding ExternalUri
+const ExternalURILink = "ExternalUri"
+
+// maxLinkLength is the max length of the ExternalUri and of source property
+// values. Longer links are skipped, since truncated links don't work.
+const maxLinkLength = 255
+
+var (
+	// builtinLinkTemplates can be used by name instead of a template
+	builtinLinkTemplates = map[string]string{
+		// GKE workloads page in the Google Cloud console
+		"gke": `{{if and .Project .Location .Cluster .Namespace}}{{with gkePage .Group .Kind}}https://console.cloud.google.com/kubernetes/{{.}}/{{pathescape $.Location}}/{{pathescape $.Cluster}}/{{pathescape $.Namespace}}/{{pathescape $.Name}}/overview?project={{urlquery $.Project}}{{end}}{{end}}`,
+		// Cloud Asset Inventory search for the Google Cloud resource managed by a Config Connector resource
+		"config-connector": `{{if and .ProjectID .StatusSelfLink}}https://console.cloud.google.com/iam-admin/asset-inventory/resources?project={{urlquery .ProjectID}}&query={{urlquery .Name}}{{end}}`,
+	}
+
+	// gkePages maps workload kinds to the resource type in GKE console URLs
+	gkePages = map[string]string{
+		"apps/Deployment":  "deployment",
+		"apps/StatefulSet": "statefulset",
+		"apps/DaemonSet":   "daemonset",
+		"apps/ReplicaSet":  "replicaset",
+		"batch/Job":        "job",
+		"batch/CronJob":    "cronjob",
+		"/Pod":             "pod",
+		"/Service":         "service",
+	}
+
+	linkTemplateFuncs = template.FuncMap{
+		"gkePage":    func(group, kind string) string { return gkePages[group+"/"+kind] },
+		"lower":      strings.ToLower,
+		"pathescape": url.PathEscape,
+	}
+
+	linkNameRegexp = regexp.MustCompile("^[A-Za-z][A-Za-z0-9_]*$")
+)
+
+// LinkData are the values available to link templates
+type LinkData struct {
+	// Cluster name, from the --cluster flag
+	Cluster string
+	// Location of the GKE cluster, e.g., us-central1
+	Location string
+	// Project ID of the GKE cluster
+	Project string
+	// Namespace of the violating resource, empty for cluster-scoped resources
+	Namespace string
+	// Name of the violating resource
+	Name string
+	// Group is the API group of the violating resource, empty for the core group
+	Group string
+	// Version is the API version of the violating resource
+	Version string
+	// Kind of the violating resource
+	Kind string
+	// ProjectID of the Google Cloud resource managed by a Config Connector resource
+	ProjectID string
+	// StatusSelfLink of the Google Cloud resource managed by a Config Connector resource
+	StatusSelfLink string
+	// AssetName is the Cloud Asset Inventory name of the Google Cloud resource
+	// managed by a Config Connector resource
+	AssetName string
+	// ConstraintKind is the kind of the violated constraint
+	ConstraintKind string
+	// ConstraintName is the name of the violated constraint
+	ConstraintName string
+}
+
+// linkTemplate is a parsed link template
+type linkTemplate struct {
+	name     string
+	template *template.Template
+}
+
+// SetLinkTemplates sets the templates used to create links for each finding.
+// The keys are link names, and the values are Go templates that use LinkData,
+// or the names of built-in templates (`gke`, `config-connector`).
+//
+// The link named ExternalUri sets the finding ExternalUri, instead of the
+// default link to the constraint in the Kubernetes API server. Other links
+// are added as source properties, prefixed with `Link`.
+func (c *Client) SetLinkTemplates(templates map[string]string) error {
+	var links []*linkTemplate
+	for name, text := range templates {
+		if !linkNameRegexp.MatchString(name) {
+			return fmt.Errorf("invalid link name: [%v], must start with a letter and contain only letters, numbers, and underscores", name)
+		}
+		if builtin, exists := builtinLinkTemplates[text]; exists {
+			text = builtin
+		}
+		tmpl, err := template.New(name).Funcs(linkTemplateFuncs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return fmt.Errorf("invalid template for link %s: %w", name, err)
+		}
+		links = append(links, &linkTemplate{name: name, template: tmpl})
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].name < links[j].name })
+	c.linkTemplates = links
+	return nil
+}
+
+// SetClusterLocation sets the project ID and location of the GKE cluster,
+// used by link templates
+func (c *Client) SetClusterLocation(project, location string) {
+	c.clusterProject = project
+	c.clusterLocation = location
+}
+
+// createLinks renders the link templates for the constraint and resource.
+// Links that are empty, too long, or fail to render are skipped.
+func (c *Client) createLinks(constraint *Constraint, resource *Resource) map[string]string {
+	if len(c.linkTemplates) == 0 {
+		return nil
+	}
+	data := &LinkData{
+		Cluster:        c.cluster,
+		Location:       c.clusterLocation,
+		Project:        c.clusterProject,
+		Namespace:      resource.Namespace,
+		Name:           resource.Name,
+		Group:          resource.GVK.Group,
+		Version:        resource.GVK.Version,
+		Kind:           resource.GVK.Kind,
+		ProjectID:      resource.ProjectID,
+		StatusSelfLink: resource.StatusSelfLink,
+		AssetName:      resource.AssetName,
+		ConstraintKind: constraint.Kind,
+		ConstraintName: constraint.Name,
+	}
+	links := map[string]string{}
+	for _, link := range c.linkTemplates {
+		var buf bytes.Buffer
+		if err := link.template.Execute(&buf, data); err != nil {
+			c.log.Error(err, "could not create link", "link", link.name, "kind", resource.GVK.Kind, "name", resource.Name)
+			continue
+		}
+		value := strings.TrimSpace(buf.String())
+		if value == "" {
+			continue
+		}
+		if len(value) > maxLinkLength {
+			c.log.V(1).Info("skipping link longer than max length", "link", link.name, "length", len(value), "maxLength", maxLinkLength)
+			continue
+		}
+		links[link.name] = value
+	}
+	return links
+}
+
+// linkPropertyName returns the source property name for the link
+func linkPropertyName(name string) string {
+	return securitycenterclient.LinkPropertyPrefix + name
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestClient_createLinks(t *testing.T) {
+	constraint := &Constraint{Kind: "K8sRequiredLabels", Name: "must-have-owner"}
+	deployment := &Resource{
+		Name:      "frontend",
+		Namespace: "default",
+		GVK:       schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+	}
+	bucket := &Resource{
+		Name:           "my-bucket",
+		Namespace:      "config-connector",
+		GVK:            schema.GroupVersionKind{Group: "storage.cnrm.cloud.google.com", Version: "v1beta1", Kind: "StorageBucket"},
+		ProjectID:      "my-project",
+		StatusSelfLink: "https://www.googleapis.com/storage/v1/b/my-bucket",
+	}
+	tests := []struct {
+		name      string
+		templates map[string]string
+		resource  *Resource
+		want      map[string]string
+		wantErr   bool
+	}{
+		{
+			name:      "gke workload",
+			templates: map[string]string{ExternalURILink: "gke"},
+			resource:  deployment,
+			want: map[string]string{
+				ExternalURILink: "https://console.cloud.google.com/kubernetes/deployment/us-central1/my-cluster/default/frontend/overview?project=cluster-project",
+			},
+		},
+		{
+			name:      "gke template skips unsupported kinds",
+			templates: map[string]string{ExternalURILink: "gke"},
+			resource:  bucket,
+			want:      map[string]string{},
+		},
+		{
+			name:      "config connector resource",
+			templates: map[string]string{"CloudResource": "config-connector"},
+			resource:  bucket,
+			want: map[string]string{
+				"CloudResource": "https://console.cloud.google.com/iam-admin/asset-inventory/resources?project=my-project&query=my-bucket",
+			},
+		},
+		{
+			name:      "custom template",
+			templates: map[string]string{"PolicyPortal": "https://portal.example.com/policies/{{.ConstraintKind}}/{{.ConstraintName}}?ns={{.Namespace}}&kind={{lower .Kind}}"},
+			resource:  deployment,
+			want: map[string]string{
+				"PolicyPortal": "https://portal.example.com/policies/K8sRequiredLabels/must-have-owner?ns=default&kind=deployment",
+			},
+		},
+		{
+			name:      "too long",
+			templates: map[string]string{"Long": "https://example.com/" + strings.Repeat("a", maxLinkLength)},
+			resource:  deployment,
+			want:      map[string]string{},
+		},
+		{
+			name:      "invalid name",
+			templates: map[string]string{"my-link": "https://example.com"},
+			wantErr:   true,
+		},
+		{
+			name:      "invalid template",
+			templates: map[string]string{"Broken": "https://example.com/{{.Name"},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{log: testr.New(t), cluster: "my-cluster"}
+			c.SetClusterLocation("cluster-project", "us-central1")
+			err := c.SetLinkTemplates(tt.templates)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetLinkTemplates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := c.createLinks(constraint, tt.resource)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("createLinks() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
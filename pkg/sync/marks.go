@@ -0,0 +1,110 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// invalidSecurityMarkKeyChars matches characters that aren't allowed in
+// security mark keys, such as the `.` and `/` in `app.kubernetes.io/name`
+var invalidSecurityMarkKeyChars = regexp.MustCompile("[^a-zA-Z0-9_-]")
+
+// SetSecurityMarkLabels sets the allow-list of label keys that are copied
+// from namespaces and violating resources to the security marks of findings.
+// Resource labels take precedence over namespace labels. Labels that aren't
+// in the allow-list are never copied.
+//
+// Label keys are converted to security mark keys by replacing characters
+// other than letters, numbers, underscores, and hyphens with underscores.
+func (c *Client) SetSecurityMarkLabels(labelKeys []string) error {
+	securityMarkKeys := map[string]string{}
+	for _, labelKey := range labelKeys {
+		securityMarkKey := toSecurityMarkKey(labelKey)
+		if otherLabelKey, exists := securityMarkKeys[securityMarkKey]; exists {
+			return fmt.Errorf("labels %s and %s both map to security mark %s", otherLabelKey, labelKey, securityMarkKey)
+		}
+		securityMarkKeys[securityMarkKey] = labelKey
+	}
+	c.securityMarkLabels = labelKeys
+	return nil
+}
+
+// getSecurityMarks returns the desired security marks for findings of the
+// resource. Returns nil if no labels are allow-listed.
+//
+// If the namespace labels can't be read, e.g., because of an API server
+// timeout, only the security marks from resource labels are returned, so the
+// other security marks of the findings are left unchanged in this sync.
+func (c *Client) getSecurityMarks(ctx context.Context, resource *unstructured.Unstructured) map[string]string {
+	if len(c.securityMarkLabels) == 0 {
+		return nil
+	}
+	var namespaceLabels map[string]string
+	if namespace := resource.GetNamespace(); namespace != "" {
+		var cached bool
+		namespaceLabels, cached = c.namespaceLabels[namespace]
+		if !cached {
+			namespaceObj, err := c.dynamicClient.GetNamespace(ctx, namespace)
+			if err != nil {
+				c.log.Error(err, "could not get namespace labels for security marks, leaving other security marks unchanged", "namespace", namespace)
+				return resourceSecurityMarks(c.securityMarkLabels, resource.GetLabels())
+			}
+			namespaceLabels = namespaceObj.GetLabels()
+			if c.namespaceLabels == nil {
+				c.namespaceLabels = map[string]map[string]string{}
+			}
+			c.namespaceLabels[namespace] = namespaceLabels
+		}
+	}
+	return securityMarksFromLabels(c.securityMarkLabels, namespaceLabels, resource.GetLabels())
+}
+
+// securityMarksFromLabels maps the allow-listed label keys to security mark
+// keys. The value is empty if neither the namespace nor the resource has the
+// label, this means the security mark should be removed.
+func securityMarksFromLabels(labelKeys []string, namespaceLabels, resourceLabels map[string]string) map[string]string {
+	securityMarks := map[string]string{}
+	for _, labelKey := range labelKeys {
+		value, exists := resourceLabels[labelKey]
+		if !exists {
+			value = namespaceLabels[labelKey]
+		}
+		securityMarks[toSecurityMarkKey(labelKey)] = value
+	}
+	return securityMarks
+}
+
+// resourceSecurityMarks maps the allow-listed label keys that the resource
+// has to security mark keys. Security marks for other label keys are
+// omitted, this means they aren't changed.
+func resourceSecurityMarks(labelKeys []string, resourceLabels map[string]string) map[string]string {
+	securityMarks := map[string]string{}
+	for _, labelKey := range labelKeys {
+		if value, exists := resourceLabels[labelKey]; exists {
+			securityMarks[toSecurityMarkKey(labelKey)] = value
+		}
+	}
+	return securityMarks
+}
+
+// toSecurityMarkKey converts a label key to a security mark key
+func toSecurityMarkKey(labelKey string) string {
+	return invalidSecurityMarkKeyChars.ReplaceAllString(labelKey, "_")
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/dynamic"
+)
+
+func Test_securityMarksFromLabels(t *testing.T) {
+	labelKeys := []string{"team", "env", "app.kubernetes.io/name"}
+	namespaceLabels := map[string]string{
+		"team":     "payments",
+		"env":      "prod",
+		"internal": "do-not-copy",
+	}
+	resourceLabels := map[string]string{
+		"team":                   "checkout",
+		"app.kubernetes.io/name": "cart",
+		"secret-ish":             "do-not-copy",
+	}
+	want := map[string]string{
+		"team":                   "checkout",
+		"env":                    "prod",
+		"app_kubernetes_io_name": "cart",
+	}
+	got := securityMarksFromLabels(labelKeys, namespaceLabels, resourceLabels)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("securityMarksFromLabels() mismatch (-want +got):\n%s", diff)
+	}
+
+	wantRemoved := map[string]string{"team": "", "env": "", "app_kubernetes_io_name": ""}
+	gotRemoved := securityMarksFromLabels(labelKeys, nil, nil)
+	if diff := cmp.Diff(wantRemoved, gotRemoved); diff != "" {
+		t.Errorf("securityMarksFromLabels() for missing labels mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClient_SetSecurityMarkLabels(t *testing.T) {
+	c := &Client{}
+	if err := c.SetSecurityMarkLabels([]string{"team", "example.com/env"}); err != nil {
+		t.Errorf("SetSecurityMarkLabels() unexpected error: %v", err)
+	}
+	if err := c.SetSecurityMarkLabels([]string{"example.com/env", "example.com.env"}); err == nil {
+		t.Error("SetSecurityMarkLabels() expected error for labels that map to the same security mark")
+	}
+}
+
+func TestClient_getSecurityMarks_namespaceLookupFails(t *testing.T) {
+	// API server that fails all lookups, e.g., because of timeouts
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "etcdserver: request timed out", http.StatusInternalServerError)
+	}))
+	defer apiServer.Close()
+	dynamicClient, err := dynamic.NewClient(testr.New(t), &rest.Config{Host: apiServer.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{log: testr.New(t), dynamicClient: dynamicClient}
+	if err := c.SetSecurityMarkLabels([]string{"team", "env", "app.kubernetes.io/name"}); err != nil {
+		t.Fatal(err)
+	}
+	resource := &unstructured.Unstructured{}
+	resource.SetNamespace("default")
+	resource.SetLabels(map[string]string{"app.kubernetes.io/name": "cart"})
+
+	got := c.getSecurityMarks(context.Background(), resource)
+
+	// team and env may come from the namespace, so they're left unchanged
+	want := map[string]string{"app_kubernetes_io_name": "cart"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("getSecurityMarks() mismatch (-want +got):\n%s", diff)
+	}
+	if _, cached := c.namespaceLabels["default"]; cached {
+		t.Error("getSecurityMarks() cached the labels of a namespace that couldn't be read")
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Annotations on violating resources that mute the findings for the resource
+const (
+	// annotationMute mutes the findings for the resource if set to "true"
+	annotationMute = "gatekeeper-securitycenter/mute"
+	// annotationMuteReason is recorded in the MuteReason source property
+	annotationMuteReason = "gatekeeper-securitycenter/mute-reason"
+	// annotationMuteExpiry is an optional RFC3339 timestamp. The findings
+	// are unmuted on the first sync after this time.
+	annotationMuteExpiry = "gatekeeper-securitycenter/mute-expiry"
+
+	// defaultMuteReason is used if the mute-reason annotation is empty, since
+	// a non-empty MuteReason source property marks the finding as muted
+	defaultMuteReason = "muted by annotation " + annotationMute
+)
+
+// getMute returns the mute reason and expiry from the resource annotations.
+// The reason is empty if the resource isn't muted, or if the mute expired.
+func getMute(log logr.Logger, resource *unstructured.Unstructured, now time.Time) (string, time.Time) {
+	annotations := resource.GetAnnotations()
+	muteValue, exists := annotations[annotationMute]
+	if !exists {
+		return "", time.Time{}
+	}
+	mute, err := strconv.ParseBool(muteValue)
+	if err != nil {
+		log.Error(err, "invalid annotation value, ignoring", "annotation", annotationMute, "value", muteValue, "resource", resource.GetName(), "namespace", resource.GetNamespace())
+		return "", time.Time{}
+	}
+	if !mute {
+		return "", time.Time{}
+	}
+	var expiry time.Time
+	if expiryValue := annotations[annotationMuteExpiry]; expiryValue != "" {
+		expiry, err = time.Parse(time.RFC3339, expiryValue)
+		if err != nil {
+			log.Error(fmt.Errorf("invalid RFC3339 timestamp: %w", err), "invalid annotation value, ignoring mute", "annotation", annotationMuteExpiry, "value", expiryValue, "resource", resource.GetName(), "namespace", resource.GetNamespace())
+			return "", time.Time{}
+		}
+		if !now.Before(expiry) {
+			log.V(1).Info("mute expired", "resource", resource.GetName(), "namespace", resource.GetNamespace(), "expiry", expiryValue)
+			return "", time.Time{}
+		}
+	}
+	reason := annotations[annotationMuteReason]
+	if reason == "" {
+		reason = defaultMuteReason
+	}
+	return reason, expiry
+}
@@ -0,0 +1,117 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_getMute(t *testing.T) {
+	now := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantReason  string
+		wantExpiry  time.Time
+	}{
+		{
+			name: "no annotations",
+		},
+		{
+			name: "mute with reason and expiry",
+			annotations: map[string]string{
+				annotationMute:       "true",
+				annotationMuteReason: "accepted risk, see ticket 123",
+				annotationMuteExpiry: "2021-04-01T00:00:00Z",
+			},
+			wantReason: "accepted risk, see ticket 123",
+			wantExpiry: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "mute without reason uses default reason",
+			annotations: map[string]string{
+				annotationMute: "true",
+			},
+			wantReason: defaultMuteReason,
+		},
+		{
+			name: "mute false",
+			annotations: map[string]string{
+				annotationMute:       "false",
+				annotationMuteReason: "accepted risk",
+			},
+		},
+		{
+			name: "mute expired",
+			annotations: map[string]string{
+				annotationMute:       "true",
+				annotationMuteExpiry: "2021-02-01T00:00:00Z",
+			},
+		},
+		{
+			name: "invalid expiry is ignored",
+			annotations: map[string]string{
+				annotationMute:       "true",
+				annotationMuteExpiry: "next week",
+			},
+		},
+		{
+			name: "invalid mute value is ignored",
+			annotations: map[string]string{
+				annotationMute: "please",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			resource.SetAnnotations(tt.annotations)
+			gotReason, gotExpiry := getMute(testr.New(t), resource, now)
+			if gotReason != tt.wantReason {
+				t.Errorf("getMute() reason = %q, want %q", gotReason, tt.wantReason)
+			}
+			if !gotExpiry.Equal(tt.wantExpiry) {
+				t.Errorf("getMute() expiry = %v, want %v", gotExpiry, tt.wantExpiry)
+			}
+		})
+	}
+}
+
+func TestClient_createFindingRequest_mute(t *testing.T) {
+	client := &Client{log: testr.New(t), host: host, source: source, cluster: cluster}
+	resource := &Resource{
+		UID:        "resourceUID",
+		MuteReason: "accepted risk",
+		MuteExpiry: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+	}
+	req := client.createFindingRequest(&Constraint{}, resource)
+	if got := req.Finding.SourceProperties["MuteReason"].GetStringValue(); got != "accepted risk" {
+		t.Errorf("MuteReason = %q, want %q", got, "accepted risk")
+	}
+	if got := req.Finding.SourceProperties["MuteExpiry"].GetStringValue(); got != "2021-04-01T00:00:00Z" {
+		t.Errorf("MuteExpiry = %q, want %q", got, "2021-04-01T00:00:00Z")
+	}
+	unmuted := client.createFindingRequest(&Constraint{}, &Resource{UID: "resourceUID"})
+	if _, exists := unmuted.Finding.SourceProperties["MuteReason"]; exists {
+		t.Error("expected no MuteReason source property for resource without mute annotations")
+	}
+	if determineFindingID(&Constraint{}, resource) != determineFindingID(&Constraint{}, &Resource{UID: "resourceUID"}) {
+		t.Error("expected mute annotations to not change the finding ID")
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	// the genproto package doesn't alias Kubernetes_Object
+	sccpb "cloud.google.com/go/securitycenter/apiv1/securitycenterpb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// maxOwnerDepth limits the walk of ownerReferences chains, e.g.,
+	// Pod -> Job -> CronJob is a depth of 2
+	maxOwnerDepth = 5
+
+	// maxAffectedObjects limits the objects in the Kubernetes field of
+	// findings that aggregate violations
+	maxAffectedObjects = 100
+)
+
+// ObjectReference identifies a Kubernetes object that violates a constraint
+type ObjectReference struct {
+	GVK       schema.GroupVersionKind
+	Namespace string
+	Name      string
+}
+
+// String returns the reference in the format `kind/namespace/name`, or
+// `kind/name` for cluster-scoped objects
+func (o ObjectReference) String() string {
+	if o.Namespace == "" {
+		return o.GVK.Kind + "/" + o.Name
+	}
+	return o.GVK.Kind + "/" + o.Namespace + "/" + o.Name
+}
+
+// SetAggregateOwners enables grouping the violations of each constraint by
+// the top-level controller of the violating objects, found by walking the
+// ownerReferences chains. For example, the violations of a Deployment, its
+// ReplicaSets, and its Pods result in one finding for the Deployment.
+func (c *Client) SetAggregateOwners(enabled bool) {
+	c.aggregateOwners = enabled
+}
+
+// ownerGroup is the set of violating objects that have the same top-level owner
+type ownerGroup struct {
+	owner    *unstructured.Unstructured
+	affected []ObjectReference
+	messages map[string]string // violation messages keyed by affected object
+}
+
+// ownerGroups groups the violating objects of a constraint by their top-level owner
+type ownerGroups struct {
+	groups map[types.UID]*ownerGroup
+	// cache of owner lookups for the current sync, keyed by UID
+	cache map[types.UID]*unstructured.Unstructured
+}
+
+func newOwnerGroups(cache map[types.UID]*unstructured.Unstructured) *ownerGroups {
+	return &ownerGroups{
+		groups: map[types.UID]*ownerGroup{},
+		cache:  cache,
+	}
+}
+
+// add the violating object to the group of its top-level owner
+func (o *ownerGroups) add(owner *unstructured.Unstructured, obj *unstructured.Unstructured, message string) {
+	group, exists := o.groups[owner.GetUID()]
+	if !exists {
+		group = &ownerGroup{owner: owner, messages: map[string]string{}}
+		o.groups[owner.GetUID()] = group
+	}
+	ref := ObjectReference{GVK: obj.GroupVersionKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	if _, exists := group.messages[ref.String()]; exists {
+		return
+	}
+	group.affected = append(group.affected, ref)
+	group.messages[ref.String()] = message
+}
+
+// resources returns one resource per top-level owner, sorted by owner UID
+func (o *ownerGroups) resources(ctx context.Context, c *Client) []*Resource {
+	var uids []string
+	for uid := range o.groups {
+		uids = append(uids, string(uid))
+	}
+	sort.Strings(uids)
+	var resources []*Resource
+	for _, uid := range uids {
+		group := o.groups[types.UID(uid)]
+		sort.Slice(group.affected, func(i, j int) bool {
+			return group.affected[i].String() < group.affected[j].String()
+		})
+		message := group.messages[group.affected[0].String()]
+		if len(group.affected) > 1 {
+			message = fmt.Sprintf("%d objects owned by %s %s violate the constraint, %s: %s", len(group.affected), group.owner.GetKind(), group.owner.GetName(), group.affected[0], message)
+		}
+		resource := c.newResource(ctx, group.owner, message)
+		resource.Affected = group.affected
+		resources = append(resources, resource)
+	}
+	return resources
+}
+
+// topLevelOwner walks the controller ownerReferences of the object, and
+// returns the top-level owner. Returns the object itself if it doesn't have
+// a controller. If an owner can't be found, for instance because of missing
+// permissions, the walk stops at the last object that was found.
+func (c *Client) topLevelOwner(ctx context.Context, obj *unstructured.Unstructured, kindToGVR map[string][]schema.GroupVersionResource, cache map[types.UID]*unstructured.Unstructured) *unstructured.Unstructured {
+	current := obj
+	for depth := 0; depth < maxOwnerDepth; depth++ {
+		ref := metav1.GetControllerOfNoCopy(current)
+		if ref == nil {
+			return current
+		}
+		if owner, exists := cache[ref.UID]; exists {
+			current = owner
+			continue
+		}
+		owner, err := c.getOwner(ctx, current.GetNamespace(), ref, kindToGVR)
+		if err != nil {
+			c.log.V(1).Info("could not get owner, using the last object found as the top-level owner", "kind", current.GetKind(), "namespace", current.GetNamespace(), "name", current.GetName(), "ownerKind", ref.Kind, "ownerName", ref.Name, "error", err.Error())
+			return current
+		}
+		cache[ref.UID] = owner
+		current = owner
+	}
+	return current
+}
+
+// getOwner returns the object of the owner reference. Owners are in the same
+// namespace as the object, or cluster-scoped.
+func (c *Client) getOwner(ctx context.Context, namespace string, ref *metav1.OwnerReference, kindToGVR map[string][]schema.GroupVersionResource) (*unstructured.Unstructured, error) {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return nil, err
+	}
+	var gvrs []schema.GroupVersionResource
+	for _, gvr := range kindToGVR[ref.Kind] {
+		if gvr.Group == gv.Group {
+			gvrs = append(gvrs, gvr)
+		}
+	}
+	kindToOwnerGVR := map[string][]schema.GroupVersionResource{ref.Kind: gvrs}
+	owner, err := c.dynamicClient.GetResourceByKind(ctx, ref.Kind, ref.Name, namespace, kindToOwnerGVR)
+	if err != nil && namespace != "" {
+		owner, err = c.dynamicClient.GetResourceByKind(ctx, ref.Kind, ref.Name, "", kindToOwnerGVR)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if owner.GetUID() != ref.UID {
+		return nil, fmt.Errorf("owner %s %s has UID %s, want %s", ref.Kind, ref.Name, owner.GetUID(), ref.UID)
+	}
+	return owner, nil
+}
+
+// affectedObjectsProperty returns the affected objects as a comma-separated
+// list, truncated to the max source property length
+func affectedObjectsProperty(affected []ObjectReference) string {
+	var refs []string
+	for _, ref := range affected {
+		refs = append(refs, ref.String())
+	}
+	return fmt.Sprintf("%.255s", strings.Join(refs, ","))
+}
+
+// kubernetesObjects returns the Kubernetes field of a finding that lists the
+// affected objects, limited to maxAffectedObjects
+func kubernetesObjects(affected []ObjectReference) *sccpb.Kubernetes {
+	kubernetes := &sccpb.Kubernetes{}
+	for _, ref := range affected {
+		if len(kubernetes.Objects) == maxAffectedObjects {
+			break
+		}
+		kubernetes.Objects = append(kubernetes.Objects, &sccpb.Kubernetes_Object{
+			Group: ref.GVK.Group,
+			Kind:  ref.GVK.Kind,
+			Ns:    ref.Namespace,
+			Name:  ref.Name,
+		})
+	}
+	return kubernetes
+}
@@ -0,0 +1,254 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/dynamic"
+)
+
+func newOwnedObject(apiVersion, kind, namespace, name, uid string, owner *unstructured.Unstructured) *unstructured.Unstructured {
+	metadata := map[string]interface{}{
+		"namespace": namespace,
+		"name":      name,
+		"uid":       uid,
+	}
+	if owner != nil {
+		metadata["ownerReferences"] = []interface{}{
+			map[string]interface{}{
+				"apiVersion": owner.GetAPIVersion(),
+				"kind":       owner.GetKind(),
+				"name":       owner.GetName(),
+				"uid":        string(owner.GetUID()),
+				"controller": true,
+			},
+		}
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   metadata,
+		"spec":       map[string]interface{}{},
+	}}
+}
+
+func TestClient_topLevelOwner(t *testing.T) {
+	deployment := newOwnedObject("apps/v1", "Deployment", "default", "app", "deployment-uid", nil)
+	replicaSet := newOwnedObject("apps/v1", "ReplicaSet", "default", "app-5d4f", "replicaset-uid", deployment)
+	pod := newOwnedObject("v1", "Pod", "default", "app-5d4f-x7k2", "pod-uid", replicaSet)
+	standalonePod := newOwnedObject("v1", "Pod", "default", "standalone", "standalone-uid", nil)
+	cache := map[types.UID]*unstructured.Unstructured{
+		deployment.GetUID(): deployment,
+		replicaSet.GetUID(): replicaSet,
+	}
+	tests := []struct {
+		name string
+		obj  *unstructured.Unstructured
+		want types.UID
+	}{
+		{
+			name: "pod owned by replicaset owned by deployment",
+			obj:  pod,
+			want: deployment.GetUID(),
+		},
+		{
+			name: "replicaset owned by deployment",
+			obj:  replicaSet,
+			want: deployment.GetUID(),
+		},
+		{
+			name: "object without owner",
+			obj:  standalonePod,
+			want: standalonePod.GetUID(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{log: testr.New(t)}
+			got := c.topLevelOwner(context.Background(), tt.obj, nil, cache)
+			if got.GetUID() != tt.want {
+				t.Errorf("topLevelOwner() = %s, want %s", got.GetUID(), tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_getViolatingResourcesForConstraint_unresolvedOwner(t *testing.T) {
+	// API server that fails all lookups, e.g., because of timeouts
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "etcdserver: request timed out", http.StatusInternalServerError)
+	}))
+	defer apiServer.Close()
+	dynamicClient, err := dynamic.NewClient(testr.New(t), &rest.Config{Host: apiServer.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	constraint := newPolicyObject("K8sPSPPrivilegedContainer", time.Now(), map[string]interface{}{
+		"violations": []interface{}{
+			map[string]interface{}{"kind": "Pod", "namespace": "default", "name": "app-5d4f-x7k2", "message": "privileged container"},
+		},
+	})
+	kindToGVR := map[string][]schema.GroupVersionResource{"Pod": {{Version: "v1", Resource: "pods"}}}
+	c := &Client{log: testr.New(t), dynamicClient: dynamicClient}
+	c.SetAggregateOwners(true)
+	unresolved := newUnresolvedViolations()
+
+	resources := c.getViolatingResourcesForConstraint(context.Background(), constraint, kindToGVR, unresolved, map[types.UID]*unstructured.Unstructured{})
+
+	if len(resources) != 0 {
+		t.Errorf("getViolatingResourcesForConstraint() returned %d resources, want 0", len(resources))
+	}
+	ownerFinding := &securitycenterpb.Finding{
+		State: securitycenterpb.Finding_ACTIVE,
+		SourceProperties: map[string]*structpb.Value{
+			"ConstraintUID":     structpb.NewStringValue(string(constraint.GetUID())),
+			"ResourceKind":      structpb.NewStringValue("Deployment"),
+			"ResourceNamespace": structpb.NewStringValue("default"),
+			"ResourceName":      structpb.NewStringValue("app"),
+		},
+	}
+	if !unresolved.preserves(ownerFinding) {
+		t.Errorf("preserves() = false for the finding of the top-level owner of an unresolved violation, want true")
+	}
+}
+
+func TestClient_getViolatingResourcesForConstraint_removedKind(t *testing.T) {
+	// the API server isn't called for kinds without mappings
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "etcdserver: request timed out", http.StatusInternalServerError)
+	}))
+	defer apiServer.Close()
+	dynamicClient, err := dynamic.NewClient(testr.New(t), &rest.Config{Host: apiServer.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	constraint := newPolicyObject("K8sRequiredLabels", time.Now(), map[string]interface{}{
+		"violations": []interface{}{
+			map[string]interface{}{"group": "example.com", "version": "v1", "kind": "Widget", "namespace": "default", "name": "w", "message": "you must provide labels"},
+		},
+	})
+	for _, aggregateOwners := range []bool{false, true} {
+		c := &Client{log: testr.New(t), dynamicClient: dynamicClient}
+		c.SetAggregateOwners(aggregateOwners)
+		unresolved := newUnresolvedViolations()
+
+		// no mappings for the Widget kind, e.g., because the CRD was removed
+		resources := c.getViolatingResourcesForConstraint(context.Background(), constraint, map[string][]schema.GroupVersionResource{}, unresolved, map[types.UID]*unstructured.Unstructured{})
+
+		if len(resources) != 0 {
+			t.Errorf("aggregateOwners=%v: getViolatingResourcesForConstraint() returned %d resources, want 0", aggregateOwners, len(resources))
+		}
+		if unresolved.count() != 0 {
+			t.Errorf("aggregateOwners=%v: unresolved count = %d, want 0 for a removed kind", aggregateOwners, unresolved.count())
+		}
+	}
+}
+
+func Test_ownerGroups_resources(t *testing.T) {
+	deployment := newOwnedObject("apps/v1", "Deployment", "default", "app", "deployment-uid", nil)
+	replicaSet := newOwnedObject("apps/v1", "ReplicaSet", "default", "app-5d4f", "replicaset-uid", deployment)
+	pod1 := newOwnedObject("v1", "Pod", "default", "app-5d4f-b", "pod-uid-1", replicaSet)
+	pod2 := newOwnedObject("v1", "Pod", "default", "app-5d4f-a", "pod-uid-2", replicaSet)
+	standalonePod := newOwnedObject("v1", "Pod", "default", "standalone", "standalone-uid", nil)
+
+	groups := newOwnerGroups(map[types.UID]*unstructured.Unstructured{})
+	groups.add(deployment, pod1, "privileged container")
+	groups.add(deployment, pod2, "privileged container")
+	groups.add(deployment, pod2, "privileged container") // duplicate violation
+	groups.add(standalonePod, standalonePod, "privileged container")
+
+	c := &Client{log: testr.New(t)}
+	got := groups.resources(context.Background(), c)
+
+	podGVK := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	type resourceSummary struct {
+		UID      types.UID
+		Message  string
+		Affected []ObjectReference
+	}
+	want := []resourceSummary{
+		{
+			UID:     deployment.GetUID(),
+			Message: "2 objects owned by Deployment app violate the constraint, Pod/default/app-5d4f-a: privileged container",
+			Affected: []ObjectReference{
+				{GVK: podGVK, Namespace: "default", Name: "app-5d4f-a"},
+				{GVK: podGVK, Namespace: "default", Name: "app-5d4f-b"},
+			},
+		},
+		{
+			UID:     standalonePod.GetUID(),
+			Message: "privileged container",
+			Affected: []ObjectReference{
+				{GVK: podGVK, Namespace: "default", Name: "standalone"},
+			},
+		},
+	}
+	var gotSummaries []resourceSummary
+	for _, resource := range got {
+		gotSummaries = append(gotSummaries, resourceSummary{UID: resource.UID, Message: resource.Message, Affected: resource.Affected})
+	}
+	if diff := cmp.Diff(want, gotSummaries); diff != "" {
+		t.Errorf("resources() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_affectedObjectsProperty(t *testing.T) {
+	podGVK := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	nsGVK := schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+	got := affectedObjectsProperty([]ObjectReference{
+		{GVK: podGVK, Namespace: "default", Name: "pod-1"},
+		{GVK: nsGVK, Name: "default"},
+	})
+	if want := "Pod/default/pod-1,Namespace/default"; got != want {
+		t.Errorf("affectedObjectsProperty() = %q, want %q", got, want)
+	}
+
+	var many []ObjectReference
+	for i := 0; i < 50; i++ {
+		many = append(many, ObjectReference{GVK: podGVK, Namespace: "default", Name: strings.Repeat("p", 10)})
+	}
+	if got := affectedObjectsProperty(many); len(got) != 255 {
+		t.Errorf("len(affectedObjectsProperty()) = %d, want 255", len(got))
+	}
+}
+
+func Test_kubernetesObjects(t *testing.T) {
+	var affected []ObjectReference
+	for i := 0; i < maxAffectedObjects+10; i++ {
+		affected = append(affected, ObjectReference{GVK: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ReplicaSet"}, Namespace: "default", Name: "rs"})
+	}
+	got := kubernetesObjects(affected)
+	if len(got.Objects) != maxAffectedObjects {
+		t.Errorf("len(kubernetesObjects().Objects) = %d, want %d", len(got.Objects), maxAffectedObjects)
+	}
+	if got.Objects[0].Group != "apps" || got.Objects[0].Kind != "ReplicaSet" || got.Objects[0].Ns != "default" || got.Objects[0].Name != "rs" {
+		t.Errorf("kubernetesObjects().Objects[0] = %+v", got.Objects[0])
+	}
+}
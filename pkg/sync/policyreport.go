@@ -0,0 +1,195 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+// PolicyReportScannerName is the ScannerName source property of findings
+// created from policy reports, see securitycenter.PolicyReportScannerName
+const PolicyReportScannerName = securitycenter.PolicyReportScannerName
+
+// policyReportResults are the results of policy report entries that become
+// findings. The results pass, skip, and error don't.
+var policyReportResults = map[string]bool{
+	"fail": true,
+	"warn": true,
+}
+
+// policyReportSeverities maps policy report severities to finding
+// severities. The severity info, and missing severities, are unspecified.
+var policyReportSeverities = map[string]securitycenterpb.Finding_Severity{
+	"critical": securitycenterpb.Finding_CRITICAL,
+	"high":     securitycenterpb.Finding_HIGH,
+	"medium":   securitycenterpb.Finding_MEDIUM,
+	"low":      securitycenterpb.Finding_LOW,
+}
+
+// SetPolicyReports enables findings for the failed and warned results in
+// the wgpolicyk8s.io PolicyReports and ClusterPolicyReports, e.g., as
+// published by Kyverno. Disabled by default.
+func (c *Client) SetPolicyReports(enabled bool) {
+	c.policyReports = enabled
+}
+
+// policyReportResult is a failed or warned result of a policy report entry
+// for one resource
+type policyReportResult struct {
+	source         string
+	policy         string
+	rule           string
+	result         string
+	severity       string
+	category       string
+	message        string
+	timestamp      time.Time
+	reportSelfLink string
+	// resource is the object reference of the resource, with the fields
+	// apiVersion, kind, namespace, name, and uid
+	resource map[string]interface{}
+}
+
+// getPolicyReportFindingRequests returns finding requests for the failed
+// and warned results in the policy reports, keyed by full finding name.
+// Results for resources that can't be resolved are recorded in unresolved.
+func (c *Client) getPolicyReportFindingRequests(ctx context.Context, kindToGVR map[string][]schema.GroupVersionResource, unresolved *unresolvedViolations) (map[string]*securitycenterpb.CreateFindingRequest, error) {
+	reports, err := c.dynamicClient.GetPolicyReports(ctx, kindToGVR)
+	if err != nil {
+		return nil, err
+	}
+	findingRequests := map[string]*securitycenterpb.CreateFindingRequest{}
+	for i := range reports {
+		for _, result := range parsePolicyReport(c.log, &reports[i], kindToGVR) {
+			if namespace, _, _ := unstructured.NestedString(result.resource, "namespace"); !c.filters.includesNamespace(namespace) {
+				c.log.V(1).Info("skipping policy report result in namespace excluded by filters", "namespace", namespace)
+				continue
+			}
+			obj, err := c.getViolatingObject(ctx, result.resource, kindToGVR)
+			switch {
+			case apierrors.IsNotFound(err):
+				c.log.V(1).Info("skipping policy report result of deleted resource", "error", err.Error())
+				continue
+			case err != nil:
+				c.log.Error(err, "could not resolve policy report result, preserving existing findings", "policy", result.policy, "rule", result.rule)
+				unresolved.addViolation(result.constraint().UID, result.resource)
+				continue
+			}
+			req := c.createPolicyReportFindingRequest(result, c.newResource(ctx, obj, result.message))
+			findingRequests[fmt.Sprintf("%s/findings/%s", req.Parent, req.FindingId)] = req
+		}
+	}
+	return findingRequests, nil
+}
+
+// parsePolicyReport returns the failed and warned results of the policy
+// report, with one result per resource. Results without resources apply to
+// the scope of the report.
+func parsePolicyReport(log logr.Logger, report *unstructured.Unstructured, kindToGVR map[string][]schema.GroupVersionResource) []*policyReportResult {
+	reportSelfLink := objectPath(report.GroupVersionKind(), report.GetNamespace(), report.GetName(), kindToGVR)
+	scope, _, _ := unstructured.NestedMap(report.UnstructuredContent(), "scope")
+	rawResults, _, _ := unstructured.NestedSlice(report.UnstructuredContent(), "results")
+	var results []*policyReportResult
+	for _, rawResult := range rawResults {
+		entry, ok := rawResult.(map[string]interface{})
+		if !ok {
+			log.Error(fmt.Errorf("could not cast policy report result to map[string]interface{}: %+v", rawResult), "skipping policy report result")
+			continue
+		}
+		result, _, _ := unstructured.NestedString(entry, "result")
+		if !policyReportResults[result] {
+			continue
+		}
+		resources, _, _ := unstructured.NestedSlice(entry, "resources")
+		if len(resources) == 0 && scope != nil {
+			resources = []interface{}{scope}
+		}
+		for _, rawResource := range resources {
+			resource, ok := rawResource.(map[string]interface{})
+			if !ok {
+				log.Error(fmt.Errorf("could not cast policy report resource to map[string]interface{}: %+v", rawResource), "skipping policy report resource")
+				continue
+			}
+			parsed := &policyReportResult{
+				result:         result,
+				reportSelfLink: reportSelfLink,
+				resource:       resource,
+			}
+			parsed.source, _, _ = unstructured.NestedString(entry, "source")
+			parsed.policy, _, _ = unstructured.NestedString(entry, "policy")
+			parsed.rule, _, _ = unstructured.NestedString(entry, "rule")
+			parsed.severity, _, _ = unstructured.NestedString(entry, "severity")
+			parsed.category, _, _ = unstructured.NestedString(entry, "category")
+			parsed.message, _, _ = unstructured.NestedString(entry, "message")
+			if seconds, exists, _ := unstructured.NestedInt64(entry, "timestamp", "seconds"); exists {
+				nanos, _, _ := unstructured.NestedInt64(entry, "timestamp", "nanos")
+				parsed.timestamp = time.Unix(seconds, nanos).UTC()
+			}
+			results = append(results, parsed)
+		}
+	}
+	return results
+}
+
+// constraint returns the Constraint for the policy rule of the result. The
+// constraint UID identifies the policy rule, since policy reports don't
+// reference the policy objects by UID.
+func (r *policyReportResult) constraint() *Constraint {
+	return &Constraint{
+		Name:      r.policy,
+		SelfLink:  r.reportSelfLink,
+		UID:       types.UID(strings.Join([]string{PolicyReportScannerName, r.source, r.policy, r.rule}, "/")),
+		Kind:      r.policy,
+		AuditTime: r.timestamp,
+	}
+}
+
+// createPolicyReportFindingRequest creates a CreateFindingRequest for the
+// policy report result, using the same source properties as findings for
+// Gatekeeper audit violations, where they apply
+func (c *Client) createPolicyReportFindingRequest(result *policyReportResult, resource *Resource) *securitycenterpb.CreateFindingRequest {
+	req := c.createFindingRequest(result.constraint(), resource)
+	req.Finding.Severity = policyReportSeverities[result.severity]
+	properties := req.Finding.SourceProperties
+	// policy reports don't reference constraint templates
+	delete(properties, "ConstraintTemplateSelfLink")
+	delete(properties, "ConstraintTemplateUID")
+	for key, value := range map[string]string{
+		"ScannerName":    PolicyReportScannerName,
+		"PolicySource":   result.source,
+		"PolicyName":     result.policy,
+		"PolicyRule":     result.rule,
+		"PolicyResult":   result.result,
+		"PolicySeverity": result.severity,
+		"PolicyCategory": result.category,
+	} {
+		// each source property value must be max 255 chars
+		properties[key] = &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: fmt.Sprintf("%.255s", value)}}
+	}
+	return req
+}
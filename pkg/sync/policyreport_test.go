@@ -0,0 +1,195 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var policyReportKindToGVR = map[string][]schema.GroupVersionResource{
+	"PolicyReport":        {{Group: "wgpolicyk8s.io", Version: "v1alpha2", Resource: "policyreports"}},
+	"ClusterPolicyReport": {{Group: "wgpolicyk8s.io", Version: "v1alpha2", Resource: "clusterpolicyreports"}},
+}
+
+// newPolicyReport returns a Kyverno PolicyReport in the default namespace
+// with the scope and results
+func newPolicyReport(scope map[string]interface{}, results ...interface{}) *unstructured.Unstructured {
+	report := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "wgpolicyk8s.io/v1alpha2",
+		"kind":       "PolicyReport",
+		"metadata": map[string]interface{}{
+			"name":      "report",
+			"namespace": "default",
+		},
+		"results": results,
+	}}
+	if scope != nil {
+		report.Object["scope"] = scope
+	}
+	return report
+}
+
+// newPolicyReportResult returns a Kyverno policy report result for the
+// require-labels policy
+func newPolicyReportResult(rule, result string, resources ...interface{}) map[string]interface{} {
+	entry := map[string]interface{}{
+		"source":    "kyverno",
+		"policy":    "require-labels",
+		"rule":      rule,
+		"result":    result,
+		"severity":  "medium",
+		"category":  "Best Practices",
+		"message":   "label 'team' is required",
+		"timestamp": map[string]interface{}{"seconds": int64(1614564184), "nanos": int64(0)},
+	}
+	if len(resources) > 0 {
+		entry["resources"] = resources
+	}
+	return entry
+}
+
+func podReference(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"namespace":  "default",
+		"name":       name,
+		"uid":        name + "-uid",
+	}
+}
+
+func Test_parsePolicyReport(t *testing.T) {
+	tests := []struct {
+		name          string
+		report        *unstructured.Unstructured
+		wantResources []string
+		wantResults   []string
+	}{
+		{
+			name: "failed and warned results",
+			report: newPolicyReport(nil,
+				newPolicyReportResult("check-team", "fail", podReference("pod-1"), podReference("pod-2")),
+				newPolicyReportResult("check-env", "warn", podReference("pod-1")),
+				newPolicyReportResult("check-owner", "pass", podReference("pod-1")),
+				newPolicyReportResult("check-cost-center", "skip", podReference("pod-1")),
+				newPolicyReportResult("check-app", "error", podReference("pod-1")),
+			),
+			wantResources: []string{"pod-1", "pod-2", "pod-1"},
+			wantResults:   []string{"fail", "fail", "warn"},
+		},
+		{
+			name: "results for the scope of the report",
+			report: newPolicyReport(podReference("pod-3"),
+				newPolicyReportResult("check-team", "fail"),
+			),
+			wantResources: []string{"pod-3"},
+			wantResults:   []string{"fail"},
+		},
+		{
+			name: "results without resources or scope",
+			report: newPolicyReport(nil,
+				newPolicyReportResult("check-team", "fail"),
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := parsePolicyReport(testr.New(t), tt.report, policyReportKindToGVR)
+			var gotResources, gotResults []string
+			for _, result := range results {
+				name, _, _ := unstructured.NestedString(result.resource, "name")
+				gotResources = append(gotResources, name)
+				gotResults = append(gotResults, result.result)
+				if result.reportSelfLink != "/apis/wgpolicyk8s.io/v1alpha2/namespaces/default/policyreports/report" {
+					t.Errorf("parsePolicyReport() reportSelfLink = %s", result.reportSelfLink)
+				}
+				if want := time.Date(2021, 3, 1, 2, 3, 4, 0, time.UTC); !result.timestamp.Equal(want) {
+					t.Errorf("parsePolicyReport() timestamp = %v, want %v", result.timestamp, want)
+				}
+			}
+			if diff := cmp.Diff(tt.wantResources, gotResources); diff != "" {
+				t.Errorf("parsePolicyReport() resources mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantResults, gotResults); diff != "" {
+				t.Errorf("parsePolicyReport() results mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestClient_createPolicyReportFindingRequest(t *testing.T) {
+	report := newPolicyReport(nil,
+		newPolicyReportResult("check-team", "fail", podReference("pod-1")),
+		newPolicyReportResult("check-env", "fail", podReference("pod-1")),
+	)
+	results := parsePolicyReport(testr.New(t), report, policyReportKindToGVR)
+	if len(results) != 2 {
+		t.Fatalf("parsePolicyReport() returned %d results, want 2", len(results))
+	}
+	resource := &Resource{
+		Name:      "pod-1",
+		Namespace: "default",
+		GVK:       schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		SelfLink:  "/api/v1/namespaces/default/pods/pod-1",
+		UID:       types.UID("pod-1-uid"),
+		Message:   "label 'team' is required",
+	}
+	c := &Client{log: testr.New(t), host: host, source: source, cluster: cluster, findingIDStrategy: FindingIDStrategySpec}
+	req := c.createPolicyReportFindingRequest(results[0], resource)
+
+	if req.Finding.Category != "require-labels" {
+		t.Errorf("Category = %s, want require-labels", req.Finding.Category)
+	}
+	if req.Finding.Severity != securitycenterpb.Finding_MEDIUM {
+		t.Errorf("Severity = %v, want %v", req.Finding.Severity, securitycenterpb.Finding_MEDIUM)
+	}
+	if want := host + "/apis/wgpolicyk8s.io/v1alpha2/namespaces/default/policyreports/report"; req.Finding.ExternalUri != want {
+		t.Errorf("ExternalUri = %s, want %s", req.Finding.ExternalUri, want)
+	}
+	wantProperties := map[string]string{
+		"ScannerName":                PolicyReportScannerName,
+		"Explanation":                "label 'team' is required",
+		"Cluster":                    cluster,
+		"ConstraintName":             "require-labels",
+		"ConstraintUID":              "POLICY_REPORT/kyverno/require-labels/check-team",
+		"ConstraintTemplateSelfLink": "",
+		"ConstraintTemplateUID":      "",
+		"PolicySource":               "kyverno",
+		"PolicyName":                 "require-labels",
+		"PolicyRule":                 "check-team",
+		"PolicyResult":               "fail",
+		"PolicySeverity":             "medium",
+		"PolicyCategory":             "Best Practices",
+		"ResourceKind":               "Pod",
+		"ResourceName":               "pod-1",
+		"ResourceUID":                "pod-1-uid",
+	}
+	for key, want := range wantProperties {
+		if got := property(req.Finding, key); got != want {
+			t.Errorf("source property %s = %q, want %q", key, got, want)
+		}
+	}
+	if other := c.createPolicyReportFindingRequest(results[1], resource); other.FindingId == req.FindingId {
+		t.Errorf("FindingId is the same for different rules of the same policy")
+	}
+}
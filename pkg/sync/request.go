@@ -25,6 +25,8 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+
+	securitycenterclient "github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
 )
 
 const scannerName = "GATEKEEPER"
@@ -38,17 +40,31 @@ type Resource struct {
 	UID            types.UID
 	ProjectID      string
 	StatusSelfLink string
-	Message        string
-	SpecJSON       string
+	// AssetName is the Cloud Asset Inventory name of the Google Cloud
+	// resource managed by a Config Connector resource, empty if unresolved
+	AssetName string
+	Message   string
+	SpecJSON  string
+	// MuteReason from annotations, empty if the findings shouldn't be muted
+	MuteReason string
+	// MuteExpiry from annotations, zero value if the mute doesn't expire
+	MuteExpiry time.Time
+	// SecurityMarks from allow-listed labels, empty values for missing labels
+	SecurityMarks map[string]string
+	// Affected are the violating objects aggregated into the finding for
+	// this resource, empty if the finding is for a single violation
+	Affected []ObjectReference
 }
 
 // Constraint holds the constraint-related values used to create a finding request
 type Constraint struct {
-	Name             string
-	SelfLink         string
-	UID              types.UID
-	Kind             string
-	AuditTime        time.Time
+	Name      string
+	SelfLink  string
+	UID       types.UID
+	Kind      string
+	AuditTime time.Time
+	// AuditStale is true if the last audit is older than the staleness threshold
+	AuditStale       bool
 	SpecJSON         string
 	TemplateUID      types.UID
 	TemplateSelfLink string
@@ -69,9 +85,13 @@ func (c *Client) createFindingRequest(constraint *Constraint, resource *Resource
 	// Limit message to 255 chars
 	message := fmt.Sprintf("%.255s", resource.Message)
 
-	// Use the status.selfLink attribute as the ResourceName if available.
-	// This gives users a direct link from SCC to the resource in the web Cloud Console.
-	resourceName := resourceStatusSelfLink
+	// Use the Cloud Asset Inventory name, or the status.selfLink attribute,
+	// as the ResourceName if available. This lets SCC link the finding to the
+	// Google Cloud resource.
+	resourceName := resource.AssetName
+	if resourceName == "" {
+		resourceName = resourceStatusSelfLink
+	}
 	if resourceName == "" {
 		resourceName = resourceSelfLink
 	}
@@ -80,8 +100,8 @@ func (c *Client) createFindingRequest(constraint *Constraint, resource *Resource
 		// use audit time if not zero value
 		eventTime = timestamppb.New(constraint.AuditTime)
 	}
-	ID := determineFindingID(constraint, resource)
-	return &securitycenter.CreateFindingRequest{
+	ID := c.findingID(c.cluster, constraint, resource)
+	req := &securitycenter.CreateFindingRequest{
 		Parent:    c.source,
 		FindingId: ID,
 		Finding: &securitycenter.Finding{
@@ -112,6 +132,49 @@ func (c *Client) createFindingRequest(constraint *Constraint, resource *Resource
 			},
 		},
 	}
+	if resource.AssetName != "" {
+		req.Finding.SourceProperties[securitycenterclient.ResourceAssetNameProperty] = &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: fmt.Sprintf("%.255s", resource.AssetName)}}
+	}
+	if len(resource.Affected) > 0 {
+		req.Finding.SourceProperties[securitycenterclient.AffectedObjectCountProperty] = &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: float64(len(resource.Affected))}}
+		req.Finding.SourceProperties[securitycenterclient.AffectedObjectsProperty] = &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: affectedObjectsProperty(resource.Affected)}}
+		if namespaces := affectedNamespacesProperty(resource.Affected); namespaces != "" {
+			req.Finding.SourceProperties[securitycenterclient.AffectedNamespacesProperty] = &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: namespaces}}
+		}
+		req.Finding.Kubernetes = kubernetesObjects(resource.Affected)
+	}
+	for name, link := range c.createLinks(constraint, resource) {
+		if name == ExternalURILink {
+			req.Finding.ExternalUri = link
+			continue
+		}
+		req.Finding.SourceProperties[linkPropertyName(name)] = &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: link}}
+	}
+	if len(resource.SecurityMarks) > 0 || c.markStaleAudits {
+		// Security Command Center adds the security marks, see SyncFindings
+		marks := map[string]string{}
+		for key, value := range resource.SecurityMarks {
+			marks[key] = value
+		}
+		if c.markStaleAudits {
+			// an empty value removes the mark when the audit is no longer stale
+			marks[AuditStaleMark] = ""
+			if constraint.AuditStale {
+				marks[AuditStaleMark] = "true"
+			}
+		}
+		req.Finding.SecurityMarks = &securitycenter.SecurityMarks{Marks: marks}
+	}
+	if resource.MuteReason != "" {
+		// Security Command Center mutes the finding, see SyncFindings
+		var muteExpiry string
+		if !resource.MuteExpiry.IsZero() {
+			muteExpiry = resource.MuteExpiry.UTC().Format(time.RFC3339)
+		}
+		req.Finding.SourceProperties[securitycenterclient.MuteReasonProperty] = &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: fmt.Sprintf("%.255s", resource.MuteReason)}}
+		req.Finding.SourceProperties[securitycenterclient.MuteExpiryProperty] = &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: muteExpiry}}
+	}
+	return req
 }
 
 // determineFindingID creates a deterministic finding ID
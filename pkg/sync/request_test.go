@@ -22,6 +22,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -49,6 +50,16 @@ var (
 	resourceNameOnly = cmp.Comparer(func(l, r *securitycenterpb.CreateFindingRequest) bool {
 		return l.Finding.ResourceName == r.Finding.ResourceName
 	})
+
+	// affectedObjectsOnly compares two CreateFindingRequest objects on the affected objects source properties and the Finding.Kubernetes fields only
+	affectedObjectsOnly = cmp.Comparer(func(l, r *securitycenterpb.CreateFindingRequest) bool {
+		for _, key := range []string{"AffectedObjectCount", "AffectedObjects"} {
+			if !proto.Equal(l.Finding.SourceProperties[key], r.Finding.SourceProperties[key]) {
+				return false
+			}
+		}
+		return proto.Equal(l.Finding.Kubernetes, r.Finding.Kubernetes)
+	})
 )
 
 func TestClient_createFindingRequest(t *testing.T) {
@@ -141,6 +152,48 @@ func TestClient_createFindingRequest(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "use Cloud Asset Inventory name for KCC resource violation",
+			cmpOptions: []cmp.Option{
+				resourceNameOnly,
+			},
+			constraint: &Constraint{},
+			resource: &Resource{
+				SelfLink:       "/doNotUseThis",
+				StatusSelfLink: "https://www.googleapis.com/storage/v1/b/bucket-name",
+				AssetName:      "//storage.googleapis.com/bucket-name",
+			},
+			want: &securitycenterpb.CreateFindingRequest{
+				Finding: &securitycenterpb.Finding{
+					ResourceName: "//storage.googleapis.com/bucket-name",
+				},
+			},
+		},
+		{
+			name: "list affected objects for aggregated violations",
+			cmpOptions: []cmp.Option{
+				affectedObjectsOnly,
+			},
+			constraint: &Constraint{},
+			resource: &Resource{
+				Affected: []ObjectReference{
+					{GVK: schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, Namespace: "default", Name: "pod-1"},
+					{GVK: schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, Namespace: "default", Name: "pod-2"},
+				},
+			},
+			want: &securitycenterpb.CreateFindingRequest{
+				Finding: &securitycenterpb.Finding{
+					SourceProperties: map[string]*structpb.Value{
+						"AffectedObjectCount": structpb.NewNumberValue(2),
+						"AffectedObjects":     structpb.NewStringValue("Pod/default/pod-1,Pod/default/pod-2"),
+					},
+					Kubernetes: kubernetesObjects([]ObjectReference{
+						{GVK: schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, Namespace: "default", Name: "pod-1"},
+						{GVK: schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, Namespace: "default", Name: "pod-2"},
+					}),
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,93 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"time"
+
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+// Outcomes of a sync
+const (
+	ResultSucceeded = "Succeeded"
+	ResultUnchanged = "Unchanged"
+	ResultPartial   = "Partial"
+	ResultFailed    = "Failed"
+)
+
+// Result summarizes a sync
+type Result struct {
+	// Time when the sync finished
+	Time time.Time
+	// Result is one of ResultSucceeded, ResultUnchanged, ResultPartial, or ResultFailed
+	Result string
+	// Findings is the number of active findings requested by the sync
+	Findings int
+	// Created is the number of new findings
+	Created int
+	// Activated is the number of findings that changed from INACTIVE to ACTIVE
+	Activated int
+	// Deactivated is the number of findings that changed from ACTIVE to INACTIVE
+	Deactivated int
+	// Unresolved is the number of constraints and violations that couldn't be
+	// resolved. Their existing findings keep their state.
+	Unresolved int
+	// Err is the error that caused the sync to fail, nil otherwise
+	Err error
+}
+
+// LastResult returns the summary of the most recent sync. Returns nil before
+// the first sync, and in dry-run mode.
+func (c *Client) LastResult() *Result {
+	return c.lastResult
+}
+
+// newResult summarizes the finding transitions of a sync
+func newResult(now time.Time, findings int, transitions []*securitycenter.Transition, err error) *Result {
+	result := &Result{
+		Time:     now,
+		Result:   ResultSucceeded,
+		Findings: findings,
+		Err:      err,
+	}
+	if err != nil {
+		result.Result = ResultFailed
+	}
+	for _, transition := range transitions {
+		switch {
+		case transition.OldState == securitycenterpb.Finding_STATE_UNSPECIFIED:
+			result.Created++
+		case transition.NewState == securitycenterpb.Finding_ACTIVE:
+			result.Activated++
+		case transition.NewState == securitycenterpb.Finding_INACTIVE:
+			result.Deactivated++
+		}
+	}
+	return result
+}
+
+// withUnresolved records the number of unresolved constraints and
+// violations. A sync without errors that has unresolved constraints or
+// violations is partial.
+func (r *Result) withUnresolved(unresolved int) *Result {
+	r.Unresolved = unresolved
+	if unresolved > 0 && r.Err == nil {
+		r.Result = ResultPartial
+	}
+	return r
+}
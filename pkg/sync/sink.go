@@ -0,0 +1,62 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"context"
+
+	errorutils "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/events"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+// Sink receives the finding transitions from each sync, e.g., to notify other
+// systems when findings are created, reactivated, or resolved.
+type Sink interface {
+	Send(ctx context.Context, transitions []*securitycenter.Transition) error
+}
+
+// AddSink adds a Sink that receives the finding transitions after each sync
+func (c *Client) AddSink(sink Sink) {
+	c.sinks = append(c.sinks, sink)
+}
+
+// EnableEvents adds a Sink that records Kubernetes Events on the violating
+// resources when findings are created, reactivated, or resolved. If
+// includeConstraints is true, the events are also recorded on the
+// constraints.
+func (c *Client) EnableEvents(includeConstraints bool) error {
+	recorder, err := events.New(c.log.WithName("events"), c.config, includeConstraints)
+	if err != nil {
+		return err
+	}
+	c.AddSink(recorder)
+	return nil
+}
+
+// sendTransitions sends the transitions to all sinks, and returns the
+// aggregated errors. All sinks receive the transitions even if some fail.
+// Sinks are called even if there are no transitions, so they can retry
+// earlier failures.
+func (c *Client) sendTransitions(ctx context.Context, transitions []*securitycenter.Transition) error {
+	var errs []error
+	for _, sink := range c.sinks {
+		if err := sink.Send(ctx, transitions); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errorutils.NewAggregate(errs)
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/store"
+)
+
+// SetStore enables change detection between syncs, using a snapshot of the
+// findings that is persisted after each sync. See store.New for the format
+// of the uri.
+//
+// If the findings haven't changed since the last sync, Sync skips listing the
+// findings in Security Command Center, unless the last full sync is older than
+// the resyncInterval. The full sync corrects any changes to findings that were
+// made outside the controller.
+func (c *Client) SetStore(uri string, resyncInterval time.Duration) error {
+	if resyncInterval.Seconds() <= 0 {
+		return fmt.Errorf("invalid resyncInterval: %v", resyncInterval)
+	}
+	findingsStore, err := store.New(c.log.WithName("store"), uri, c.config)
+	if err != nil {
+		return err
+	}
+	c.store = findingsStore
+	c.resyncInterval = resyncInterval
+	return nil
+}
+
+// LastDiff returns the difference between the findings of the most recent
+// sync and the sync before that. Returns nil if there is no store, or if
+// the snapshot could not be loaded.
+func (c *Client) LastDiff() *store.Diff {
+	return c.lastDiff
+}
+
+// loadSnapshot loads the snapshot from the store and computes the diff with
+// the finding requests. Returns true if nothing changed and the last full
+// sync is recent enough to skip syncing with Security Command Center.
+func (c *Client) loadSnapshot(ctx context.Context, findingRequests map[string]*securitycenterpb.CreateFindingRequest) (*store.Snapshot, bool) {
+	c.lastDiff = nil
+	if c.store == nil {
+		return nil, false
+	}
+	snapshot, err := c.store.Load(ctx)
+	if err != nil {
+		c.log.Error(err, "could not load snapshot, syncing all findings")
+		return nil, false
+	}
+	c.lastDiff = snapshot.Compare(findingRequests)
+	c.log.Info("changes since last sync", "added", len(c.lastDiff.Added), "removed", len(c.lastDiff.Removed), "changed", len(c.lastDiff.Changed), "lastSyncTime", snapshot.SyncTime)
+	if c.lastDiff.IsEmpty() && time.Since(snapshot.FullSyncTime) < c.resyncInterval {
+		return snapshot, true
+	}
+	return snapshot, false
+}
+
+// syncFindings syncs the finding requests with Security Command Center, and
+// saves the snapshot. If the sync fails, e.g., because the deactivation
+// limit held deactivations, the findings of the previous snapshot are kept,
+// so the next sync doesn't skip the held deactivations as unchanged.
+//
+// The preserve func records the ACTIVE findings it preserves in preserved,
+// the key is the finding name and the value is the constraint UID. They are
+// recorded as ACTIVE in the snapshot, see Client.preservedFindings.
+func (c *Client) syncFindings(ctx context.Context, previous *store.Snapshot, findingRequests map[string]*securitycenterpb.CreateFindingRequest, preserve securitycenter.PreserveFunc, preserved map[string]string) ([]*securitycenter.Transition, error) {
+	transitions, err := c.securitycenterClient.SyncFindingsPreserving(ctx, c.source, findingRequests, preserve)
+	if err != nil {
+		c.saveHeldDeactivations(ctx, previous)
+		return transitions, err
+	}
+	c.preservedFindings = preserved
+	c.saveSnapshot(ctx, previous, findingRequests, true)
+	return transitions, nil
+}
+
+// saveSnapshot persists a snapshot of the finding requests. Errors are
+// logged, the next sync will list all findings.
+func (c *Client) saveSnapshot(ctx context.Context, previous *store.Snapshot, findingRequests map[string]*securitycenterpb.CreateFindingRequest, fullSync bool) {
+	if c.store == nil {
+		return
+	}
+	now := time.Now()
+	fullSyncTime := now
+	if !fullSync && previous != nil {
+		fullSyncTime = previous.FullSyncTime
+	}
+	snapshot := store.NewSnapshot(previous, findingRequests, now, fullSyncTime)
+	// preserved findings stay ACTIVE in Security Command Center
+	for findingName := range c.preservedFindings {
+		snapshot.KeepActive(findingName)
+	}
+	c.breaker.save(snapshot)
+	if err := c.store.Save(ctx, snapshot); err != nil {
+		c.log.Error(err, "could not save snapshot")
+	}
+}
+
+// saveHeldDeactivations persists the number of consecutive syncs that held
+// deactivations after a failed sync, keeping the findings of the previous
+// snapshot. Errors are logged, the next sync starts counting again.
+func (c *Client) saveHeldDeactivations(ctx context.Context, previous *store.Snapshot) {
+	if c.store == nil || c.breaker == nil || previous == nil {
+		return
+	}
+	c.breaker.save(previous)
+	if err := c.store.Save(ctx, previous); err != nil {
+		c.log.Error(err, "could not save held deactivations")
+	}
+}
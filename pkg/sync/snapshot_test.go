@@ -0,0 +1,202 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"google.golang.org/api/option"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/store"
+)
+
+const testSource = "organizations/123/sources/456"
+
+// fakeSecurityCenter keeps findings in memory, and implements the methods
+// used to sync findings
+type fakeSecurityCenter struct {
+	securitycenterpb.UnimplementedSecurityCenterServer
+	findings map[string]*securitycenterpb.Finding
+}
+
+func (s *fakeSecurityCenter) ListFindings(_ context.Context, _ *securitycenterpb.ListFindingsRequest) (*securitycenterpb.ListFindingsResponse, error) {
+	resp := &securitycenterpb.ListFindingsResponse{}
+	for _, finding := range s.findings {
+		resp.ListFindingsResults = append(resp.ListFindingsResults, &securitycenterpb.ListFindingsResponse_ListFindingsResult{Finding: finding})
+	}
+	return resp, nil
+}
+
+func (s *fakeSecurityCenter) CreateFinding(_ context.Context, req *securitycenterpb.CreateFindingRequest) (*securitycenterpb.Finding, error) {
+	finding := req.GetFinding()
+	finding.Name = req.GetParent() + "/findings/" + req.GetFindingId()
+	finding.Parent = req.GetParent()
+	s.findings[finding.Name] = finding
+	return finding, nil
+}
+
+func (s *fakeSecurityCenter) SetFindingState(_ context.Context, req *securitycenterpb.SetFindingStateRequest) (*securitycenterpb.Finding, error) {
+	finding := s.findings[req.GetName()]
+	finding.State = req.GetState()
+	return finding, nil
+}
+
+// newFakeSecurityCenterClient creates a Client that syncs findings with a
+// fake Security Command Center, and saves snapshots to a file store
+func newFakeSecurityCenterClient(t *testing.T, findingIDs ...string) (*Client, *fakeSecurityCenter) {
+	ctx := context.Background()
+	fake := &fakeSecurityCenter{findings: map[string]*securitycenterpb.Finding{}}
+	for _, id := range findingIDs {
+		name := testSource + "/findings/" + id
+		fake.findings[name] = &securitycenterpb.Finding{Name: name, Parent: testSource, State: securitycenterpb.Finding_ACTIVE}
+	}
+	server := grpc.NewServer()
+	securitycenterpb.RegisterSecurityCenterServer(server, fake)
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	securitycenterClient, err := securitycenter.NewClient(ctx, testr.New(t), "", false, option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = securitycenterClient.Close() })
+	c := &Client{
+		log:                  testr.New(t),
+		securitycenterClient: securitycenterClient,
+		source:               testSource,
+	}
+	if err := c.SetStore("file://"+filepath.Join(t.TempDir(), "snapshot.json"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	return c, fake
+}
+
+func findingRequestsFor(findingIDs ...string) map[string]*securitycenterpb.CreateFindingRequest {
+	findingRequests := map[string]*securitycenterpb.CreateFindingRequest{}
+	for _, id := range findingIDs {
+		findingRequests[testSource+"/findings/"+id] = &securitycenterpb.CreateFindingRequest{
+			Parent:    testSource,
+			FindingId: id,
+			Finding:   &securitycenterpb.Finding{State: securitycenterpb.Finding_ACTIVE, Category: "K8sRequiredLabels"},
+		}
+	}
+	return findingRequests
+}
+
+func TestClient_syncFindings_heldDeactivations(t *testing.T) {
+	ctx := context.Background()
+	c, fake := newFakeSecurityCenterClient(t, "1", "2", "3")
+	if err := c.store.Save(ctx, store.NewSnapshot(nil, findingRequestsFor("1", "2", "3"), time.Now(), time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetDeactivationLimit(0, 50, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	// findings 1, 2, and 3 would become inactive in the same sync that
+	// creates finding 4, but the deactivation limit holds them
+	findingRequests := findingRequestsFor("4")
+	snapshot, _ := c.loadSnapshot(ctx, findingRequests)
+	c.breaker.load(snapshot)
+	if _, err := c.syncFindings(ctx, snapshot, findingRequests, nil, nil); err == nil {
+		t.Fatal("expected error for held deactivations")
+	}
+
+	if _, created := fake.findings[testSource+"/findings/4"]; !created {
+		t.Error("expected finding 4 to be created")
+	}
+	saved, err := c.store.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"1", "2", "3"} {
+		findingName := testSource + "/findings/" + id
+		if fake.findings[findingName].GetState() != securitycenterpb.Finding_ACTIVE {
+			t.Errorf("finding %s state = %v, want ACTIVE", id, fake.findings[findingName].GetState())
+		}
+		if record := saved.Findings[findingName]; record == nil || record.State != securitycenterpb.Finding_ACTIVE {
+			t.Errorf("saved snapshot record for finding %s = %+v, want ACTIVE", id, record)
+		}
+	}
+	if saved.HeldDeactivationSyncs != 1 {
+		t.Errorf("saved snapshot HeldDeactivationSyncs = %d, want 1", saved.HeldDeactivationSyncs)
+	}
+	if _, unchanged := c.loadSnapshot(ctx, findingRequests); unchanged {
+		t.Error("expected the next sync not to skip the held deactivations as unchanged")
+	}
+}
+
+func TestClient_syncFindings_preserved(t *testing.T) {
+	ctx := context.Background()
+	c, fake := newFakeSecurityCenterClient(t, "1", "2")
+	findingRequests := findingRequestsFor("1")
+	finding2 := testSource + "/findings/2"
+
+	// first sync: the violation of finding 2 can't be resolved
+	preserved := map[string]string{}
+	preserve := func(finding *securitycenterpb.Finding) bool {
+		if finding.GetName() != finding2 {
+			return false
+		}
+		preserved[finding.GetName()] = "constraint-uid"
+		return true
+	}
+	snapshot, _ := c.loadSnapshot(ctx, findingRequests)
+	if _, err := c.syncFindings(ctx, snapshot, findingRequests, preserve, preserved); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.findings[finding2].GetState(); got != securitycenterpb.Finding_ACTIVE {
+		t.Fatalf("finding 2 state = %v after first sync, want ACTIVE", got)
+	}
+	saved, err := c.store.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record := saved.Findings[finding2]; record == nil || record.State != securitycenterpb.Finding_ACTIVE {
+		t.Fatalf("saved snapshot record for finding 2 = %+v after first sync, want ACTIVE", record)
+	}
+
+	// second sync: the violation is gone, so the sync isn't skipped as
+	// unchanged, and finding 2 becomes INACTIVE
+	snapshot, unchanged := c.loadSnapshot(ctx, findingRequests)
+	if unchanged {
+		t.Fatal("expected second sync not to be skipped as unchanged")
+	}
+	if _, err := c.syncFindings(ctx, snapshot, findingRequests, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.findings[finding2].GetState(); got != securitycenterpb.Finding_INACTIVE {
+		t.Errorf("finding 2 state = %v after second sync, want INACTIVE", got)
+	}
+	if _, unchanged := c.loadSnapshot(ctx, findingRequests); !unchanged {
+		t.Error("expected third sync to be skipped as unchanged")
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/metrics"
+)
+
+// AuditStaleMark is the security mark added to findings of constraints with
+// stale audits, see SetAuditStaleness
+const AuditStaleMark = "gatekeeper_audit_stale"
+
+// SetAuditStaleness sets the max age of the last Gatekeeper audit of a
+// constraint. Constraints with older audits, or with audit timestamps that
+// can't be parsed, have stale audits. Stale audits are reported as policy
+// health findings if enabled, see SetPolicyHealth, and in the metrics, see
+// SetMetrics. If markStale is true,
+// the findings of violations of constraints with stale audits get the
+// AuditStaleMark security mark.
+//
+// When staleness detection is enabled, findings of constraints without a
+// valid audit timestamp use the constraint creation time as the event time,
+// instead of the current time. A threshold of 0 disables staleness detection.
+func (c *Client) SetAuditStaleness(threshold time.Duration, markStale bool) error {
+	if threshold < 0 {
+		return fmt.Errorf("invalid audit staleness threshold: %v", threshold)
+	}
+	c.auditStaleness = threshold
+	c.markStaleAudits = markStale && threshold > 0
+	return nil
+}
+
+// SetMetrics enables recording metrics in each sync
+func (c *Client) SetMetrics(m *metrics.Metrics) {
+	c.metrics = m
+}
+
+// auditTime returns the time of the last Gatekeeper audit of the constraint,
+// and whether the audit is stale. Returns an error if the constraint has no
+// audit timestamp, or if the timestamp can't be parsed. A constraint without
+// an audit timestamp doesn't have a stale audit, it has never been audited,
+// see PolicyHealthNeverAudited.
+func (c *Client) auditTime(constraint *unstructured.Unstructured, now time.Time) (time.Time, bool, error) {
+	auditTimestamp, _, _ := unstructured.NestedString(constraint.UnstructuredContent(), "status", "auditTimestamp")
+	if auditTimestamp == "" {
+		return time.Time{}, false, errors.New("constraint has no auditTimestamp")
+	}
+	auditTime, err := time.Parse(time.RFC3339, auditTimestamp)
+	if err != nil {
+		return time.Time{}, c.auditStaleness > 0, err
+	}
+	return auditTime, c.auditStaleness > 0 && now.Sub(auditTime) > c.auditStaleness, nil
+}
+
+// recordAuditAges records the audit ages of the constraints in the metrics
+func (c *Client) recordAuditAges(constraints []unstructured.Unstructured, now time.Time) {
+	if c.metrics == nil {
+		return
+	}
+	var ages []metrics.AuditAge
+	for i := range constraints {
+		constraint := &constraints[i]
+		auditTime, stale, _ := c.auditTime(constraint, now)
+		age := metrics.AuditAge{
+			ConstraintKind: constraint.GetKind(),
+			ConstraintName: constraint.GetName(),
+			Stale:          stale,
+		}
+		if !auditTime.IsZero() {
+			age.Age = now.Sub(auditTime)
+		}
+		ages = append(ages, age)
+	}
+	c.metrics.SetAuditAges(ages)
+}
+
+// staleAuditIssues returns the stale audit issue of the constraint, if any
+func (c *Client) staleAuditIssues(constraint *unstructured.Unstructured, now time.Time) []*policyHealthIssue {
+	auditTime, stale, err := c.auditTime(constraint, now)
+	if !stale {
+		return nil
+	}
+	message := fmt.Sprintf("constraint was last audited at %s, more than %s ago", auditTime.UTC().Format(time.RFC3339), c.auditStaleness)
+	if err != nil {
+		message = fmt.Sprintf("constraint audit timestamp can't be parsed: %v", err)
+	}
+	return []*policyHealthIssue{{
+		issue:   PolicyHealthStaleAudit,
+		obj:     constraint,
+		message: message,
+	}}
+}
@@ -0,0 +1,177 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/metrics"
+)
+
+func TestClient_auditTime(t *testing.T) {
+	now := time.Date(2021, 3, 1, 2, 3, 4, 0, time.UTC)
+	tests := []struct {
+		name           string
+		threshold      time.Duration
+		auditTimestamp string
+		wantTime       time.Time
+		wantStale      bool
+		wantErr        bool
+	}{
+		{
+			name:           "recent audit",
+			threshold:      time.Hour,
+			auditTimestamp: "2021-03-01T02:03:00Z",
+			wantTime:       time.Date(2021, 3, 1, 2, 3, 0, 0, time.UTC),
+		},
+		{
+			name:           "stale audit",
+			threshold:      time.Hour,
+			auditTimestamp: "2021-03-01T00:03:00Z",
+			wantTime:       time.Date(2021, 3, 1, 0, 3, 0, 0, time.UTC),
+			wantStale:      true,
+		},
+		{
+			name:           "stale audit, staleness detection disabled",
+			auditTimestamp: "2021-03-01T00:03:00Z",
+			wantTime:       time.Date(2021, 3, 1, 0, 3, 0, 0, time.UTC),
+		},
+		{
+			name:           "unparseable timestamp",
+			threshold:      time.Hour,
+			auditTimestamp: "yesterday",
+			wantStale:      true,
+			wantErr:        true,
+		},
+		{
+			name:      "never audited",
+			threshold: time.Hour,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{log: testr.New(t)}
+			if err := c.SetAuditStaleness(tt.threshold, false); err != nil {
+				t.Fatal(err)
+			}
+			constraint := newPolicyObject("K8sRequiredLabels", now.Add(-24*time.Hour), nil)
+			if tt.auditTimestamp != "" {
+				constraint.Object["status"] = map[string]interface{}{"auditTimestamp": tt.auditTimestamp}
+			}
+			gotTime, gotStale, err := c.auditTime(constraint, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("auditTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !gotTime.Equal(tt.wantTime) {
+				t.Errorf("auditTime() time = %v, want %v", gotTime, tt.wantTime)
+			}
+			if gotStale != tt.wantStale {
+				t.Errorf("auditTime() stale = %v, want %v", gotStale, tt.wantStale)
+			}
+			wantIssues := 0
+			if tt.wantStale {
+				wantIssues = 1
+			}
+			if issues := c.staleAuditIssues(constraint, now); len(issues) != wantIssues {
+				t.Errorf("staleAuditIssues() returned %d issues, want %d", len(issues), wantIssues)
+			}
+		})
+	}
+}
+
+func TestClient_SetAuditStaleness(t *testing.T) {
+	c := &Client{}
+	if err := c.SetAuditStaleness(-time.Minute, true); err == nil {
+		t.Errorf("SetAuditStaleness() expected error for negative threshold")
+	}
+	if err := c.SetAuditStaleness(0, true); err != nil || c.markStaleAudits {
+		t.Errorf("SetAuditStaleness(0, true) = %v, markStaleAudits = %v, want nil, false", err, c.markStaleAudits)
+	}
+}
+
+func TestClient_createFindingRequest_staleAuditMark(t *testing.T) {
+	tests := []struct {
+		name       string
+		markStale  bool
+		auditStale bool
+		want       map[string]string
+	}{
+		{
+			name: "marking disabled",
+		},
+		{
+			name:       "stale audit",
+			markStale:  true,
+			auditStale: true,
+			want:       map[string]string{"team": "a", AuditStaleMark: "true"},
+		},
+		{
+			name:      "recent audit removes the mark",
+			markStale: true,
+			want:      map[string]string{"team": "a", AuditStaleMark: ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{log: testr.New(t), host: host, source: source, cluster: cluster}
+			if err := c.SetAuditStaleness(time.Hour, tt.markStale); err != nil {
+				t.Fatal(err)
+			}
+			resource := &Resource{}
+			if tt.markStale {
+				resource.SecurityMarks = map[string]string{"team": "a"}
+			}
+			req := c.createFindingRequest(&Constraint{AuditStale: tt.auditStale}, resource)
+			if diff := cmp.Diff(tt.want, req.Finding.GetSecurityMarks().GetMarks()); diff != "" {
+				t.Errorf("security marks mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestClient_recordAuditAges(t *testing.T) {
+	now := time.Date(2021, 3, 1, 2, 3, 4, 0, time.UTC)
+	recent := newPolicyObject("K8sRequiredLabels", now.Add(-24*time.Hour), map[string]interface{}{"auditTimestamp": "2021-03-01T02:03:00Z"})
+	recent.SetName("recent")
+	stale := newPolicyObject("K8sRequiredLabels", now.Add(-24*time.Hour), map[string]interface{}{"auditTimestamp": "2021-03-01T00:03:04Z"})
+	stale.SetName("stale")
+	m := metrics.New()
+	c := &Client{log: testr.New(t)}
+	c.SetMetrics(m)
+	if err := c.SetAuditStaleness(time.Hour, false); err != nil {
+		t.Fatal(err)
+	}
+	c.recordAuditAges([]unstructured.Unstructured{*recent, *stale}, now)
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", metrics.Path, nil))
+	for _, want := range []string{
+		`gatekeeper_securitycenter_constraint_audit_age_seconds{constraint_kind="K8sRequiredLabels",constraint_name="recent"} 4`,
+		`gatekeeper_securitycenter_constraint_audit_age_seconds{constraint_kind="K8sRequiredLabels",constraint_name="stale"} 7200`,
+		`gatekeeper_securitycenter_stale_audit_constraints 1`,
+	} {
+		if !strings.Contains(recorder.Body.String(), want) {
+			t.Errorf("metrics output doesn't contain %q:\n%s", want, recorder.Body.String())
+		}
+	}
+}
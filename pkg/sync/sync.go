@@ -18,20 +18,25 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/go-logr/logr"
 	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	errorutils "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/discovery"
 	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/dynamic"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/metrics"
 	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/print"
 	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/store"
 )
 
 const cnrmAnnotationProjectID = "cnrm.cloud.google.com/project-id"
@@ -46,11 +51,53 @@ type Client struct {
 	host                 string
 	source               string
 	cluster              string
+	sinks                []Sink
+	config               *rest.Config
+	store                store.Store
+	resyncInterval       time.Duration
+	lastDiff             *store.Diff
+	breaker              *deactivationBreaker
+	securityMarkLabels   []string
+	namespaceLabels      map[string]map[string]string // cache of namespace labels for the current sync
+	filters              Filters
+	annotateConstraints  bool
+	aggregateOwners      bool
+	policyHealth         bool
+	auditStaleness       time.Duration
+	markStaleAudits      bool
+	metrics              *metrics.Metrics
+	policyReports        bool
+	// admission event settings, see SetAdmissionEvents
+	admissionEventsNamespace string
+	admissionFindingTTL      time.Duration
+	linkTemplates            []*linkTemplate
+	clusterProject           string
+	clusterLocation          string
+	findingIDStrategy        string
+	findingGranularity       string
+	lastResult               *Result
+	// config resource settings, see SetConfigResource
+	configResource           string
+	configResourceDefaults   configResourceDefaults
+	configResourceSpec       *ConfigResourceSpec
+	configResourceGeneration int64
+	configResourceErr        error
+	// preservedFindings maps the names of the ACTIVE findings that the last
+	// sync with Security Command Center preserved to their constraint UID.
+	// They are counted in constraint annotations, and recorded as ACTIVE in
+	// the snapshot.
+	preservedFindings map[string]string
 }
 
-// Close cleans up resources, use with defer
+// Close cleans up resources, including sinks that implement io.Closer, use with defer
 func (c *Client) Close() error {
-	return c.securitycenterClient.Close()
+	errs := []error{c.securitycenterClient.Close()}
+	for _, sink := range c.sinks {
+		if closer, ok := sink.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errorutils.NewAggregate(errs)
 }
 
 // NewClient creates a Client that reads audit violations and creates findings.
@@ -80,6 +127,7 @@ func NewClient(ctx context.Context, log logr.Logger, kubeconfig string, dryRun b
 	if err != nil {
 		return nil, err
 	}
+	securitycenterClient.SetLineageKey(lineageKey)
 	return &Client{
 		log:                  log,
 		dryRun:               dryRun,
@@ -89,33 +137,66 @@ func NewClient(ctx context.Context, log logr.Logger, kubeconfig string, dryRun b
 		host:                 config.Host,
 		source:               source,
 		cluster:              clusterName,
+		config:               config,
+		findingIDStrategy:    FindingIDStrategySpec,
+		findingGranularity:   FindingGranularityViolation,
 	}, nil
 }
 
 // Sync retrieves Gatekeeper audit constraint violations and creates a
 // finding in Security Command Center for each violation.
+//
+// If a config resource is set, Sync applies its spec before syncing, and
+// records the result in its status.
 func (c *Client) Sync(ctx context.Context) error {
+	c.namespaceLabels = nil
+	c.applyConfigResource(ctx)
+	result, err := c.sync(ctx)
+	if result != nil {
+		c.lastResult = result
+		c.updateConfigResourceStatus(ctx, result)
+	}
+	return err
+}
+
+// sync performs one sync and returns the result. The result is nil in
+// dry-run mode.
+func (c *Client) sync(ctx context.Context) (*Result, error) {
 	groupResources, err := c.discoveryClient.GetConstraintGroupResources()
 	if err != nil {
-		return err
+		return newResult(time.Now(), 0, nil, err), err
 	}
 	violatedConstraints, err := c.dynamicClient.GetViolatedConstraints(ctx, groupResources)
 	if err != nil {
-		return err
+		return newResult(time.Now(), 0, nil, err), err
 	}
 	kindToGVR, err := c.discoveryClient.CreateKindToGVRMap()
 	if err != nil {
-		return err
+		return newResult(time.Now(), 0, nil, err), err
 	}
 
 	// For each constraint that contains audit violations,
 	// for each audit violation,
 	// get the resource that caused the violation
 	// and use attributes of the constraint, the violation, and the resource to create a finding request.
+	//
+	// Violations that can't be resolved are recorded, so their existing findings keep their state.
 	findingRequests := map[string]*securitycenterpb.CreateFindingRequest{} // key is full finding name
+	unresolved := newUnresolvedViolations()
+	ownerCache := map[types.UID]*unstructured.Unstructured{}
 	for _, unstructuredConstraint := range violatedConstraints {
-		constraint := c.getConstraint(ctx, unstructuredConstraint)
-		resources := c.getViolatingResourcesForConstraint(ctx, unstructuredConstraint, kindToGVR)
+		if !c.filters.includesConstraintKind(unstructuredConstraint.GetKind()) {
+			c.log.V(1).Info("skipping constraint excluded by filters", "kind", unstructuredConstraint.GetKind(), "name", unstructuredConstraint.GetName())
+			continue
+		}
+		constraint, err := c.getConstraint(ctx, unstructuredConstraint)
+		if err != nil {
+			c.log.Error(err, "could not resolve constraint, preserving existing findings", "kind", constraint.Kind, "name", constraint.Name)
+			unresolved.addConstraint(constraint.UID)
+			continue
+		}
+		resources := c.getViolatingResourcesForConstraint(ctx, unstructuredConstraint, kindToGVR, unresolved, ownerCache)
+		resources = c.aggregateResources(ctx, unstructuredConstraint, resources, kindToGVR, unresolved)
 		for _, resource := range resources {
 			req := c.createFindingRequest(constraint, resource)
 			findingName := fmt.Sprintf("%s/findings/%s", req.Parent, req.FindingId)
@@ -123,19 +204,86 @@ func (c *Client) Sync(ctx context.Context) error {
 		}
 	}
 
+	if c.policyReports {
+		reportRequests, err := c.getPolicyReportFindingRequests(ctx, kindToGVR, unresolved)
+		if err != nil {
+			c.log.Error(err, "could not list policy reports, preserving existing policy report findings")
+			unresolved.addScanner(PolicyReportScannerName)
+		}
+		for findingName, req := range reportRequests {
+			findingRequests[findingName] = req
+		}
+	}
+
+	if c.policyHealth || c.auditStaleness > 0 || c.metrics != nil {
+		healthRequests, err := c.getPolicyHealthFindingRequests(ctx, groupResources)
+		if err != nil {
+			c.log.Error(err, "could not determine policy health, preserving existing policy health findings")
+			unresolved.addCategory(PolicyHealthCategory)
+		}
+		for findingName, req := range healthRequests {
+			findingRequests[findingName] = req
+		}
+	}
+
+	now := time.Now()
+	if c.admissionEventsNamespace != "" {
+		admissionRequests, err := c.getAdmissionFindingRequests(ctx, kindToGVR, now)
+		if err != nil {
+			c.log.Error(err, "could not list admission events, preserving existing admission findings")
+			unresolved.addCategory(AdmissionCategory)
+		}
+		for findingName, req := range admissionRequests {
+			findingRequests[findingName] = req
+		}
+	}
+	preserved := map[string]string{}
+	preserve := func(finding *securitycenterpb.Finding) bool {
+		if !unresolved.preserves(finding) && !c.preservesAdmissionFinding(finding, now) {
+			return false
+		}
+		if finding.GetState() == securitycenterpb.Finding_ACTIVE {
+			preserved[finding.GetName()] = property(finding, "ConstraintUID")
+		}
+		return true
+	}
+
 	if c.dryRun {
-		return printFindingRequests(findingRequests)
+		return nil, printFindingRequests(findingRequests)
+	}
+	snapshot, unchanged := c.loadSnapshot(ctx, findingRequests)
+	c.breaker.load(snapshot)
+	if unchanged {
+		c.log.Info("no changes since last sync, skip syncing findings")
+		c.saveSnapshot(ctx, snapshot, findingRequests, false)
+		if err := c.sendTransitions(ctx, nil); err != nil {
+			c.log.Error(err, "could not send finding transitions")
+		}
+		c.updateConstraintAnnotations(ctx, groupResources, findingRequests)
+		result := newResult(time.Now(), len(findingRequests), nil, nil)
+		result.Result = ResultUnchanged
+		return result.withUnresolved(unresolved.count()), nil
 	}
-	if err := c.securitycenterClient.SyncFindings(ctx, c.source, findingRequests); err != nil {
-		return fmt.Errorf("could not sync findings: %w", err)
+	transitions, syncErr := c.syncFindings(ctx, snapshot, findingRequests, preserve, preserved)
+	if err := c.sendTransitions(ctx, transitions); err != nil {
+		c.log.Error(err, "could not send finding transitions")
 	}
-	return nil
+	if syncErr != nil {
+		err := fmt.Errorf("could not sync findings: %w", syncErr)
+		return newResult(time.Now(), len(findingRequests), transitions, err).withUnresolved(unresolved.count()), err
+	}
+	c.updateConstraintAnnotations(ctx, groupResources, findingRequests)
+	return newResult(time.Now(), len(findingRequests), transitions, nil).withUnresolved(unresolved.count()), nil
 }
 
 // getConstraint creates a Constraint struct from an unstructured constraint.
 // It's intentionally forgiving of errors and defaults to empty string values
 // for fields that aren't required to create a finding.
-func (c *Client) getConstraint(ctx context.Context, constraint *unstructured.Unstructured) *Constraint {
+//
+// Returns an error if the constraint template lookup failed. The template is
+// an input to the finding ID, so the returned Constraint can't be used to
+// create finding requests, but it can be used for other purposes.
+func (c *Client) getConstraint(ctx context.Context, constraint *unstructured.Unstructured) (*Constraint, error) {
 	name := constraint.GetName()
 	selfLink := constraint.GetSelfLink()
 	uid := constraint.GetUID()
@@ -147,9 +295,10 @@ func (c *Client) getConstraint(ctx context.Context, constraint *unstructured.Uns
 	var templateUID types.UID
 	var templateSelfLink string
 	var templateSpecJSON string
-	template, err := c.dynamicClient.GetConstraintTemplate(ctx, constraintKind)
-	if err != nil {
-		c.log.Error(err, "could not get constraint template", "constraintKind", constraintKind)
+	template, templateErr := c.dynamicClient.GetConstraintTemplate(ctx, constraintKind)
+	if templateErr != nil {
+		c.log.Error(templateErr, "could not get constraint template", "constraintKind", constraintKind)
+		templateErr = fmt.Errorf("could not get constraint template for kind=[%v]: %w", constraintKind, templateErr)
 	} else {
 		templateUID = template.GetUID()
 		templateSelfLink = template.GetSelfLink()
@@ -158,37 +307,69 @@ func (c *Client) getConstraint(ctx context.Context, constraint *unstructured.Uns
 			c.log.Error(err, "could not get constraint template spec as JSON string")
 		}
 	}
-	auditTimestamp, _, _ := unstructured.NestedString(constraint.UnstructuredContent(), "status", "auditTimestamp")
-	auditTime, err := time.Parse(time.RFC3339, auditTimestamp)
-	if err != nil {
-		c.log.Error(err, "could not parse auditTimestamp, using current time instead", "auditTimestamp", auditTimestamp)
+	auditTime, auditStale, err := c.auditTime(constraint, time.Now())
+	switch {
+	case err != nil && c.auditStaleness > 0:
+		// don't refresh the event time of violations that weren't audited recently
+		c.log.Error(err, "could not get audit time, using constraint creation time instead", "kind", constraintKind, "name", name)
+		auditTime = constraint.GetCreationTimestamp().Time
+	case err != nil:
+		c.log.Error(err, "could not get audit time, using current time instead", "kind", constraintKind, "name", name)
 		auditTime = time.Now()
 	}
+	if auditStale {
+		c.log.Info("constraint audit is stale", "kind", constraintKind, "name", name, "auditTime", auditTime, "threshold", c.auditStaleness)
+	}
 	return &Constraint{
 		Name:             name,
 		SelfLink:         selfLink,
 		UID:              uid,
 		Kind:             constraintKind,
 		AuditTime:        auditTime,
+		AuditStale:       auditStale,
 		SpecJSON:         specJSON,
 		TemplateUID:      templateUID,
 		TemplateSelfLink: templateSelfLink,
 		TemplateSpecJSON: templateSpecJSON,
-	}
+	}, templateErr
 }
 
-func (c *Client) getViolatingResourcesForConstraint(ctx context.Context, constraint *unstructured.Unstructured, kindToGVR map[string][]schema.GroupVersionResource) []*Resource {
+// getViolatingResourcesForConstraint returns the resources for the audit
+// violations of the constraint. Violations of resources that were deleted
+// are skipped. Violations that can't be resolved for other reasons, such as
+// timeouts or missing permissions, are recorded in unresolved. If owner
+// aggregation is enabled, there is one resource per top-level owner, see
+// SetAggregateOwners, and an unresolved violation preserves all findings of
+// the constraint.
+func (c *Client) getViolatingResourcesForConstraint(ctx context.Context, constraint *unstructured.Unstructured, kindToGVR map[string][]schema.GroupVersionResource, unresolved *unresolvedViolations, ownerCache map[types.UID]*unstructured.Unstructured) []*Resource {
 	violations := getViolationsForConstraint(c.log, constraint)
 	var resources []*Resource
+	owners := newOwnerGroups(ownerCache)
 	for _, violation := range violations {
-		resource, err := c.getResource(ctx, violation, kindToGVR)
-		if err != nil {
-			c.log.Error(err, "skipping violation")
-		} else {
-			resources = append(resources, resource)
+		if namespace, _, _ := unstructured.NestedString(violation, "namespace"); !c.filters.includesNamespace(namespace) {
+			c.log.V(1).Info("skipping violation in namespace excluded by filters", "namespace", namespace)
+			continue
+		}
+		obj, err := c.getViolatingObject(ctx, violation, kindToGVR)
+		message, _, _ := unstructured.NestedString(violation, "message")
+		switch {
+		case err == nil && c.aggregateOwners:
+			owners.add(c.topLevelOwner(ctx, obj, kindToGVR, owners.cache), obj, message)
+		case err == nil:
+			resources = append(resources, c.newResource(ctx, obj, message))
+		case apierrors.IsNotFound(err):
+			c.log.V(1).Info("skipping violation of deleted resource", "error", err.Error())
+		case c.aggregateOwners && (c.findingGranularity == FindingGranularityViolation || c.findingGranularity == ""):
+			// the finding of the violation is for the top-level owner, which
+			// can't be determined without the violating object
+			c.log.Error(err, "could not resolve violation, preserving existing findings of the constraint")
+			unresolved.addConstraint(constraint.GetUID())
+		default:
+			c.log.Error(err, "could not resolve violation, preserving existing findings")
+			unresolved.addViolation(constraint.GetUID(), c.aggregateViolation(constraint, violation))
 		}
 	}
-	return resources
+	return append(resources, owners.resources(ctx, c)...)
 }
 
 func getViolationsForConstraint(log logr.Logger, constraint *unstructured.Unstructured) []map[string]interface{} {
@@ -210,35 +391,51 @@ func getViolationsForConstraint(log logr.Logger, constraint *unstructured.Unstru
 
 // getResource collects resource information for a violation
 func (c *Client) getResource(ctx context.Context, violation map[string]interface{}, kindToGVR map[string][]schema.GroupVersionResource) (*Resource, error) {
-	name, _, _ := unstructured.NestedString(violation, "name")
-	namespace, _, _ := unstructured.NestedString(violation, "namespace")
-	kind, _, _ := unstructured.NestedString(violation, "kind")
-	resource, err := c.dynamicClient.GetResourceByKind(ctx, kind, name, namespace, kindToGVR)
+	resource, err := c.getViolatingObject(ctx, violation, kindToGVR)
 	if err != nil {
 		return nil, err
 	}
+	message, _, _ := unstructured.NestedString(violation, "message")
+	return c.newResource(ctx, resource, message), nil
+}
+
+// getViolatingObject returns the object of a violation
+func (c *Client) getViolatingObject(ctx context.Context, violation map[string]interface{}, kindToGVR map[string][]schema.GroupVersionResource) (*unstructured.Unstructured, error) {
+	name, _, _ := unstructured.NestedString(violation, "name")
+	namespace, _, _ := unstructured.NestedString(violation, "namespace")
+	kind, _, _ := unstructured.NestedString(violation, "kind")
+	return c.dynamicClient.GetResourceByKind(ctx, kind, name, namespace, kindToGVR)
+}
+
+// newResource collects resource information from the object
+func (c *Client) newResource(ctx context.Context, resource *unstructured.Unstructured, message string) *Resource {
 	// Config Connector resources have a status.selfLink attribute pointing to the
 	// actual Google Cloud resource (not the Kubernetes resource).
 	statusSelfLink, _, _ := unstructured.NestedString(resource.UnstructuredContent(), "status", "selfLink")
 	// Config Connector resources have an annotation pointing to the
 	// project ID of the Google Cloud resource. Get it if available.
 	projectID := resource.GetAnnotations()[cnrmAnnotationProjectID]
-	message, _, _ := unstructured.NestedString(violation, "message")
 	specJSON, err := getSpecAsJSON(resource)
 	if err != nil {
 		c.log.Error(err, "could not get resource spec as JSON string")
 	}
+	muteReason, muteExpiry := getMute(c.log, resource, time.Now())
+	securityMarks := c.getSecurityMarks(ctx, resource)
 	return &Resource{
-		Name:           name,
-		Namespace:      namespace,
+		Name:           resource.GetName(),
+		Namespace:      resource.GetNamespace(),
 		GVK:            resource.GroupVersionKind(),
 		SelfLink:       resource.GetSelfLink(),
 		UID:            resource.GetUID(),
 		ProjectID:      projectID,
 		StatusSelfLink: statusSelfLink,
+		AssetName:      resolveAssetName(resource),
 		Message:        message,
 		SpecJSON:       specJSON,
-	}, nil
+		MuteReason:     muteReason,
+		MuteExpiry:     muteExpiry,
+		SecurityMarks:  securityMarks,
+	}
 }
 
 // getSpecAsJSON returns a string containing the spec field of the provided object
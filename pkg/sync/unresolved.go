@@ -0,0 +1,115 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// violationKey identifies an audit violation using the values that are
+// available without looking up the violating resource
+type violationKey struct {
+	constraintUID string
+	kind          string
+	namespace     string
+	name          string
+}
+
+// unresolvedViolations records the constraints and violations that were
+// observed in a sync, but couldn't be resolved to finding requests, e.g.,
+// because of API server timeouts or missing permissions. Their existing
+// findings keep their state, instead of being set to INACTIVE.
+type unresolvedViolations struct {
+	constraints map[string]bool
+	violations  map[violationKey]bool
+	// categories of findings that couldn't be determined, e.g., because
+	// the policy health issues couldn't be listed
+	categories map[string]bool
+	// scanners of findings that couldn't be determined, e.g., because the
+	// policy reports couldn't be listed
+	scanners map[string]bool
+}
+
+func newUnresolvedViolations() *unresolvedViolations {
+	return &unresolvedViolations{
+		constraints: map[string]bool{},
+		violations:  map[violationKey]bool{},
+		categories:  map[string]bool{},
+		scanners:    map[string]bool{},
+	}
+}
+
+// addConstraint records a constraint that couldn't be resolved. All
+// existing findings for the constraint are preserved.
+func (u *unresolvedViolations) addConstraint(constraintUID types.UID) {
+	u.constraints[string(constraintUID)] = true
+}
+
+// addViolation records a violation of the constraint whose resource
+// couldn't be resolved
+func (u *unresolvedViolations) addViolation(constraintUID types.UID, violation map[string]interface{}) {
+	kind, _, _ := unstructured.NestedString(violation, "kind")
+	namespace, _, _ := unstructured.NestedString(violation, "namespace")
+	name, _, _ := unstructured.NestedString(violation, "name")
+	u.violations[violationKey{
+		constraintUID: string(constraintUID),
+		kind:          kind,
+		namespace:     namespace,
+		name:          name,
+	}] = true
+}
+
+// addCategory records that the findings of the category, such as
+// PolicyHealthCategory, couldn't be determined. All existing findings of
+// the category are preserved.
+func (u *unresolvedViolations) addCategory(category string) {
+	u.categories[category] = true
+}
+
+// addScanner records that the findings with the ScannerName source
+// property, such as PolicyReportScannerName, couldn't be determined. All
+// existing findings of the scanner are preserved.
+func (u *unresolvedViolations) addScanner(scanner string) {
+	u.scanners[scanner] = true
+}
+
+// count returns the number of unresolved constraints, violations, finding
+// categories, and scanners
+func (u *unresolvedViolations) count() int {
+	return len(u.constraints) + len(u.violations) + len(u.categories) + len(u.scanners)
+}
+
+// preserves returns true if the finding belongs to an unresolved constraint
+// or violation. Implements securitycenter.PreserveFunc.
+func (u *unresolvedViolations) preserves(finding *securitycenterpb.Finding) bool {
+	if u.categories[finding.GetCategory()] || u.scanners[property(finding, "ScannerName")] {
+		return true
+	}
+	constraintUID := property(finding, "ConstraintUID")
+	if constraintUID == "" {
+		return false
+	}
+	if u.constraints[constraintUID] {
+		return true
+	}
+	return u.violations[violationKey{
+		constraintUID: constraintUID,
+		kind:          property(finding, "ResourceKind"),
+		namespace:     property(finding, "ResourceNamespace"),
+		name:          property(finding, "ResourceName"),
+	}]
+}
@@ -0,0 +1,161 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"errors"
+	"testing"
+
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func Test_unresolvedViolations_preserves(t *testing.T) {
+	unresolved := newUnresolvedViolations()
+	unresolved.addConstraint("constraint-uid-1")
+	unresolved.addViolation("constraint-uid-2", map[string]interface{}{
+		"kind":      "Pod",
+		"namespace": "default",
+		"name":      "pod-1",
+	})
+	finding := func(properties map[string]string) *securitycenterpb.Finding {
+		sourceProperties := map[string]*structpb.Value{}
+		for key, value := range properties {
+			sourceProperties[key] = structpb.NewStringValue(value)
+		}
+		return &securitycenterpb.Finding{SourceProperties: sourceProperties}
+	}
+	tests := []struct {
+		name    string
+		finding *securitycenterpb.Finding
+		want    bool
+	}{
+		{
+			name:    "unresolved constraint",
+			finding: finding(map[string]string{"ConstraintUID": "constraint-uid-1", "ResourceKind": "Pod", "ResourceNamespace": "default", "ResourceName": "pod-2"}),
+			want:    true,
+		},
+		{
+			name:    "unresolved violation",
+			finding: finding(map[string]string{"ConstraintUID": "constraint-uid-2", "ResourceKind": "Pod", "ResourceNamespace": "default", "ResourceName": "pod-1"}),
+			want:    true,
+		},
+		{
+			name:    "other violation of constraint with unresolved violation",
+			finding: finding(map[string]string{"ConstraintUID": "constraint-uid-2", "ResourceKind": "Pod", "ResourceNamespace": "default", "ResourceName": "pod-2"}),
+			want:    false,
+		},
+		{
+			name:    "other constraint",
+			finding: finding(map[string]string{"ConstraintUID": "constraint-uid-3", "ResourceKind": "Pod", "ResourceNamespace": "default", "ResourceName": "pod-1"}),
+			want:    false,
+		},
+		{
+			name:    "no constraint UID",
+			finding: finding(map[string]string{}),
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unresolved.preserves(tt.finding); got != tt.want {
+				t.Errorf("preserves() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if unresolved.count() != 2 {
+		t.Errorf("count() = %d, want 2", unresolved.count())
+	}
+}
+
+func Test_unresolvedViolations_category(t *testing.T) {
+	healthFinding := &securitycenterpb.Finding{Category: PolicyHealthCategory}
+	unresolved := newUnresolvedViolations()
+	if unresolved.preserves(healthFinding) {
+		t.Errorf("preserves() = true before addCategory(), want false")
+	}
+	unresolved.addCategory(PolicyHealthCategory)
+	if !unresolved.preserves(healthFinding) {
+		t.Errorf("preserves() = false after addCategory(), want true")
+	}
+	if unresolved.count() != 1 {
+		t.Errorf("count() = %d, want 1", unresolved.count())
+	}
+}
+
+func Test_unresolvedViolations_scanner(t *testing.T) {
+	reportFinding := &securitycenterpb.Finding{
+		Category: "require-labels",
+		SourceProperties: map[string]*structpb.Value{
+			"ScannerName": structpb.NewStringValue(PolicyReportScannerName),
+		},
+	}
+	gatekeeperFinding := &securitycenterpb.Finding{
+		Category: "K8sRequiredLabels",
+		SourceProperties: map[string]*structpb.Value{
+			"ScannerName": structpb.NewStringValue(scannerName),
+		},
+	}
+	unresolved := newUnresolvedViolations()
+	unresolved.addScanner(PolicyReportScannerName)
+	if !unresolved.preserves(reportFinding) {
+		t.Errorf("preserves() = false for finding of unresolved scanner, want true")
+	}
+	if unresolved.preserves(gatekeeperFinding) {
+		t.Errorf("preserves() = true for finding of other scanner, want false")
+	}
+	if unresolved.count() != 1 {
+		t.Errorf("count() = %d, want 1", unresolved.count())
+	}
+}
+
+func Test_Result_withUnresolved(t *testing.T) {
+	tests := []struct {
+		name       string
+		result     *Result
+		unresolved int
+		want       string
+	}{
+		{
+			name:       "all resolved",
+			result:     &Result{Result: ResultSucceeded},
+			unresolved: 0,
+			want:       ResultSucceeded,
+		},
+		{
+			name:       "unresolved violations",
+			result:     &Result{Result: ResultSucceeded},
+			unresolved: 2,
+			want:       ResultPartial,
+		},
+		{
+			name:       "failed",
+			result:     &Result{Result: ResultFailed, Err: errors.New("failed")},
+			unresolved: 2,
+			want:       ResultFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.result.withUnresolved(tt.unresolved)
+			if got.Result != tt.want {
+				t.Errorf("Result = %s, want %s", got.Result, tt.want)
+			}
+			if got.Unresolved != tt.unresolved {
+				t.Errorf("Unresolved = %d, want %d", got.Unresolved, tt.unresolved)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	errorutils "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/version"
+)
+
+// Ref: https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/formats/json-format.md
+
+const (
+	defaultTimeout        = 30 * time.Second
+	defaultMaxAttempts    = 5
+	defaultInitialBackoff = 500 * time.Millisecond
+	defaultMaxPending     = 1000
+	defaultSendTimeout    = 2 * time.Minute
+
+	cloudEventsContentType = "application/cloudevents+json"
+	cloudEventsSpecVersion = "1.0"
+
+	// SignatureHeader is the HTTP request header containing the hex-encoded
+	// HMAC-SHA256 signature of the request body, prefixed with `sha256=`.
+	SignatureHeader = "X-Signature-256"
+
+	// EventTypeCreated is the CloudEvents type for new findings
+	EventTypeCreated = "com.google.cloud.securitycenter.gatekeeper.finding.created"
+	// EventTypeReactivated is the CloudEvents type for INACTIVE findings that became ACTIVE again
+	EventTypeReactivated = "com.google.cloud.securitycenter.gatekeeper.finding.reactivated"
+	// EventTypeResolved is the CloudEvents type for ACTIVE findings that became INACTIVE
+	EventTypeResolved = "com.google.cloud.securitycenter.gatekeeper.finding.resolved"
+)
+
+// Event is a CloudEvent in the structured JSON format
+type Event struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	Type            string    `json:"type"`
+	Subject         string    `json:"subject"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype"`
+	Data            EventData `json:"data"`
+}
+
+// EventData is the payload of an Event
+type EventData struct {
+	FindingName string          `json:"findingName"`
+	OldState    string          `json:"oldState"`
+	NewState    string          `json:"newState"`
+	Finding     json.RawMessage `json:"finding"`
+}
+
+// Client posts finding transitions to a webhook endpoint as CloudEvents.
+// Implements the sync.Sink interface.
+type Client struct {
+	log            logr.Logger
+	url            string
+	secret         []byte
+	httpClient     *http.Client
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxPending     int
+	sendTimeout    time.Duration
+
+	mu sync.Mutex
+	// delivered is the last state delivered for each finding name, used to
+	// avoid sending the same transition more than once. Findings are removed
+	// when their INACTIVE state is delivered.
+	delivered map[string]securitycenterpb.Finding_State
+	// pending holds events that could not be delivered yet, oldest first.
+	// A finding can have several pending events, e.g., when it's created
+	// and resolved while the endpoint is unavailable, and they're delivered
+	// in order. They are retried on the next call to Send. At most
+	// maxPending events are kept.
+	pending []*Event
+}
+
+// NewClient creates a webhook client that posts events to the provided URL.
+// If secret is not empty, request bodies are signed using HMAC-SHA256.
+func NewClient(log logr.Logger, url string, secret []byte) *Client {
+	return &Client{
+		log:            log,
+		url:            url,
+		secret:         secret,
+		httpClient:     &http.Client{Timeout: defaultTimeout},
+		maxAttempts:    defaultMaxAttempts,
+		initialBackoff: defaultInitialBackoff,
+		maxPending:     defaultMaxPending,
+		sendTimeout:    defaultSendTimeout,
+		delivered:      map[string]securitycenterpb.Finding_State{},
+	}
+}
+
+// SetTimeout for each HTTP request to the webhook endpoint
+func (c *Client) SetTimeout(timeout time.Duration) error {
+	if timeout.Seconds() <= 0 {
+		return fmt.Errorf("invalid timeout: %v", timeout)
+	}
+	c.httpClient.Timeout = timeout
+	return nil
+}
+
+// SetRetry sets the max number of attempts to deliver each event, and the
+// backoff before the first retry. The backoff doubles on each retry.
+func (c *Client) SetRetry(maxAttempts int, initialBackoff time.Duration) error {
+	if maxAttempts < 1 {
+		return fmt.Errorf("invalid maxAttempts: %v", maxAttempts)
+	}
+	if initialBackoff < 0 {
+		return fmt.Errorf("invalid initialBackoff: %v", initialBackoff)
+	}
+	c.maxAttempts = maxAttempts
+	c.initialBackoff = initialBackoff
+	return nil
+}
+
+// SetLimits sets the max number of undelivered events that are kept for
+// the next call to Send, and the max total time that each call to Send
+// spends delivering events
+func (c *Client) SetLimits(maxPending int, sendTimeout time.Duration) error {
+	if maxPending < 1 {
+		return fmt.Errorf("invalid maxPending: %v", maxPending)
+	}
+	if sendTimeout <= 0 {
+		return fmt.Errorf("invalid sendTimeout: %v", sendTimeout)
+	}
+	c.maxPending = maxPending
+	c.sendTimeout = sendTimeout
+	return nil
+}
+
+// Send posts an event for each transition of a finding that was created,
+// reactivated, or resolved. Events that can't be delivered because of
+// network errors, HTTP 429, or HTTP 5xx responses are kept and retried on
+// the next call, oldest first, so the events for each finding are delivered
+// in the order of the transitions. Events that the endpoint rejects with other
+// responses are dropped. Delivery stops at the first event that can't be
+// delivered after retries, or when the send timeout expires, so an
+// unavailable endpoint doesn't stall the control loop.
+func (c *Client) Send(ctx context.Context, transitions []*securitycenter.Transition) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, transition := range transitions {
+		event, err := newEvent(transition)
+		if err != nil {
+			c.log.Error(err, "skipping transition", "findingName", transition.FindingName)
+			continue
+		}
+		if event == nil {
+			continue
+		}
+		if state, exists := c.lastState(transition.FindingName); exists && state == transition.NewState {
+			c.log.V(2).Info("skipping already delivered transition", "findingName", transition.FindingName, "state", transition.NewState.String())
+			continue
+		}
+		c.pending = append(c.pending, event)
+	}
+	if dropped := len(c.pending) - c.maxPending; dropped > 0 {
+		c.log.Error(fmt.Errorf("too many undelivered events"), "dropping oldest events", "dropped", dropped, "maxPending", c.maxPending)
+		c.pending = c.pending[dropped:]
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.sendTimeout)
+	defer cancel()
+	var errs []error
+	sent := 0
+	for _, event := range c.pending {
+		if ctx.Err() != nil {
+			errs = append(errs, fmt.Errorf("send timeout expired, keeping %d events for the next sync", len(c.pending)-sent))
+			break
+		}
+		retryable, err := c.deliver(ctx, event)
+		if err == nil {
+			if state := eventState(event); state == securitycenterpb.Finding_INACTIVE {
+				delete(c.delivered, event.Subject)
+			} else {
+				c.delivered[event.Subject] = state
+			}
+			sent++
+			continue
+		}
+		if !retryable {
+			c.log.Error(err, "dropping event rejected by webhook endpoint", "type", event.Type, "findingName", event.Subject)
+			errs = append(errs, fmt.Errorf("dropped event for finding %s: %w", event.Subject, err))
+			sent++
+			continue
+		}
+		errs = append(errs, fmt.Errorf("could not deliver event for finding %s, keeping %d events for the next sync: %w", event.Subject, len(c.pending)-sent, err))
+		break
+	}
+	c.pending = c.pending[sent:]
+	return errorutils.NewAggregate(errs)
+}
+
+// lastState returns the state of the most recent pending event for the
+// finding, or the last delivered state if the finding has no pending events.
+// Returns false if neither exists.
+func (c *Client) lastState(findingName string) (securitycenterpb.Finding_State, bool) {
+	for i := len(c.pending) - 1; i >= 0; i-- {
+		if c.pending[i].Subject == findingName {
+			return eventState(c.pending[i]), true
+		}
+	}
+	state, exists := c.delivered[findingName]
+	return state, exists
+}
+
+// eventState returns the new finding state of the event
+func eventState(event *Event) securitycenterpb.Finding_State {
+	return securitycenterpb.Finding_State(securitycenterpb.Finding_State_value[event.Data.NewState])
+}
+
+// deliver posts the event, retrying with exponential backoff on network
+// errors, HTTP 429, and HTTP 5xx responses. Returns true if delivery failed
+// and can be retried later.
+func (c *Client) deliver(ctx context.Context, event *Event) (bool, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return false, err
+	}
+	backoff := c.initialBackoff
+	for attempt := 1; ; attempt++ {
+		retryable, err := c.post(ctx, body)
+		if err == nil {
+			c.log.V(1).Info("delivered event", "type", event.Type, "findingName", event.Subject)
+			return false, nil
+		}
+		if !retryable || attempt >= c.maxAttempts {
+			return retryable, err
+		}
+		c.log.V(1).Info("retrying event delivery", "findingName", event.Subject, "attempt", attempt, "backoff", backoff, "error", err.Error())
+		select {
+		case <-ctx.Done():
+			return true, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post sends the request body to the webhook endpoint. Returns true if the
+// request failed and can be retried.
+func (c *Client) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", cloudEventsContentType)
+	req.Header.Set("User-Agent", "cloud-solutions/gatekeeper-securitycenter-"+version.Version)
+	if len(c.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(c.secret, body))
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retryable, fmt.Errorf("unexpected response status from webhook: %s", resp.Status)
+}
+
+// Sign returns the signature of the body using HMAC-SHA256 and the provided
+// secret, in the format used by the SignatureHeader.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newEvent creates an event for the provided transition. Returns nil if the
+// transition is not a finding being created, reactivated, or resolved.
+func newEvent(transition *securitycenter.Transition) (*Event, error) {
+	var eventType string
+	switch {
+	case transition.OldState == securitycenterpb.Finding_STATE_UNSPECIFIED && transition.NewState == securitycenterpb.Finding_ACTIVE:
+		eventType = EventTypeCreated
+	case transition.OldState == securitycenterpb.Finding_INACTIVE && transition.NewState == securitycenterpb.Finding_ACTIVE:
+		eventType = EventTypeReactivated
+	case transition.OldState == securitycenterpb.Finding_ACTIVE && transition.NewState == securitycenterpb.Finding_INACTIVE:
+		eventType = EventTypeResolved
+	default:
+		return nil, nil
+	}
+	findingJSON, err := protojson.Marshal(transition.Finding)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	idSha := sha256.Sum256([]byte(fmt.Sprintf("%s%s%d", transition.FindingName, transition.NewState, now.UnixNano())))
+	return &Event{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              hex.EncodeToString(idSha[:])[:32],
+		Source:          "//securitycenter.googleapis.com/" + transition.Finding.GetParent(),
+		Type:            eventType,
+		Subject:         transition.FindingName,
+		Time:            now,
+		DataContentType: "application/json",
+		Data: EventData{
+			FindingName: transition.FindingName,
+			OldState:    transition.OldState.String(),
+			NewState:    transition.NewState.String(),
+			Finding:     findingJSON,
+		},
+	}, nil
+}
@@ -0,0 +1,289 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	securitycenterpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1"
+
+	"github.com/googlecloudplatform/gatekeeper-securitycenter/pkg/securitycenter"
+)
+
+const (
+	source = "organizations/123/sources/456"
+	secret = "s3cr3t"
+)
+
+// recorder is a webhook endpoint that records the events it receives. It
+// responds with the status codes in `failures` before succeeding.
+type recorder struct {
+	t        *testing.T
+	mu       sync.Mutex
+	events   []*Event
+	failures []int
+	requests int
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests++
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	if got, want := req.Header.Get(SignatureHeader), Sign([]byte(secret), body); got != want {
+		r.t.Errorf("expected signature %s, got %s", want, got)
+	}
+	if got := req.Header.Get("Content-Type"); got != cloudEventsContentType {
+		r.t.Errorf("expected Content-Type %s, got %s", cloudEventsContentType, got)
+	}
+	if len(r.failures) > 0 {
+		w.WriteHeader(r.failures[0])
+		r.failures = r.failures[1:]
+		return
+	}
+	event := &Event{}
+	if err := json.Unmarshal(body, event); err != nil {
+		r.t.Fatal(err)
+	}
+	r.events = append(r.events, event)
+}
+
+func transition(id string, oldState, newState securitycenterpb.Finding_State) *securitycenter.Transition {
+	name := source + "/findings/" + id
+	return &securitycenter.Transition{
+		FindingName: name,
+		OldState:    oldState,
+		NewState:    newState,
+		Finding: &securitycenterpb.Finding{
+			Name:     name,
+			Parent:   source,
+			State:    newState,
+			Category: "K8sRequiredLabels",
+		},
+	}
+}
+
+func TestClient_Send(t *testing.T) {
+	ctx := context.Background()
+	endpoint := &recorder{t: t}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+	client := NewClient(testr.New(t), server.URL, []byte(secret))
+
+	transitions := []*securitycenter.Transition{
+		transition("1", securitycenterpb.Finding_STATE_UNSPECIFIED, securitycenterpb.Finding_ACTIVE),
+		transition("2", securitycenterpb.Finding_INACTIVE, securitycenterpb.Finding_ACTIVE),
+		transition("3", securitycenterpb.Finding_ACTIVE, securitycenterpb.Finding_INACTIVE),
+		transition("4", securitycenterpb.Finding_STATE_UNSPECIFIED, securitycenterpb.Finding_INACTIVE), // not sent
+	}
+	if err := client.Send(ctx, transitions); err != nil {
+		t.Fatal(err)
+	}
+	wantTypes := map[string]string{
+		source + "/findings/1": EventTypeCreated,
+		source + "/findings/2": EventTypeReactivated,
+		source + "/findings/3": EventTypeResolved,
+	}
+	if len(endpoint.events) != len(wantTypes) {
+		t.Fatalf("expected %d events, got %d", len(wantTypes), len(endpoint.events))
+	}
+	for _, event := range endpoint.events {
+		if event.Type != wantTypes[event.Subject] {
+			t.Errorf("expected type %s for %s, got %s", wantTypes[event.Subject], event.Subject, event.Type)
+		}
+		if event.SpecVersion != cloudEventsSpecVersion {
+			t.Errorf("expected specversion %s, got %s", cloudEventsSpecVersion, event.SpecVersion)
+		}
+		if event.Source != "//securitycenter.googleapis.com/"+source {
+			t.Errorf("unexpected source %s", event.Source)
+		}
+		if event.Data.FindingName != event.Subject {
+			t.Errorf("expected data.findingName %s, got %s", event.Subject, event.Data.FindingName)
+		}
+	}
+
+	// unchanged transitions are not sent again
+	if err := client.Send(ctx, transitions[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if len(endpoint.events) != len(wantTypes) {
+		t.Errorf("expected no new events, got %d", len(endpoint.events)-len(wantTypes))
+	}
+}
+
+func TestClient_SendRetry(t *testing.T) {
+	ctx := context.Background()
+	endpoint := &recorder{t: t, failures: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+	client := NewClient(testr.New(t), server.URL, []byte(secret))
+	if err := client.SetRetry(3, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Send(ctx, []*securitycenter.Transition{
+		transition("1", securitycenterpb.Finding_STATE_UNSPECIFIED, securitycenterpb.Finding_ACTIVE),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(endpoint.events) != 1 {
+		t.Errorf("expected 1 event after retries, got %d", len(endpoint.events))
+	}
+}
+
+func TestClient_SendPending(t *testing.T) {
+	ctx := context.Background()
+	endpoint := &recorder{t: t, failures: []int{http.StatusServiceUnavailable}}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+	client := NewClient(testr.New(t), server.URL, []byte(secret))
+	if err := client.SetRetry(1, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// the event is kept when the endpoint is unavailable
+	if err := client.Send(ctx, []*securitycenter.Transition{
+		transition("1", securitycenterpb.Finding_ACTIVE, securitycenterpb.Finding_INACTIVE),
+	}); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(endpoint.events) != 0 {
+		t.Fatalf("expected no events, got %d", len(endpoint.events))
+	}
+
+	// the pending event is delivered on the next call
+	if err := client.Send(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(endpoint.events) != 1 || endpoint.events[0].Type != EventTypeResolved {
+		t.Errorf("expected 1 resolved event, got %+v", endpoint.events)
+	}
+	if len(client.delivered) != 0 {
+		t.Errorf("expected delivered INACTIVE findings to be pruned, got %+v", client.delivered)
+	}
+}
+
+func TestClient_SendPendingTransitions(t *testing.T) {
+	ctx := context.Background()
+	endpoint := &recorder{t: t, failures: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable}}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+	client := NewClient(testr.New(t), server.URL, []byte(secret))
+	if err := client.SetRetry(1, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// the finding is created and resolved while the endpoint is unavailable
+	if err := client.Send(ctx, []*securitycenter.Transition{
+		transition("1", securitycenterpb.Finding_STATE_UNSPECIFIED, securitycenterpb.Finding_ACTIVE),
+	}); err == nil {
+		t.Fatal("expected error")
+	}
+	if err := client.Send(ctx, []*securitycenter.Transition{
+		transition("1", securitycenterpb.Finding_ACTIVE, securitycenterpb.Finding_INACTIVE),
+	}); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(client.pending) != 2 {
+		t.Fatalf("expected 2 pending events, got %d", len(client.pending))
+	}
+
+	// both events are delivered in order
+	if err := client.Send(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, event := range endpoint.events {
+		got = append(got, event.Type)
+	}
+	if diff := cmp.Diff([]string{EventTypeCreated, EventTypeResolved}, got); diff != "" {
+		t.Errorf("delivered events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClient_SendRejected(t *testing.T) {
+	ctx := context.Background()
+	endpoint := &recorder{t: t, failures: []int{http.StatusBadRequest}}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+	client := NewClient(testr.New(t), server.URL, []byte(secret))
+
+	// client errors are not retried, and the event is dropped
+	if err := client.Send(ctx, []*securitycenter.Transition{
+		transition("1", securitycenterpb.Finding_ACTIVE, securitycenterpb.Finding_INACTIVE),
+		transition("2", securitycenterpb.Finding_ACTIVE, securitycenterpb.Finding_INACTIVE),
+	}); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(endpoint.events) != 1 || endpoint.events[0].Subject != source+"/findings/2" {
+		t.Fatalf("expected the event for finding 2 only, got %+v", endpoint.events)
+	}
+	if err := client.Send(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if endpoint.requests != 2 {
+		t.Errorf("expected the rejected event to be dropped, got %d requests", endpoint.requests)
+	}
+}
+
+func TestClient_SendUnavailable(t *testing.T) {
+	ctx := context.Background()
+	endpoint := &recorder{t: t, failures: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable}}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+	client := NewClient(testr.New(t), server.URL, []byte(secret))
+	if err := client.SetRetry(2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SetLimits(2, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	// delivery stops at the first event that can't be delivered after retries
+	if err := client.Send(ctx, []*securitycenter.Transition{
+		transition("1", securitycenterpb.Finding_STATE_UNSPECIFIED, securitycenterpb.Finding_ACTIVE),
+		transition("2", securitycenterpb.Finding_STATE_UNSPECIFIED, securitycenterpb.Finding_ACTIVE),
+	}); err == nil {
+		t.Fatal("expected error")
+	}
+	if endpoint.requests != 2 || len(client.pending) != 2 {
+		t.Fatalf("expected 2 requests and 2 pending events, got %d requests and %d pending events", endpoint.requests, len(client.pending))
+	}
+
+	// the oldest events are dropped when there are more than maxPending
+	if err := client.Send(ctx, []*securitycenter.Transition{
+		transition("3", securitycenterpb.Finding_STATE_UNSPECIFIED, securitycenterpb.Finding_ACTIVE),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, event := range endpoint.events {
+		got = append(got, event.Subject)
+	}
+	if diff := cmp.Diff([]string{source + "/findings/2", source + "/findings/3"}, got); diff != "" {
+		t.Errorf("delivered events mismatch (-want +got):\n%s", diff)
+	}
+}